@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToProto converts the user to its protobuf representation.
+func (u *User) ToProto() proto.Message {
+	return &pbv1.User{
+		Metadata:    objectMetaToProto(&u.ObjectMeta),
+		Status:      int64(u.Status),
+		Nickname:    u.Nickname,
+		Password:    u.Password,
+		Email:       u.Email,
+		Phone:       u.Phone,
+		IsAdmin:     int64(u.IsAdmin),
+		TotalPolicy: u.TotalPolicy,
+		LoginedAt:   timeToProto(u.LoginedAt),
+	}
+}
+
+// FromProto fills the user with the protobuf message.
+func (u *User) FromProto(msg proto.Message) error {
+	pb, ok := msg.(*pbv1.User)
+	if !ok {
+		return fmt.Errorf("unexpected protobuf message %T, want *v1.User", msg)
+	}
+
+	u.ObjectMeta = objectMetaFromProto(pb.GetMetadata())
+	u.Status = int(pb.GetStatus())
+	u.Nickname = pb.GetNickname()
+	u.Password = pb.GetPassword()
+	u.Email = pb.GetEmail()
+	u.Phone = pb.GetPhone()
+	u.IsAdmin = int(pb.GetIsAdmin())
+	u.TotalPolicy = pb.GetTotalPolicy()
+	u.LoginedAt = timeFromProto(pb.GetLoginedAt())
+
+	return nil
+}
+
+func objectMetaToProto(m *metav1.ObjectMeta) *pbv1.ObjectMeta {
+	pb := &pbv1.ObjectMeta{
+		Id:         m.ID,
+		InstanceId: m.InstanceID,
+		Name:       m.Name,
+		CreatedAt:  timeToProto(m.CreatedAt),
+		UpdatedAt:  timeToProto(m.UpdatedAt),
+	}
+
+	// Extend 中无法转换为 protobuf Struct 的值会被忽略
+	if len(m.Extend) != 0 {
+		if extend, err := structpb.NewStruct(m.Extend); err == nil {
+			pb.Extend = extend
+		}
+	}
+
+	return pb
+}
+
+func objectMetaFromProto(pb *pbv1.ObjectMeta) metav1.ObjectMeta {
+	m := metav1.ObjectMeta{
+		ID:         pb.GetId(),
+		InstanceID: pb.GetInstanceId(),
+		Name:       pb.GetName(),
+		CreatedAt:  timeFromProto(pb.GetCreatedAt()),
+		UpdatedAt:  timeFromProto(pb.GetUpdatedAt()),
+	}
+
+	if pb.GetExtend() != nil {
+		m.Extend = pb.GetExtend().AsMap()
+	}
+
+	return m
+}
+
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// Version 是 v1 API 的版本名。
+const Version = "v1"
+
+// ExtendReservedPrefix 是保留的 Extend 键前缀。
+// User 是所有版本的 hub 类型，其他版本中无法用 v1 字段表示的数据保存在使用该前缀的 Extend 键中。
+const ExtendReservedPrefix = "apiserver.sharkagent.io/"
+
+// AddToScheme 注册 v1 版本和类型。
+func AddToScheme(s *scheme.Scheme) {
+	s.AddVersion(scheme.Version{Name: Version})
+	s.AddKnownTypes(Version, &User{}, &UserList{})
+}
+
+// Hub marks User as the hub type, it is also the type saved in storage.
+func (*User) Hub() {}
+
+// Hub marks UserList as the hub type.
+func (*UserList) Hub() {}
+
+// PreserveReservedExtend 将 from 中使用 ExtendReservedPrefix 的 Extend 键复制到 u 中，u 中已有的键保持不变。
+// v1 请求中无法表示其他版本的数据，更新时使用它避免丢失这些数据。
+func (u *User) PreserveReservedExtend(from *User) {
+	for k, v := range from.Extend {
+		if !strings.HasPrefix(k, ExtendReservedPrefix) {
+			continue
+		}
+
+		if u.Extend == nil {
+			u.Extend = map[string]interface{}{}
+		}
+
+		if _, ok := u.Extend[k]; !ok {
+			u.Extend[k] = v
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package v1
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"gorm.io/gorm"
+)
 
 // User represents a user restful resource. It is also used as gorm model.
 type User struct {
@@ -28,4 +34,36 @@ type User struct {
 	TotalPolicy int64 `json:"totalPolicy" gorm:"-" validate:"omitempty"`
 
 	LoginedAt time.Time `json:"loginedAt,omitempty" gorm:"column:loginedAt"`
-}
\ No newline at end of file
+}
+
+// UserList is the whole list of all users which have been stored in storage.
+type UserList struct {
+	// May add TypeMeta in the future.
+	// metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata.
+	// +optional
+	metav1.ListMeta `json:",inline"`
+
+	Items []*User `json:"items"`
+}
+
+// instanceIDPrefix 是用户 InstanceID 的前缀。
+const instanceIDPrefix = "user-"
+
+// TableName maps to mysql table name.
+func (u *User) TableName() string {
+	return "user"
+}
+
+// AfterCreate run after create database record.
+// 没有指定 InstanceID 时在同一个事务中根据 ID 生成，避免空字符串触发 instanceID 唯一索引冲突。
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	if u.InstanceID != "" {
+		return nil
+	}
+
+	u.InstanceID = fmt.Sprintf("%s%d", instanceIDPrefix, u.ID)
+
+	return tx.Model(u).UpdateColumn("instanceID", u.InstanceID).Error
+}
@@ -11,8 +11,16 @@ func (u *User) Validate() field.ErrorList {
 	allErrs := val.Validate()
 
 	if err := validation.IsValidPassword(u.Password); err != nil {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("password"), err.Error(), ""))
+		allErrs = append(allErrs, field.Invalid(field.NewPath("password"), "", err.Error()))
 	}
 
 	return allErrs
-}
\ No newline at end of file
+}
+
+// ValidateUpdate validates that a user object is valid when update.
+// Like Validate but not validate password.
+func (u *User) ValidateUpdate() field.ErrorList {
+	val := validation.NewValidator(u)
+
+	return val.Validate()
+}
@@ -0,0 +1,181 @@
+package v2
+
+import (
+	"fmt"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// hub 中保存 v2 独有字段的 Extend 键。
+const (
+	extendGivenName  = v1.ExtendReservedPrefix + "v2.givenName"
+	extendFamilyName = v1.ExtendReservedPrefix + "v2.familyName"
+	// extendEmails 保存主邮箱以外的邮箱地址，主邮箱保存在 v1 的 email 字段中
+	extendEmails = v1.ExtendReservedPrefix + "v2.emails"
+)
+
+var (
+	_ scheme.Convertible = (*User)(nil)
+	_ scheme.Convertible = (*UserList)(nil)
+	_ scheme.Defaulter   = (*User)(nil)
+	_ scheme.Validator   = (*User)(nil)
+)
+
+// ConvertTo converts the user to the hub type.
+func (u *User) ConvertTo(hub scheme.Hub) error {
+	dst, ok := hub.(*v1.User)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T, want *v1.User", hub)
+	}
+
+	dst.ObjectMeta = u.ObjectMeta
+	dst.Extend = map[string]interface{}{}
+	for k, v := range u.Extend {
+		dst.Extend[k] = v
+	}
+
+	dst.Status = 0
+	if u.Status == UserStatusActive {
+		dst.Status = 1
+	}
+
+	dst.IsAdmin = 0
+	if u.IsAdmin {
+		dst.IsAdmin = 1
+	}
+
+	dst.Nickname = u.FullName.Display
+	dst.Password = u.Password
+	dst.Email = u.PrimaryEmail()
+	dst.Phone = u.Phone
+	dst.TotalPolicy = u.TotalPolicy
+	dst.LoginedAt = u.LoginedAt
+
+	if u.FullName.Given != "" {
+		dst.Extend[extendGivenName] = u.FullName.Given
+	}
+
+	if u.FullName.Family != "" {
+		dst.Extend[extendFamilyName] = u.FullName.Family
+	}
+
+	var others []interface{}
+	for _, e := range u.Emails {
+		if !e.Primary {
+			others = append(others, e.Address)
+		}
+	}
+
+	if len(others) != 0 {
+		dst.Extend[extendEmails] = others
+	}
+
+	return nil
+}
+
+// ConvertFrom fills the user with the hub type. 密码不会被转换。
+func (u *User) ConvertFrom(hub scheme.Hub) error {
+	src, ok := hub.(*v1.User)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T, want *v1.User", hub)
+	}
+
+	u.ObjectMeta = src.ObjectMeta
+	u.Extend = nil
+	for k, v := range src.Extend {
+		switch k {
+		case extendGivenName, extendFamilyName, extendEmails:
+			continue
+		}
+
+		if u.Extend == nil {
+			u.Extend = map[string]interface{}{}
+		}
+		u.Extend[k] = v
+	}
+
+	u.Status = UserStatusDisabled
+	if src.Status == 1 {
+		u.Status = UserStatusActive
+	}
+
+	u.FullName = FullName{
+		Given:   stringValue(src.Extend[extendGivenName]),
+		Family:  stringValue(src.Extend[extendFamilyName]),
+		Display: src.Nickname,
+	}
+
+	u.Emails = nil
+	if src.Email != "" {
+		u.Emails = append(u.Emails, Email{Address: src.Email, Primary: true})
+	}
+
+	// Extend 从存储中读取后，列表的类型为 []interface{}
+	switch others := src.Extend[extendEmails].(type) {
+	case []interface{}:
+		for _, e := range others {
+			u.Emails = append(u.Emails, Email{Address: stringValue(e)})
+		}
+	case []string:
+		for _, e := range others {
+			u.Emails = append(u.Emails, Email{Address: e})
+		}
+	}
+
+	u.Password = ""
+	u.Phone = src.Phone
+	u.IsAdmin = src.IsAdmin == 1
+	u.TotalPolicy = src.TotalPolicy
+	u.LoginedAt = src.LoginedAt
+
+	return nil
+}
+
+// ConvertTo converts the user list to the hub type.
+func (l *UserList) ConvertTo(hub scheme.Hub) error {
+	dst, ok := hub.(*v1.UserList)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T, want *v1.UserList", hub)
+	}
+
+	dst.ListMeta = l.ListMeta
+	dst.Items = make([]*v1.User, 0, len(l.Items))
+	for _, item := range l.Items {
+		user := &v1.User{}
+		if err := item.ConvertTo(user); err != nil {
+			return err
+		}
+
+		dst.Items = append(dst.Items, user)
+	}
+
+	return nil
+}
+
+// ConvertFrom fills the user list with the hub type.
+func (l *UserList) ConvertFrom(hub scheme.Hub) error {
+	src, ok := hub.(*v1.UserList)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T, want *v1.UserList", hub)
+	}
+
+	l.ListMeta = src.ListMeta
+	l.Items = make([]*User, 0, len(src.Items))
+	for _, item := range src.Items {
+		user := &User{}
+		if err := user.ConvertFrom(item); err != nil {
+			return err
+		}
+
+		l.Items = append(l.Items, user)
+	}
+
+	return nil
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+
+	return s
+}
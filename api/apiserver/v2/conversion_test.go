@@ -0,0 +1,77 @@
+package v2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+func TestUserRoundTrip(t *testing.T) {
+	in := &User{
+		ObjectMeta: metav1.ObjectMeta{Name: "colin", Extend: map[string]interface{}{"team": "iam"}},
+		Status:     UserStatusDisabled,
+		FullName:   FullName{Given: "Colin", Family: "Lee", Display: "colin"},
+		Emails: []Email{
+			{Address: "colin@example.com", Primary: true},
+			{Address: "colin@work.example.com"},
+		},
+		Phone:   "1812884xxxx",
+		IsAdmin: true,
+	}
+
+	hub := &v1.User{}
+	require.NoError(t, in.ConvertTo(hub))
+	assert.Equal(t, "colin", hub.Nickname)
+	assert.Equal(t, "colin@example.com", hub.Email)
+	assert.Equal(t, 0, hub.Status)
+	assert.Equal(t, 1, hub.IsAdmin)
+
+	// 模拟 Extend 经过存储后的 JSON 往返
+	data, err := json.Marshal(hub)
+	require.NoError(t, err)
+	stored := &v1.User{}
+	require.NoError(t, json.Unmarshal(data, stored))
+
+	out := &User{}
+	require.NoError(t, out.ConvertFrom(stored))
+	assert.Equal(t, in, out)
+}
+
+func TestDefault(t *testing.T) {
+	u := &User{
+		FullName: FullName{Given: "Colin", Family: "Lee"},
+		Emails:   []Email{{Address: "colin@example.com"}, {Address: "colin@work.example.com"}},
+	}
+	u.Default()
+
+	assert.Equal(t, UserStatusActive, u.Status)
+	assert.Equal(t, "Colin Lee", u.FullName.Display)
+	assert.Equal(t, "colin@example.com", u.PrimaryEmail())
+	assert.False(t, u.Emails[1].Primary)
+}
+
+func TestValidateUpdate(t *testing.T) {
+	u := &User{
+		Status:   UserStatusActive,
+		FullName: FullName{Display: "colin"},
+		Emails: []Email{
+			{Address: "colin@example.com", Primary: true},
+			{Address: "colin@example.com", Primary: true},
+		},
+	}
+
+	errs := u.ValidateUpdate()
+	require.Len(t, errs, 2)
+	assert.Equal(t, "emails[1].address", errs[0].Field)
+	assert.Equal(t, "emails", errs[1].Field)
+
+	u.Emails = u.Emails[:1]
+	assert.Empty(t, u.ValidateUpdate())
+
+	u.Status = "Unknown"
+	assert.Len(t, u.ValidateUpdate(), 1)
+}
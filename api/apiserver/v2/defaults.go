@@ -0,0 +1,19 @@
+package v2
+
+import "strings"
+
+// Default sets the default values of the user.
+// 状态默认为 Active；没有主邮箱时第一个邮箱为主邮箱；显示名为空时使用 Given 和 Family 生成。
+func (u *User) Default() {
+	if u.Status == "" {
+		u.Status = UserStatusActive
+	}
+
+	if len(u.Emails) != 0 && u.PrimaryEmail() == "" {
+		u.Emails[0].Primary = true
+	}
+
+	if u.FullName.Display == "" {
+		u.FullName.Display = strings.TrimSpace(u.FullName.Given + " " + u.FullName.Family)
+	}
+}
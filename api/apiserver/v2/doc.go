@@ -0,0 +1,5 @@
+// Package v2 is the v2 version of the apiserver API.
+//
+// 与 v1 相比，v2 的用户使用结构化的姓名、支持多个邮箱，状态使用字符串枚举。
+// v2 的类型不直接保存到存储中，而是通过 scheme 与 hub 类型（api/apiserver/v1）相互转换。
+package v2
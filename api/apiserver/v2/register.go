@@ -0,0 +1,12 @@
+package v2
+
+import "github.com/tiandh987/SharkAgent/pkg/scheme"
+
+// Version 是 v2 API 的版本名。
+const Version = "v2"
+
+// AddToScheme 注册 v2 版本和类型。
+func AddToScheme(s *scheme.Scheme) {
+	s.AddVersion(scheme.Version{Name: Version})
+	s.AddKnownTypes(Version, &User{}, &UserList{})
+}
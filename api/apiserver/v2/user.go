@@ -0,0 +1,80 @@
+package v2
+
+import (
+	"time"
+
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// UserStatus 是用户的状态。
+type UserStatus string
+
+const (
+	// UserStatusActive 表示用户可用。
+	UserStatusActive UserStatus = "Active"
+	// UserStatusDisabled 表示用户被禁用。
+	UserStatusDisabled UserStatus = "Disabled"
+)
+
+// FullName 是用户的结构化姓名。
+type FullName struct {
+	Given  string `json:"given,omitempty"  validate:"max=64"`
+	Family string `json:"family,omitempty" validate:"max=64"`
+
+	// Display 是显示名，对应 v1 的 nickname，为空时由 Given 和 Family 生成。
+	// Required: true
+	Display string `json:"display" validate:"required,min=1,max=30"`
+}
+
+// Email 是用户的一个邮箱地址。
+type Email struct {
+	// Required: true
+	Address string `json:"address" validate:"required,email,min=1,max=100"`
+
+	// Primary 标记主邮箱，每个用户有且只有一个主邮箱。
+	Primary bool `json:"primary,omitempty"`
+}
+
+// User represents a user restful resource.
+type User struct {
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status UserStatus `json:"status" validate:"omitempty,oneof=Active Disabled"`
+
+	FullName FullName `json:"fullName"`
+
+	// Password 只在创建用户时使用，响应中不会返回。
+	Password string `json:"password,omitempty" validate:"omitempty"`
+
+	// Required: true
+	Emails []Email `json:"emails" validate:"required,min=1,dive"`
+
+	Phone string `json:"phone,omitempty" validate:"omitempty"`
+
+	IsAdmin bool `json:"isAdmin"`
+
+	TotalPolicy int64 `json:"totalPolicy"`
+
+	LoginedAt time.Time `json:"loginedAt,omitempty"`
+}
+
+// UserList is the whole list of all users which have been stored in storage.
+type UserList struct {
+	// Standard list metadata.
+	// +optional
+	metav1.ListMeta `json:",inline"`
+
+	Items []*User `json:"items"`
+}
+
+// PrimaryEmail 返回主邮箱地址，没有主邮箱时返回空字符串。
+func (u *User) PrimaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Address
+		}
+	}
+
+	return ""
+}
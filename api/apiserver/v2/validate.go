@@ -0,0 +1,47 @@
+package v2
+
+import (
+	"github.com/tiandh987/SharkAgent/pkg/validation"
+	"github.com/tiandh987/SharkAgent/pkg/validation/field"
+)
+
+// Validate validates that a user object is valid.
+func (u *User) Validate() field.ErrorList {
+	allErrs := u.ValidateUpdate()
+
+	if u.Password == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("password"), "password is required"))
+	} else if err := validation.IsValidPassword(u.Password); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("password"), "", err.Error()))
+	}
+
+	return allErrs
+}
+
+// ValidateUpdate validates that a user object is valid when update.
+// Like Validate but not validate password.
+func (u *User) ValidateUpdate() field.ErrorList {
+	val := validation.NewValidator(u)
+	allErrs := val.Validate()
+
+	primary := 0
+	seen := map[string]bool{}
+	for i, e := range u.Emails {
+		if e.Primary {
+			primary++
+		}
+
+		if seen[e.Address] {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("emails").Index(i).Child("address"),
+				e.Address, "duplicate email address"))
+		}
+		seen[e.Address] = true
+	}
+
+	if len(u.Emails) != 0 && primary != 1 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("emails"), primary,
+			"exactly one email must be primary"))
+	}
+
+	return allErrs
+}
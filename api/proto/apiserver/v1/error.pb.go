@@ -0,0 +1,177 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: api/proto/apiserver/v1/error.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ErrResponse is the protobuf representation of core.ErrResponse.
+type ErrResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code      int64  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message   string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Reference string `protobuf:"bytes,3,opt,name=reference,proto3" json:"reference,omitempty"`
+	RequestId string `protobuf:"bytes,4,opt,name=request_id,json=requestID,proto3" json:"request_id,omitempty"`
+}
+
+func (x *ErrResponse) Reset() {
+	*x = ErrResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_apiserver_v1_error_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrResponse) ProtoMessage() {}
+
+func (x *ErrResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_apiserver_v1_error_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrResponse.ProtoReflect.Descriptor instead.
+func (*ErrResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_apiserver_v1_error_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ErrResponse) GetCode() int64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ErrResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ErrResponse) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *ErrResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+var File_api_proto_apiserver_v1_error_proto protoreflect.FileDescriptor
+
+var file_api_proto_apiserver_v1_error_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x70, 0x69, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x61, 0x70, 0x69, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x22, 0x78, 0x0a, 0x0b, 0x45, 0x72, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x44, 0x42, 0x3b, 0x5a, 0x39,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x69, 0x61, 0x6e, 0x64,
+	0x68, 0x39, 0x38, 0x37, 0x2f, 0x53, 0x68, 0x61, 0x72, 0x6b, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x2f,
+	0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x70, 0x69, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_api_proto_apiserver_v1_error_proto_rawDescOnce sync.Once
+	file_api_proto_apiserver_v1_error_proto_rawDescData = file_api_proto_apiserver_v1_error_proto_rawDesc
+)
+
+func file_api_proto_apiserver_v1_error_proto_rawDescGZIP() []byte {
+	file_api_proto_apiserver_v1_error_proto_rawDescOnce.Do(func() {
+		file_api_proto_apiserver_v1_error_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_proto_apiserver_v1_error_proto_rawDescData)
+	})
+	return file_api_proto_apiserver_v1_error_proto_rawDescData
+}
+
+var file_api_proto_apiserver_v1_error_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_api_proto_apiserver_v1_error_proto_goTypes = []interface{}{
+	(*ErrResponse)(nil), // 0: apiserver.v1.ErrResponse
+}
+var file_api_proto_apiserver_v1_error_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_api_proto_apiserver_v1_error_proto_init() }
+func file_api_proto_apiserver_v1_error_proto_init() {
+	if File_api_proto_apiserver_v1_error_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_api_proto_apiserver_v1_error_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ErrResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_api_proto_apiserver_v1_error_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_api_proto_apiserver_v1_error_proto_goTypes,
+		DependencyIndexes: file_api_proto_apiserver_v1_error_proto_depIdxs,
+		MessageInfos:      file_api_proto_apiserver_v1_error_proto_msgTypes,
+	}.Build()
+	File_api_proto_apiserver_v1_error_proto = out.File
+	file_api_proto_apiserver_v1_error_proto_rawDesc = nil
+	file_api_proto_apiserver_v1_error_proto_goTypes = nil
+	file_api_proto_apiserver_v1_error_proto_depIdxs = nil
+}
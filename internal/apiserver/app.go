@@ -14,6 +14,7 @@ func NewApp(basename string) *app.App {
 		basename,
 		app.WithOptions(opts),
 		app.WithRunFunc(run(opts)),
+		app.WithCommands(newMigrateCommand(), newKeysCommand()),
 	)
 
 	return application
@@ -0,0 +1,33 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware/auth"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// newCertAuth 创建客户端证书认证策略，将证书的 CommonName/SAN 映射为 user 表中可用的用户。
+// clientAuth 是 --secure.client-auth 的值，为 request 时没有客户端证书的请求不需要认证。
+func newCertAuth(storeIns store.Factory, clientAuth string) auth.CertStrategy {
+	return auth.NewCertStrategy(func(ctx context.Context, identity string) (string, error) {
+		user, err := storeIns.Users().Get(ctx, identity, metav1.GetOptions{})
+		if errors.Is(err, store.ErrNotFound) {
+			return "", fmt.Errorf("%w: user %s not found", auth.ErrUnknownIdentity, identity)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if user.Status != 1 {
+			return "", fmt.Errorf("%w: user %s is disabled", auth.ErrUnknownIdentity, user.Name)
+		}
+
+		return user.Name, nil
+	}, clientAuth == genericoptions.ClientAuthRequest)
+}
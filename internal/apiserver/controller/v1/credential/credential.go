@@ -0,0 +1,72 @@
+package credential
+
+import (
+	"context"
+
+	"github.com/marmotedu/errors"
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	srvv1 "github.com/tiandh987/SharkAgent/internal/apiserver/service/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/auth"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CredentialServer implements the gRPC CredentialService.
+// 供需要自己认证用户的服务查询用户凭证。
+type CredentialServer struct {
+	pbv1.UnimplementedCredentialServiceServer
+
+	srv srvv1.Service
+}
+
+var _ pbv1.CredentialServiceServer = (*CredentialServer)(nil)
+
+// NewCredentialServer creates a gRPC credential server.
+func NewCredentialServer(store store.Factory) *CredentialServer {
+	return &CredentialServer{
+		srv: srvv1.NewService(store, nil),
+	}
+}
+
+// GetCredential returns the credential of the user.
+// 不返回密码哈希，校验密码使用 VerifyPassword。
+func (s *CredentialServer) GetCredential(ctx context.Context,
+	r *pbv1.GetCredentialRequest) (*pbv1.Credential, error) {
+	log.L(ctx).Info("get credential function called.")
+
+	user, err := s.srv.Users().Get(ctx, r.GetUsername(), metav1.GetOptions{})
+	if err != nil {
+		return nil, core.GRPCError(ctx, err)
+	}
+
+	return &pbv1.Credential{
+		Username:  user.Name,
+		Status:    int64(user.Status),
+		IsAdmin:   user.IsAdmin == 1,
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}, nil
+}
+
+// VerifyPassword checks the password of the user.
+// 用户不存在、被禁用或密码错误时都只返回 valid=false，不区分具体原因。
+func (s *CredentialServer) VerifyPassword(ctx context.Context,
+	r *pbv1.VerifyPasswordRequest) (*pbv1.VerifyPasswordResponse, error) {
+	log.L(ctx).Info("verify password function called.")
+
+	user, err := s.srv.Users().Get(ctx, r.GetUsername(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return &pbv1.VerifyPasswordResponse{Valid: false}, nil
+		}
+
+		return nil, core.GRPCError(ctx, err)
+	}
+
+	valid := user.Status == 1 && auth.Compare(user.Password, r.GetPassword()) == nil
+
+	return &pbv1.VerifyPasswordResponse{Valid: valid}, nil
+}
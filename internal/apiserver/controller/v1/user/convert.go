@@ -0,0 +1,66 @@
+package user
+
+import (
+	"github.com/marmotedu/errors"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// userKind 是用户在 scheme 中注册的 kind。
+const userKind = "User"
+
+// newUser 创建请求版本的用户对象。
+func (u *UserController) newUser() (interface{}, error) {
+	obj, err := u.scheme.New(u.version, userKind)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	return obj, nil
+}
+
+// toHub 将请求版本的用户对象转换为 hub 类型。
+func (u *UserController) toHub(obj interface{}) (*v1.User, error) {
+	user := &v1.User{}
+	if err := u.scheme.ToHub(obj, user); err != nil {
+		return nil, errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	return user, nil
+}
+
+// external 将 hub 类型的对象转换为请求版本的对象，用于响应。
+// v1 和 hub 是相同的类型，转换时会复制密码哈希，这里清空，与 v2 和 gRPC 一样不返回密码。
+func (u *UserController) external(hub scheme.Hub) (interface{}, error) {
+	obj, err := u.fromHub(hub)
+	if err != nil {
+		return nil, err
+	}
+
+	switch out := obj.(type) {
+	case *v1.User:
+		out.Password = ""
+	case *v1.UserList:
+		// 复制时 Items 与 hub 共享底层数组和用户，清空前先复制，避免修改 hub 中的用户
+		items := make([]*v1.User, 0, len(out.Items))
+		for _, item := range out.Items {
+			user := *item
+			user.Password = ""
+			items = append(items, &user)
+		}
+		out.Items = items
+	}
+
+	return obj, nil
+}
+
+// fromHub 将 hub 类型的对象转换为请求版本的对象。
+func (u *UserController) fromHub(hub scheme.Hub) (interface{}, error) {
+	obj, err := u.scheme.FromHub(hub, u.version)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	return obj, nil
+}
@@ -3,39 +3,60 @@ package user
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/errors"
-	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
 	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/auth"
 	"github.com/tiandh987/SharkAgent/pkg/core"
 	"github.com/tiandh987/SharkAgent/pkg/log"
 	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
 )
 
 // Create add new user to the storage.
+// 请求体使用请求版本的类型，设置默认值并按该版本的规则校验后转换为 hub 类型保存。
 func (u *UserController) Create(c *gin.Context) {
 	log.L(c).Info("user create function called.")
 
-	var r v1.User
+	r, err := u.newUser()
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if err := core.Bind(c, r); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
 
-	if err := c.ShouldBindJSON(&r); err != nil {
-		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+	u.scheme.Default(r)
+	if errs := u.scheme.Validate(r); len(errs) != 0 {
+		core.WriteResponse(c, core.NewValidationError(errs), nil)
+
+		return
+	}
+
+	user, err := u.toHub(r)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
-	if errs := r.Validate(); len(errs) != 0 {
-		core.WriteResponse(c, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()), nil)
+	if user.Password, err = auth.Encrypt(user.Password); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrEncrypt, err.Error()), nil)
 
 		return
 	}
 
-	r.Password, _ = auth.Encrypt(r.Password)
-	r.Status = 1
+	user.Status = 1
 
 	// Insert the user to the storage.
-	if err := u.srv.Users().Create(c, &r, metav1.CreateOptions{}); err != nil {
+	if err := u.srv.Users().Create(c.Request.Context(), user, metav1.CreateOptions{}); err != nil {
 		core.WriteResponse(c, err, nil)
 
 		return
 	}
 
-	core.WriteResponse(c, nil, r)
\ No newline at end of file
+	out, err := u.external(user)
+	core.WriteResponse(c, err, out)
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/etag"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// Delete delete an user by the user identifier.
+// 请求带有 If-Match 时，只有用户在读取后没有被修改才会删除。
+func (u *UserController) Delete(c *gin.Context) {
+	log.L(c).Info("delete user function called.")
+
+	// 读取的用户用于 If-Match 检查，从主库读取，避免从库的复制延迟返回旧的 version
+	store.MarkWritten(c.Request.Context())
+
+	user, err := u.srv.Users().Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	conditional, err := etag.CheckIfMatch(c, u.etag(user))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	opts := metav1.DeleteOptions{Unscoped: true}
+	if conditional {
+		opts.Preconditions = preconditions(user)
+	}
+
+	if err := u.srv.Users().Delete(c.Request.Context(), user.Name, opts); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
@@ -0,0 +1,20 @@
+package user
+
+import (
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/pkg/etag"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// etag 根据 API 版本、用户的 ID 和 version 计算强 ETag，存储层每次写入都会增加 version，
+// 因此用户每次更新后都会变化，不同版本的表示使用不同的 ETag。
+func (u *UserController) etag(user *v1.User) string {
+	return etag.New(u.version, user.ID, user.Version)
+}
+
+// preconditions 返回在存储层检查用户没有被修改的前置条件。
+func preconditions(user *v1.User) *metav1.Preconditions {
+	version := user.Version
+
+	return &metav1.Preconditions{Version: &version}
+}
@@ -0,0 +1,29 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/internal/pkg/etag"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// Get get an user by the user identifier.
+// 响应带有 ETag，If-None-Match 匹配时返回 304 Not Modified。
+func (u *UserController) Get(c *gin.Context) {
+	log.L(c).Info("get user function called.")
+
+	user, err := u.srv.Users().Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if etag.NotModified(c, u.etag(user)) {
+		return
+	}
+
+	out, err := u.external(user)
+	core.WriteResponse(c, err, out)
+}
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	srvv1 "github.com/tiandh987/SharkAgent/internal/apiserver/service/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// UserServer implements the gRPC UserService.
+type UserServer struct {
+	pbv1.UnimplementedUserServiceServer
+
+	srv srvv1.Service
+}
+
+var _ pbv1.UserServiceServer = (*UserServer)(nil)
+
+// NewUserServer creates a gRPC user server.
+func NewUserServer(store store.Factory) *UserServer {
+	return &UserServer{
+		srv: srvv1.NewService(store, nil),
+	}
+}
+
+// GetUser get an user by the user identifier.
+func (s *UserServer) GetUser(ctx context.Context, r *pbv1.GetUserRequest) (*pbv1.User, error) {
+	log.L(ctx).Info("get user function called.")
+
+	user, err := s.srv.Users().Get(ctx, r.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, core.GRPCError(ctx, err)
+	}
+
+	return userToProto(user), nil
+}
+
+// ListUsers list the users in the storage.
+func (s *UserServer) ListUsers(ctx context.Context, r *pbv1.ListUsersRequest) (*pbv1.ListUsersResponse, error) {
+	log.L(ctx).Info("list user function called.")
+
+	opts := metav1.ListOptions{
+		FieldSelector: r.GetFieldSelector(),
+	}
+	if r.Offset != nil {
+		offset := r.GetOffset().GetValue()
+		opts.Offset = &offset
+	}
+	if r.Limit != nil {
+		limit := r.GetLimit().GetValue()
+		opts.Limit = &limit
+	}
+
+	users, err := s.srv.Users().List(ctx, opts)
+	if err != nil {
+		return nil, core.GRPCError(ctx, err)
+	}
+
+	items := make([]*pbv1.User, 0, len(users.Items))
+	for _, user := range users.Items {
+		items = append(items, userToProto(user))
+	}
+
+	return &pbv1.ListUsersResponse{
+		TotalCount: users.TotalCount,
+		Items:      items,
+	}, nil
+}
+
+// userToProto 转换用户，gRPC 响应中不包含密码。
+func userToProto(user *v1.User) *pbv1.User {
+	pb, _ := user.ToProto().(*pbv1.User)
+	pb.Password = ""
+
+	return pb
+}
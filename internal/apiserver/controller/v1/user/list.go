@@ -0,0 +1,108 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// List list the users in the storage.
+// watch=true 时不返回列表，而是以 Server-Sent Events 或 JSON lines 持续返回用户的 ADDED/MODIFIED/DELETED 事件，
+// 可以通过 resourceVersion 参数或 Last-Event-ID 请求头从指定的事件之后恢复。
+func (u *UserController) List(c *gin.Context) {
+	log.L(c).Info("list user function called.")
+
+	var r metav1.ListOptions
+	if err := c.ShouldBindQuery(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	if r.Watch {
+		u.watch(c, r)
+
+		return
+	}
+
+	users, err := u.srv.Users().List(c.Request.Context(), r)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	out, err := u.external(users)
+	core.WriteResponse(c, err, out)
+}
+
+func (u *UserController) watch(c *gin.Context, r metav1.ListOptions) {
+	r.ResourceVersion = watch.ResumeToken(c, r.ResourceVersion)
+
+	w, err := u.srv.Users().Watch(c.Request.Context(), r)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	// 事件日志中保存的是 hub 类型，需要转换为请求版本
+	obj, err := u.newUser()
+	if err != nil {
+		w.Stop()
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if _, ok := obj.(scheme.Hub); !ok {
+		ctx := c.Request.Context()
+		w = watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+			return u.convertEvent(ctx, in)
+		})
+	}
+
+	var timeout time.Duration
+	if r.TimeoutSeconds != nil {
+		timeout = time.Duration(*r.TimeoutSeconds) * time.Second
+	}
+
+	watch.StreamEvents(c, w, timeout)
+}
+
+// convertEvent 将事件中的 hub 类型对象转换为请求版本，转换失败的事件会被丢弃。
+func (u *UserController) convertEvent(ctx context.Context, in watch.Event) (watch.Event, bool) {
+	if in.Type == metav1.Error {
+		return in, true
+	}
+
+	user := &v1.User{}
+	if err := json.Unmarshal(in.Object, user); err != nil {
+		log.L(ctx).Errorf("decode %s event %d failed: %s", in.Type, in.ResourceVersion, err.Error())
+
+		return in, false
+	}
+
+	out, err := u.external(user)
+	if err == nil {
+		in.Object, err = json.Marshal(out)
+	}
+
+	if err != nil {
+		log.L(ctx).Errorf("convert %s event %d to %s failed: %s", in.Type, in.ResourceVersion, u.version, err.Error())
+
+		return in, false
+	}
+
+	return in, true
+}
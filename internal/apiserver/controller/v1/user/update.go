@@ -0,0 +1,117 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/etag"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// Update update a user info by the user identifier.
+// PUT 使用请求体替换用户可修改的字段，请求体中没有的字段会被清空。
+func (u *UserController) Update(c *gin.Context) {
+	log.L(c).Info("update user function called.")
+
+	u.update(c, func(*v1.User) (interface{}, error) { return u.newUser() })
+}
+
+// Patch 使用请求体中的字段修改用户信息，请求体中没有的字段保持不变。
+func (u *UserController) Patch(c *gin.Context) {
+	log.L(c).Info("patch user function called.")
+
+	u.update(c, func(user *v1.User) (interface{}, error) { return u.external(user) })
+}
+
+// update 读取当前用户，检查 If-Match，将 base 返回的请求版本对象绑定请求体、设置默认值后转换为 hub 类型，
+// 合并可修改的字段，按请求版本的规则校验后保存。
+// 请求带有 If-Match 时，存储层会原子地检查用户在读取后没有被修改，避免更新丢失。
+func (u *UserController) update(c *gin.Context, base func(user *v1.User) (interface{}, error)) {
+	// 读取的用户用于 If-Match 检查并且会被整体写回，从主库读取，避免从库的复制延迟覆盖更新的数据
+	store.MarkWritten(c.Request.Context())
+
+	user, err := u.srv.Users().Get(c.Request.Context(), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	conditional, err := etag.CheckIfMatch(c, u.etag(user))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	obj, err := base(user)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if err := core.Bind(c, obj); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	u.scheme.Default(obj)
+
+	r, err := u.toHub(obj)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	// hub 版本的请求无法表示其他版本的数据，这些数据保持不变
+	if _, ok := obj.(scheme.Hub); ok {
+		r.PreserveReservedExtend(user)
+	}
+
+	opts := metav1.UpdateOptions{}
+	if conditional {
+		opts.Preconditions = preconditions(user)
+	}
+
+	user.Nickname = r.Nickname
+	user.Email = r.Email
+	user.Phone = r.Phone
+	user.Extend = r.Extend
+
+	// 按请求版本的规则校验合并后的用户，校验时需要保留密码
+	out, err := u.fromHub(user)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if errs := u.scheme.ValidateUpdate(out); len(errs) != 0 {
+		core.WriteResponse(c, core.NewValidationError(errs), nil)
+
+		return
+	}
+
+	if err := u.srv.Users().Update(c.Request.Context(), user, opts); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	// 重新读取用户，使 ETag 与数据库中保存的 version 一致
+	if user, err = u.srv.Users().Get(c.Request.Context(), user.Name, metav1.GetOptions{}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	c.Header(etag.HeaderETag, u.etag(user))
+	out, err = u.external(user)
+	core.WriteResponse(c, err, out)
+}
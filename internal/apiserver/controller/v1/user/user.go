@@ -3,13 +3,25 @@ package user
 import (
 	srvv1 "github.com/tiandh987/SharkAgent/internal/apiserver/service/v1"
 	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
 )
 
+// UserController 处理一个 API 版本的用户请求。请求和响应使用该版本的类型，
+// 通过 scheme 与 hub 类型（v1.User）相互转换后交给 service 处理。
+// 调用 service 时使用 c.Request.Context()：gin.Context 的 Done 永远不会关闭，客户端断开后查询不会被取消。
 type UserController struct {
-	srv srvv1.Service
+	srv     srvv1.Service
+	scheme  *scheme.Scheme
+	version string
 }
-func NewUserController(store store.Factory) *UserController {
+
+// NewUserController creates a user handler for the given API version. userEvents 为 nil 时不支持 watch。
+func NewUserController(store store.Factory, userEvents *watch.Broadcaster, s *scheme.Scheme,
+	version string) *UserController {
 	return &UserController{
-		srv: srvv1.NewService(store),
+		srv:     srvv1.NewService(store, userEvents),
+		scheme:  s,
+		version: version,
 	}
-}
\ No newline at end of file
+}
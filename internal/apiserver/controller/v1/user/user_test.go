@@ -0,0 +1,176 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	v2 "github.com/tiandh987/SharkAgent/api/apiserver/v2"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+func newTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	s := scheme.NewScheme()
+	v1.AddToScheme(s)
+	v2.AddToScheme(s)
+
+	factory := memory.NewFactory()
+	g := gin.New()
+	for _, version := range s.Versions() {
+		u := NewUserController(factory, nil, s, version.Name)
+		users := g.Group("/" + version.Name + "/users")
+		users.POST("", u.Create)
+		users.GET("", u.List)
+		users.GET(":name", u.Get)
+		users.PATCH(":name", u.Patch)
+	}
+
+	return g
+}
+
+func serve(t *testing.T, g *gin.Engine, method, path, body string, obj interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), obj))
+}
+
+func TestUserController_Versions(t *testing.T) {
+	g := newTestEngine()
+
+	var created v2.User
+	serve(t, g, http.MethodPost, "/v2/users", `{
+		"metadata": {"name": "colin"},
+		"fullName": {"given": "Colin", "family": "Lee"},
+		"password": "Colin@2026",
+		"emails": [{"address": "colin@example.com"}, {"address": "colin@work.example.com"}]
+	}`, &created)
+	assert.Equal(t, "Colin Lee", created.FullName.Display)
+	assert.Equal(t, v2.UserStatusActive, created.Status)
+	assert.Empty(t, created.Password)
+
+	var old v1.User
+	serve(t, g, http.MethodGet, "/v1/users/colin", "", &old)
+	assert.Equal(t, "Colin Lee", old.Nickname)
+	assert.Equal(t, "colin@example.com", old.Email)
+	assert.Equal(t, 1, old.Status)
+
+	// v1 修改不会丢失 v2 独有的字段
+	serve(t, g, http.MethodPatch, "/v1/users/colin", `{"metadata": {"extend": {"team": "iam"}}, "phone": "1812884xxxx"}`, &old)
+
+	var got v2.User
+	serve(t, g, http.MethodGet, "/v2/users/colin", "", &got)
+	assert.Equal(t, "1812884xxxx", got.Phone)
+	assert.Equal(t, map[string]interface{}{"team": "iam"}, got.Extend)
+	assert.Equal(t, v2.FullName{Given: "Colin", Family: "Lee", Display: "Colin Lee"}, got.FullName)
+	assert.Equal(t, []v2.Email{
+		{Address: "colin@example.com", Primary: true},
+		{Address: "colin@work.example.com"},
+	}, got.Emails)
+}
+
+func TestUserController_HidePassword(t *testing.T) {
+	g := newTestEngine()
+
+	var created v1.User
+	serve(t, g, http.MethodPost, "/v1/users", `{
+		"metadata": {"name": "colin"},
+		"nickname": "colin",
+		"password": "Colin@2026",
+		"email": "colin@example.com"
+	}`, &created)
+	assert.Empty(t, created.Password)
+
+	for _, path := range []string{"/v1/users/colin", "/v1/users"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "colin@example.com")
+		assert.NotContains(t, w.Body.String(), "password")
+	}
+
+	var patched v1.User
+	serve(t, g, http.MethodPatch, "/v1/users/colin", `{"phone": "1812884xxxx"}`, &patched)
+	assert.Equal(t, "1812884xxxx", patched.Phone)
+	assert.Empty(t, patched.Password)
+}
+
+// primaryReads 记录每次 Get 是否会读取主库。
+type primaryReads struct {
+	store.Factory
+	reads []bool
+}
+
+func (f *primaryReads) Users() store.UserStore {
+	return &primaryReadUsers{UserStore: f.Factory.Users(), f: f}
+}
+
+type primaryReadUsers struct {
+	store.UserStore
+	f *primaryReads
+}
+
+func (u *primaryReadUsers) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	u.f.reads = append(u.f.reads, store.Written(ctx))
+
+	return u.UserStore.Get(ctx, username, opts)
+}
+
+func TestUserController_ReadPrimaryBeforeWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := scheme.NewScheme()
+	v1.AddToScheme(s)
+
+	factory := &primaryReads{Factory: memory.NewFactory()}
+	require.NoError(t, factory.Factory.Users().Create(context.Background(), &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "colin"},
+		Nickname:   "colin",
+		Password:   "hash",
+		Email:      "colin@example.com",
+	}, metav1.CreateOptions{}))
+
+	u := NewUserController(factory, nil, s, "v1")
+	g := gin.New()
+	g.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(store.WithSession(c.Request.Context()))
+	})
+	g.GET("/v1/users/:name", u.Get)
+	g.PATCH("/v1/users/:name", u.Patch)
+	g.DELETE("/v1/users/:name", u.Delete)
+
+	var user v1.User
+	serve(t, g, http.MethodGet, "/v1/users/colin", "", &user)
+	assert.Equal(t, []bool{false}, factory.reads)
+
+	// 修改和删除之前的读取使用主库
+	factory.reads = nil
+	serve(t, g, http.MethodPatch, "/v1/users/colin", `{"nickname": "lee"}`, &user)
+	require.NotEmpty(t, factory.reads)
+	assert.True(t, factory.reads[0])
+
+	factory.reads = nil
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/users/colin", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []bool{true}, factory.reads)
+}
@@ -0,0 +1,144 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/controller/v1/credential"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/controller/v1/user"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	genericapiserver "github.com/tiandh987/SharkAgent/internal/pkg/server"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// grpcShutdownTimeout 是等待 gRPC 请求处理完成的时间，超时后强制关闭连接。
+const grpcShutdownTimeout = 10 * time.Second
+
+// grpcConfig 包含创建 gRPC 服务所需的配置。
+type grpcConfig struct {
+	Addr       string
+	MaxMsgSize int
+	// SecureServing 不为 nil 时使用 TLS，证书和客户端证书校验配置与 HTTPS 服务相同
+	SecureServing *genericapiserver.SecureServingInfo
+	// ClientAuth 是 --secure.client-auth 的值，不为 none 时使用客户端证书认证除健康检查外的所有请求
+	ClientAuth string
+}
+
+type grpcAPIServer struct {
+	*grpc.Server
+	address string
+
+	health         *health.Server
+	stopCertReload func()
+}
+
+// newGRPCAPIServer 创建 gRPC 服务，注册 UserService、CredentialService、健康检查和反射服务。
+// CredentialService 只在使用 TLS 并且强制校验客户端证书时注册。
+func newGRPCAPIServer(c *grpcConfig, storeIns store.Factory) (*grpcAPIServer, error) {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxMsgSize),
+		grpc.MaxSendMsgSize(c.MaxMsgSize),
+	}
+
+	stopCertReload := func() {}
+
+	if c.SecureServing != nil {
+		if !c.SecureServing.HasCertificate() {
+			return nil, fmt.Errorf("--grpc.tls requires a TLS certificate configured by --secure.tls.*")
+		}
+
+		tlsConfig, stop, err := c.SecureServing.ServingTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		stopCertReload = stop
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	if c.ClientAuth != genericoptions.ClientAuthNone {
+		certAuth := newCertAuth(storeIns, c.ClientAuth)
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(certAuth.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(certAuth.StreamServerInterceptor()),
+		)
+	}
+
+	srv := grpc.NewServer(opts...)
+
+	pbv1.RegisterUserServiceServer(srv, user.NewUserServer(storeIns))
+
+	// CredentialService 可以校验任意用户的密码，只允许通过 TLS 并且校验过客户端证书的调用方访问
+	if c.SecureServing != nil && c.ClientAuth == genericoptions.ClientAuthRequireAndVerify {
+		pbv1.RegisterCredentialServiceServer(srv, credential.NewCredentialServer(storeIns))
+	} else {
+		log.Warnf("CredentialService is disabled, it requires --grpc.tls and --secure.client-auth=%s",
+			genericoptions.ClientAuthRequireAndVerify)
+	}
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	reflection.Register(srv)
+
+	return &grpcAPIServer{
+		Server:         srv,
+		address:        c.Addr,
+		health:         healthServer,
+		stopCertReload: stopCertReload,
+	}, nil
+}
+
+// Listen 监听 gRPC 服务的地址。
+func (s *grpcAPIServer) Listen() (net.Listener, error) {
+	ln, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on grpc address %s: %w", s.address, err)
+	}
+
+	return ln, nil
+}
+
+// Run 在 ln 上提供 gRPC 服务，直到服务被关闭，服务失败时返回错误。
+func (s *grpcAPIServer) Run(ln net.Listener) error {
+	log.Infof("start grpc server at %s", s.address)
+
+	if err := s.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("grpc server on %s failed: %w", s.address, err)
+	}
+
+	return nil
+}
+
+// Close graceful shutdown the grpc server.
+// 健康检查先返回 NOT_SERVING，等待正在处理的请求完成，超过 grpcShutdownTimeout 后强制关闭。
+func (s *grpcAPIServer) Close() {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(grpcShutdownTimeout):
+		log.Warnf("GRPC server on %s did not stop in %s, force stop", s.address, grpcShutdownTimeout)
+		s.Stop()
+	}
+
+	s.stopCertReload()
+
+	log.Infof("GRPC server on %s stopped", s.address)
+}
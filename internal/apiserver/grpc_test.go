@@ -0,0 +1,123 @@
+package apiserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	srvv1 "github.com/tiandh987/SharkAgent/internal/apiserver/service/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	genericapiserver "github.com/tiandh987/SharkAgent/internal/pkg/server"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+)
+
+func TestNewGRPCAPIServer_CredentialServiceRequiresTLS(t *testing.T) {
+	s, err := newGRPCAPIServer(&grpcConfig{
+		Addr:       "127.0.0.1:0",
+		MaxMsgSize: 1024,
+		ClientAuth: genericoptions.ClientAuthNone,
+	}, memory.NewFactory())
+	require.NoError(t, err)
+
+	services := s.GetServiceInfo()
+	assert.Contains(t, services, "apiserver.v1.UserService")
+	assert.NotContains(t, services, "apiserver.v1.CredentialService")
+}
+
+func newTestGRPCAPIServer(t *testing.T, storeIns store.Factory) *grpcAPIServer {
+	t.Helper()
+
+	s, err := newGRPCAPIServer(&grpcConfig{
+		Addr:       "127.0.0.1:0",
+		MaxMsgSize: 1024,
+		ClientAuth: genericoptions.ClientAuthNone,
+	}, storeIns)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestGRPCAPIServer_Run(t *testing.T) {
+	s := newTestGRPCAPIServer(t, memory.NewFactory())
+
+	// 地址被占用时返回错误，不会退出进程
+	used, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer used.Close()
+
+	s.address = used.Addr().String()
+	_, err = s.Listen()
+	assert.Error(t, err)
+
+	// 监听失败时返回错误
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+	assert.Error(t, s.Run(closed))
+
+	// 关闭服务后 Run 正常返回
+	s = newTestGRPCAPIServer(t, memory.NewFactory())
+	ln, err := s.Listen()
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ln) }()
+
+	s.Close()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Close")
+	}
+}
+
+// closeRecorder 在 Close 时记录 gRPC 服务是否已经停止接受连接。
+type closeRecorder struct {
+	store.Factory
+	address       string
+	serverStopped bool
+}
+
+func (f *closeRecorder) Close() error {
+	conn, err := net.Dial("tcp", f.address)
+	if err == nil {
+		conn.Close()
+	}
+	f.serverStopped = err != nil
+
+	return f.Factory.Close()
+}
+
+func TestAPIServer_StopClosesStoreLast(t *testing.T) {
+	storeIns := memory.NewFactory()
+	grpcServer := newTestGRPCAPIServer(t, storeIns)
+
+	ln, err := grpcServer.Listen()
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() { served <- grpcServer.Run(ln) }()
+
+	recorder := &closeRecorder{Factory: storeIns, address: ln.Addr().String()}
+	s := &apiServer{
+		gRPCAPIServer:    grpcServer,
+		genericAPIServer: &genericapiserver.GenericAPIServer{},
+		userEvents:       watch.NewBroadcaster(srvv1.NewUserEventSource(storeIns), time.Second),
+		storeIns:         recorder,
+	}
+
+	// 服务失败时关闭其他服务，最后关闭存储
+	assert.Equal(t, assert.AnError, s.stopOnError(assert.AnError))
+	assert.True(t, recorder.serverStopped)
+	assert.NoError(t, <-served)
+
+	// 只会关闭一次
+	recorder.serverStopped = false
+	s.stop()
+	assert.False(t, recorder.serverStopped)
+}
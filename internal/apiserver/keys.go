@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/options"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/pii"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	"github.com/tiandh987/SharkAgent/pkg/app"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"gorm.io/gorm"
+)
+
+// newKeysCommand 创建 keys 子命令，管理加密用户 email 和 phone 的数据密钥。
+// 执行前需要使用 migrate up 创建 data_key 表。
+func newKeysCommand() *app.Command {
+	opts := options.NewKeysOptions()
+
+	cmd := app.NewCommand("keys", "Manage the data keys encrypting the email and phone of users.")
+	cmd.AddCommands(
+		app.NewCommand("rotate", "Create a new data key and re-encrypt all users with it in batches. "+
+			"Running apiservers switch to the new key when they read a user encrypted by it or restart, "+
+			"run reencrypt again after all of them have switched.",
+			app.WithCommandOptions(opts),
+			app.WithCommandRunFunc(runKeys(opts, true)),
+		),
+		app.NewCommand("reencrypt", "Re-encrypt users not encrypted with the latest data key, "+
+			"e.g. users created before --encryption.enabled or left by an interrupted rotate.",
+			app.WithCommandOptions(opts),
+			app.WithCommandRunFunc(runKeys(opts, false)),
+		),
+	)
+
+	return cmd
+}
+
+// runKeys 打开数据库并加载数据密钥，rotate 为 true 时先创建新的数据密钥，然后重新加密用户。
+func runKeys(opts *options.KeysOptions, rotate bool) app.RunCommandFunc {
+	return func(args []string) error {
+		log.Init(opts.Log)
+		defer log.Flush()
+
+		masterKey, err := opts.Encryption.MasterKey()
+		if err != nil {
+			return err
+		}
+
+		db, closeDB, err := openDB(opts.Store, opts.MySQLOptions, opts.SQLiteOptions)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		return reencryptUsers(context.Background(), db, masterKey, opts.BatchSize, rotate)
+	}
+}
+
+func reencryptUsers(ctx context.Context, db *gorm.DB, masterKey []byte, batchSize int, rotate bool) error {
+	keys, err := encryption.LoadKeyring(ctx, db, masterKey)
+	if err != nil {
+		return err
+	}
+
+	if rotate {
+		version, err := keys.Rotate(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created data key version %d\n", version)
+	}
+
+	result, err := pii.Reencrypt(ctx, db, keys, batchSize, func(r pii.ReencryptResult) {
+		log.Infof("Re-encrypted %d users, skipped %d modified users", r.Updated, r.Skipped)
+	})
+	if err != nil {
+		return fmt.Errorf("re-encrypt users with data key %d failed after %d users: %w",
+			keys.Current(), result.Updated, err)
+	}
+
+	fmt.Printf("Re-encrypted %d users with data key version %d\n", result.Updated, keys.Current())
+
+	if result.Skipped != 0 {
+		fmt.Printf("%d users were modified during re-encryption, run keys reencrypt again\n", result.Skipped)
+	}
+
+	return nil
+}
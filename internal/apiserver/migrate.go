@@ -0,0 +1,158 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/options"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/mysql"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/sqlite"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	"github.com/tiandh987/SharkAgent/pkg/app"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"github.com/tiandh987/SharkAgent/pkg/migrate"
+	"gorm.io/gorm"
+)
+
+// newMigrateCommand 创建 migrate 子命令，管理 --store.driver 指定的数据库的表结构。
+func newMigrateCommand() *app.Command {
+	opts := options.NewMigrateOptions()
+
+	cmd := app.NewCommand("migrate", "Manage the database schema migrations.")
+	cmd.AddCommands(
+		app.NewCommand("up", "Apply all pending migrations.",
+			app.WithCommandOptions(opts),
+			app.WithCommandRunFunc(runMigrate(opts, func(ctx context.Context, m *migrate.Migrator, _ []string) error {
+				return m.Up(ctx)
+			})),
+		),
+		app.NewCommand("down", "Roll back the latest applied migration.",
+			app.WithCommandOptions(opts),
+			app.WithCommandRunFunc(runMigrate(opts, func(ctx context.Context, m *migrate.Migrator, _ []string) error {
+				return m.Down(ctx)
+			})),
+		),
+		app.NewCommand("to VERSION", "Apply or roll back migrations until the schema is at VERSION, 0 rolls back all.",
+			app.WithCommandOptions(opts),
+			app.WithCommandRunFunc(runMigrate(opts, migrateTo)),
+		),
+		app.NewCommand("status", "Show the applied and pending migrations.",
+			app.WithCommandOptions(opts),
+			app.WithCommandRunFunc(runMigrate(opts, printMigrateStatus)),
+		),
+	)
+
+	return cmd
+}
+
+// runMigrate 打开数据库并创建对应的 Migrator 后执行 fn。
+func runMigrate(opts *options.MigrateOptions,
+	fn func(ctx context.Context, m *migrate.Migrator, args []string) error) app.RunCommandFunc {
+	return func(args []string) error {
+		log.Init(opts.Log)
+		defer log.Flush()
+
+		m, closeDB, err := newMigrator(opts)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		return fn(context.Background(), m, args)
+	}
+}
+
+// newMigrator 打开 --store.driver 指定的数据库，返回 Migrator 和关闭数据库的函数。
+func newMigrator(opts *options.MigrateOptions) (*migrate.Migrator, func(), error) {
+	migrator := mysql.NewMigrator
+	if opts.Store.Driver == options.StoreSQLite {
+		migrator = sqlite.NewMigrator
+	}
+
+	db, closeDB, err := openDB(opts.Store, opts.MySQLOptions, opts.SQLiteOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := migrator(db)
+	if err != nil {
+		closeDB()
+
+		return nil, nil, err
+	}
+
+	return m, closeDB, nil
+}
+
+// openDB 打开 --store.driver 指定的数据库，返回数据库和关闭数据库的函数。
+func openDB(storeOpts *options.StoreOptions, mysqlOpts *genericoptions.MySQLOptions,
+	sqliteOpts *genericoptions.SQLiteOptions) (*gorm.DB, func(), error) {
+	open := mysqlOpts.NewClient
+	if storeOpts.Driver == options.StoreSQLite {
+		open = sqliteOpts.NewClient
+	}
+
+	db, err := open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeDB := func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+
+	return db, closeDB, nil
+}
+
+func migrateTo(ctx context.Context, m *migrate.Migrator, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("migrate to requires exactly one VERSION argument")
+	}
+
+	version, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid migration version %q", args[0])
+	}
+
+	return m.To(ctx, version)
+}
+
+func printMigrateStatus(ctx context.Context, m *migrate.Migrator, _ []string) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied := false
+	for _, s := range status {
+		applied = applied || s.Applied
+	}
+
+	if !applied {
+		fmt.Println("no migrations applied")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, s := range status {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+
+		if s.Dirty {
+			state = "dirty"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,289 @@
+package apiserver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/config"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/options"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/openapi"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+	"github.com/tiandh987/SharkAgent/pkg/version"
+)
+
+// OpenAPIPath 是 OpenAPI 3 文档的访问路径。
+const OpenAPIPath = "/openapi/v3"
+
+// routeDoc 描述一个路由的 OpenAPI 信息。
+type routeDoc struct {
+	Tag         string
+	Summary     string
+	OperationID string
+	// RequestKind 为请求体在 scheme 中的 kind，为空表示没有请求体
+	RequestKind string
+	// ResponseKind 为成功时的响应体在 scheme 中的 kind
+	ResponseKind string
+	// Errors 为该路由可能返回的错误码
+	Errors []int
+}
+
+// routeDocs 保存已注册路由的文档，key 为 "METHOD path"，版本化的路由不包含版本前缀，
+// 例如 "POST /users" 同时描述 POST /v1/users 和 POST /v2/users。新增路由时需要在这里补充文档。
+var routeDocs = map[string]routeDoc{
+	"POST /users": {
+		Tag:          "users",
+		Summary:      "Create a user",
+		OperationID:  "createUser",
+		RequestKind:  "User",
+		ResponseKind: "User",
+		Errors: []int{code.ErrBind, code.ErrValidation, code.ErrUserAlreadyExist,
+			code.ErrDatabase, code.ErrDatabaseUnavailable, code.ErrDatabaseTimeout},
+	},
+	"GET /users": {
+		Tag:          "users",
+		Summary:      "List users, or stream ADDED/MODIFIED/DELETED events as text/event-stream or application/x-ndjson with watch=true",
+		OperationID:  "listUsers",
+		ResponseKind: "UserList",
+		Errors: []int{code.ErrBind, code.ErrValidation,
+			code.ErrDatabase, code.ErrDatabaseUnavailable, code.ErrDatabaseTimeout},
+	},
+	"GET /users/:name": {
+		Tag:          "users",
+		Summary:      "Get a user, returns 304 when If-None-Match matches the ETag",
+		OperationID:  "getUser",
+		ResponseKind: "User",
+		Errors:       []int{code.ErrUserNotFound, code.ErrDatabase, code.ErrDatabaseUnavailable, code.ErrDatabaseTimeout},
+	},
+	"PUT /users/:name": {
+		Tag:          "users",
+		Summary:      "Replace the name, emails, phone and extend of a user, If-Match is checked",
+		OperationID:  "updateUser",
+		RequestKind:  "User",
+		ResponseKind: "User",
+		Errors: []int{code.ErrBind, code.ErrValidation, code.ErrUserNotFound, code.ErrPreconditionFailed,
+			code.ErrDatabase, code.ErrDatabaseUnavailable, code.ErrDatabaseTimeout},
+	},
+	"PATCH /users/:name": {
+		Tag:          "users",
+		Summary:      "Update the given fields of a user, If-Match is checked",
+		OperationID:  "patchUser",
+		RequestKind:  "User",
+		ResponseKind: "User",
+		Errors: []int{code.ErrBind, code.ErrValidation, code.ErrUserNotFound, code.ErrPreconditionFailed,
+			code.ErrDatabase, code.ErrDatabaseUnavailable, code.ErrDatabaseTimeout},
+	},
+	"DELETE /users/:name": {
+		Tag:         "users",
+		Summary:     "Delete a user, If-Match is checked",
+		OperationID: "deleteUser",
+		Errors: []int{code.ErrUserNotFound, code.ErrPreconditionFailed,
+			code.ErrDatabase, code.ErrDatabaseUnavailable, code.ErrDatabaseTimeout},
+	},
+	"GET " + ReadyzPath: {
+		Tag:         "health",
+		Summary:     "Check whether the store is available, returns 503 when it is not",
+		OperationID: "getReadiness",
+		Errors:      []int{code.ErrDatabaseUnavailable},
+	},
+	"GET " + OpenAPIPath: {
+		Tag:         "openapi",
+		Summary:     "Get the OpenAPI 3 specification of this server",
+		OperationID: "getOpenAPISpec",
+	},
+}
+
+// commonErrors 是所有路由都可能返回的错误码。
+var commonErrors = []int{code.ErrUnknown, code.ErrNotAcceptable}
+
+// buildOpenAPIDocument 根据已注册的路由、scheme 中各版本的类型和已注册的错误码生成 OpenAPI 文档。
+// 废弃版本的路由标记为 deprecated。
+func buildOpenAPIDocument(routes gin.RoutesInfo, apiScheme *scheme.Scheme) *openapi.Document {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "SharkAgent API Server",
+		Description: "SharkAgent apiserver RESTful API.",
+		Version:     version.Get().GitVersion,
+	})
+
+	errSchema := doc.SchemaFor(&core.ErrResponse{})
+	problemSchema := doc.SchemaFor(&core.Problem{})
+	documentErrorCodes(doc)
+
+	errorCodes := map[int]openapi.ErrorCode{}
+	for _, c := range code.List() {
+		errorCodes[c.C] = openapi.ErrorCode{Code: c.C, HTTPStatus: c.HTTPStatus(), Message: c.Ext}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+
+		return routes[i].Method < routes[j].Method
+	})
+
+	versions := apiScheme.Versions()
+
+	for _, route := range routes {
+		version, path := splitVersion(versions, route.Path)
+
+		rd, ok := routeDocs[route.Method+" "+path]
+		if !ok {
+			rd = routeDoc{Summary: route.Method + " " + route.Path}
+		}
+
+		op := &openapi.Operation{
+			Summary:     rd.Summary,
+			OperationID: rd.OperationID,
+			Responses:   map[string]*openapi.Response{},
+			Deprecated:  version.Deprecated,
+		}
+
+		// 第一个版本保持原来的 operationId，其他版本添加版本后缀，例如 createUserV2
+		if version.Name != "" && version.Name != versions[0].Name && op.OperationID != "" {
+			op.OperationID += strings.ToUpper(version.Name[:1]) + version.Name[1:]
+		}
+
+		if rd.Tag != "" {
+			op.Tags = []string{rd.Tag}
+		}
+
+		request := newKind(apiScheme, version.Name, rd.RequestKind)
+		if request != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  negotiatedContent(doc, request),
+			}
+		}
+
+		errs := append(append([]int{}, rd.Errors...), commonErrors...)
+		if request != nil {
+			errs = append(errs, code.ErrUnsupportedMediaType)
+		}
+
+		var codes []openapi.ErrorCode
+		for _, c := range errs {
+			if ec, ok := errorCodes[c]; ok {
+				codes = append(codes, ec)
+			}
+		}
+
+		for status, resp := range openapi.ErrorResponses(errSchema, codes...) {
+			resp.Content[core.MIMEProblemJSON] = &openapi.MediaType{Schema: problemSchema}
+			op.Responses[status] = resp
+		}
+
+		op.Responses["200"] = &openapi.Response{Description: "OK"}
+		if response := newKind(apiScheme, version.Name, rd.ResponseKind); response != nil {
+			op.Responses["200"].Content = negotiatedContent(doc, response)
+		}
+
+		doc.AddOperation(route.Method, route.Path, op)
+	}
+
+	return doc
+}
+
+// splitVersion 返回路径所属的 API 版本和去掉版本前缀后的路径，不属于任何版本时返回原路径。
+func splitVersion(versions []scheme.Version, path string) (scheme.Version, string) {
+	for _, v := range versions {
+		prefix := "/" + v.Name
+		if strings.HasPrefix(path, prefix+"/") {
+			return v, path[len(prefix):]
+		}
+	}
+
+	return scheme.Version{}, path
+}
+
+// newKind 创建版本中 kind 对应类型的对象，kind 为空或未注册时返回 nil。
+func newKind(apiScheme *scheme.Scheme, version, kind string) interface{} {
+	if kind == "" {
+		return nil
+	}
+
+	obj, err := apiScheme.New(version, kind)
+	if err != nil {
+		return nil
+	}
+
+	return obj
+}
+
+// negotiatedContent 返回 core.Bind 和 core.WriteResponse 支持的所有媒体类型的内容定义。
+func negotiatedContent(doc *openapi.Document, obj interface{}) map[string]*openapi.MediaType {
+	schema := doc.SchemaFor(obj)
+	content := map[string]*openapi.MediaType{
+		core.MIMEJSON: {Schema: schema},
+		core.MIMEYAML: {Schema: schema},
+	}
+
+	if _, ok := obj.(core.ProtoConverter); ok {
+		content[core.MIMEProtobuf] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string", Format: "binary"}}
+	}
+
+	return content
+}
+
+// documentErrorCodes 在 ErrResponse.code 中列出所有已注册的错误码。
+func documentErrorCodes(doc *openapi.Document) {
+	schema, ok := doc.Components.Schemas["ErrResponse"]
+	if !ok {
+		return
+	}
+
+	prop, ok := schema.Properties["code"]
+	if !ok {
+		return
+	}
+
+	lines := []string{"Business error code:"}
+	for _, c := range code.List() {
+		prop.Enum = append(prop.Enum, c.C)
+		lines = append(lines, fmt.Sprintf("- %d (HTTP %d): %s", c.C, c.HTTPStatus(), c.Ext))
+	}
+
+	prop.Description = strings.Join(lines, "\n")
+}
+
+// openAPIHandler 返回 OpenAPI 文档。文档在第一次请求时生成，此时所有路由都已注册。
+func openAPIHandler(g *gin.Engine, apiScheme *scheme.Scheme) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc = buildOpenAPIDocument(g.Routes(), apiScheme)
+		})
+
+		core.WriteResponse(c, nil, doc)
+	}
+}
+
+// OpenAPIDocument 使用默认配置注册路由并生成 OpenAPI 文档，供 tools/openapi-gen 使用。
+func OpenAPIDocument() (*openapi.Document, error) {
+	cfg, err := config.CreateConfigFromOptions(options.NewOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	apiScheme, err := newScheme(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只需要注册的路由，不访问存储
+	g := gin.New()
+	if err := installController(g, cfg, apiScheme, memory.NewFactory(), nil, routeMiddlewares{}); err != nil {
+		return nil, err
+	}
+
+	return buildOpenAPIDocument(g.Routes(), apiScheme), nil
+}
@@ -0,0 +1,75 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// apiserver 支持的用户缓存类型。
+const (
+	// CacheNone 不缓存用户。
+	CacheNone = "none"
+	// CacheMemory 在进程内缓存用户，多个 apiserver 实例之间的修改在 TTL 后才可见。
+	CacheMemory = "memory"
+	// CacheRedis 在 Redis 中缓存用户，连接配置见 --redis.*。
+	CacheRedis = "redis"
+)
+
+// CacheOptions 包含按用户名缓存用户的配置项。
+type CacheOptions struct {
+	Type string        `json:"type" mapstructure:"type"`
+	Size int           `json:"size" mapstructure:"size"`
+	TTL  time.Duration `json:"ttl"  mapstructure:"ttl"`
+}
+
+// NewCacheOptions creates a CacheOptions object with default parameters.
+func NewCacheOptions() *CacheOptions {
+	return &CacheOptions{
+		Type: CacheNone,
+		Size: 10000,
+		TTL:  30 * time.Second,
+	}
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *CacheOptions) Validate() []error {
+	var errs []error
+
+	switch o.Type {
+	case CacheNone:
+		return nil
+	case CacheMemory, CacheRedis:
+	default:
+		errs = append(errs, fmt.Errorf("--cache.type %q must be one of %s, %s or %s",
+			o.Type, CacheNone, CacheMemory, CacheRedis))
+	}
+
+	if o.Type == CacheMemory && o.Size <= 0 {
+		errs = append(errs, errors.New("--cache.size must be greater than 0"))
+	}
+
+	if o.TTL <= 0 {
+		errs = append(errs, errors.New("--cache.ttl must be greater than 0"))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to the user cache for a specific APIServer to the
+// specified FlagSet.
+func (o *CacheOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Type, "cache.type", o.Type, ""+
+		"Cache of users looked up by name, one of none, memory or redis. "+
+		"The memory cache is per process, changes made through other instances are visible after --cache.ttl. "+
+		"The redis cache cannot be used with --encryption.enabled.")
+
+	fs.IntVar(&o.Size, "cache.size", o.Size, ""+
+		"Maximum number of users kept in the memory cache.")
+
+	fs.DurationVar(&o.TTL, "cache.ttl", o.TTL, ""+
+		"Time after which a cached user expires.")
+}
@@ -0,0 +1,82 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	cliflag "github.com/tiandh987/SharkAgent/pkg/cli/flag"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// KeysOptions 是 keys 子命令使用的配置，与 Options 中对应的配置项使用相同的名称，
+// 因此可以和 apiserver 使用同一个配置文件。
+type KeysOptions struct {
+	Log           *log.Options                      `json:"log"        mapstructure:"log"`
+	Store         *StoreOptions                     `json:"store"      mapstructure:"store"`
+	MySQLOptions  *genericoptions.MySQLOptions      `json:"mysql"      mapstructure:"mysql"`
+	SQLiteOptions *genericoptions.SQLiteOptions     `json:"sqlite"     mapstructure:"sqlite"`
+	Encryption    *genericoptions.EncryptionOptions `json:"encryption" mapstructure:"encryption"`
+
+	// BatchSize 是重新加密时每个事务处理的用户数
+	BatchSize int `json:"batch-size" mapstructure:"batch-size"`
+}
+
+// NewKeysOptions 使用默认参数创建一个 KeysOptions 对象
+func NewKeysOptions() *KeysOptions {
+	return &KeysOptions{
+		Log:           log.NewOptions(),
+		Store:         NewStoreOptions(),
+		MySQLOptions:  genericoptions.NewMySQLOptions(),
+		SQLiteOptions: genericoptions.NewSQLiteOptions(),
+		Encryption:    genericoptions.NewEncryptionOptions(),
+		BatchSize:     100,
+	}
+}
+
+// Flags returns flags for the keys commands by section name.
+func (o *KeysOptions) Flags() (fss cliflag.NamedFlagSets) {
+	o.Store.AddFlags(fss.FlagSet("store"))
+	o.MySQLOptions.AddFlags(fss.FlagSet("mysql"))
+	o.SQLiteOptions.AddFlags(fss.FlagSet("sqlite"))
+	o.Encryption.AddFlags(fss.FlagSet("encryption"))
+	o.addFlags(fss.FlagSet("keys"))
+
+	return fss
+}
+
+func (o *KeysOptions) addFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.BatchSize, "keys.batch-size", o.BatchSize, ""+
+		"Number of users re-encrypted in one transaction.")
+}
+
+// Validate checks KeysOptions and return a slice of found errs.
+func (o *KeysOptions) Validate() []error {
+	var errs []error
+
+	errs = append(errs, o.Log.Validate()...)
+	errs = append(errs, o.Store.Validate()...)
+
+	switch o.Store.Driver {
+	case StoreMySQL:
+		errs = append(errs, o.MySQLOptions.Validate()...)
+	case StoreSQLite:
+		errs = append(errs, o.SQLiteOptions.Validate()...)
+	case StoreMemory:
+		errs = append(errs, fmt.Errorf("--store.driver %s does not encrypt users", StoreMemory))
+	}
+
+	if !o.Encryption.Enabled {
+		errs = append(errs, errors.New("--encryption.enabled must be true to manage the data keys"))
+	}
+
+	errs = append(errs, o.Encryption.Validate()...)
+
+	if o.BatchSize <= 0 {
+		errs = append(errs, errors.New("--keys.batch-size must be greater than 0"))
+	}
+
+	return errs
+}
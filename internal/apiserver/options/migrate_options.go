@@ -0,0 +1,56 @@
+package options
+
+import (
+	"fmt"
+
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	cliflag "github.com/tiandh987/SharkAgent/pkg/cli/flag"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// MigrateOptions 是 migrate 子命令使用的配置，与 Options 中对应的配置项使用相同的名称，
+// 因此可以和 apiserver 使用同一个配置文件。
+type MigrateOptions struct {
+	Log           *log.Options                  `json:"log"    mapstructure:"log"`
+	Store         *StoreOptions                 `json:"store"  mapstructure:"store"`
+	MySQLOptions  *genericoptions.MySQLOptions  `json:"mysql"  mapstructure:"mysql"`
+	SQLiteOptions *genericoptions.SQLiteOptions `json:"sqlite" mapstructure:"sqlite"`
+}
+
+// NewMigrateOptions 使用默认参数创建一个 MigrateOptions 对象
+func NewMigrateOptions() *MigrateOptions {
+	return &MigrateOptions{
+		Log:           log.NewOptions(),
+		Store:         NewStoreOptions(),
+		MySQLOptions:  genericoptions.NewMySQLOptions(),
+		SQLiteOptions: genericoptions.NewSQLiteOptions(),
+	}
+}
+
+// Flags returns flags for the migrate commands by section name.
+func (o *MigrateOptions) Flags() (fss cliflag.NamedFlagSets) {
+	o.Store.AddFlags(fss.FlagSet("store"))
+	o.MySQLOptions.AddFlags(fss.FlagSet("mysql"))
+	o.SQLiteOptions.AddFlags(fss.FlagSet("sqlite"))
+
+	return fss
+}
+
+// Validate checks MigrateOptions and return a slice of found errs.
+func (o *MigrateOptions) Validate() []error {
+	var errs []error
+
+	errs = append(errs, o.Log.Validate()...)
+	errs = append(errs, o.Store.Validate()...)
+
+	switch o.Store.Driver {
+	case StoreMySQL:
+		errs = append(errs, o.MySQLOptions.Validate()...)
+	case StoreSQLite:
+		errs = append(errs, o.SQLiteOptions.Validate()...)
+	case StoreMemory:
+		errs = append(errs, fmt.Errorf("--store.driver %s has no schema to migrate", StoreMemory))
+	}
+
+	return errs
+}
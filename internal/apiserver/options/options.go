@@ -1,6 +1,8 @@
 package options
 
 import (
+	"fmt"
+
 	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
 	cliflag "github.com/tiandh987/SharkAgent/pkg/cli/flag"
 	"github.com/tiandh987/SharkAgent/pkg/log"
@@ -19,9 +21,28 @@ type Options struct {
 	InsecureServing         *genericoptions.InsecureServingOptions `json:"insecure" mapstructure:"insecure"`
 	SecureServing           *genericoptions.SecureServingOptions   `json:"secure"   mapstructure:"secure"`
 	FeatureOptions          *genericoptions.FeatureOptions         `json:"feature"  mapstructure:"feature"`
+	RateLimit               *genericoptions.RateLimitOptions       `json:"ratelimit" mapstructure:"ratelimit"`
+	Idempotency             *genericoptions.IdempotencyOptions     `json:"idempotency" mapstructure:"idempotency"`
+	GRPCOptions             *genericoptions.GRPCOptions            `json:"grpc"     mapstructure:"grpc"`
+	APIVersions             *genericoptions.APIVersionOptions      `json:"api"      mapstructure:"api"`
+
+	// 存储后端：mysql、sqlite 或 memory
+	Store *StoreOptions `json:"store" mapstructure:"store"`
 
 	// mysql
 	MySQLOptions *genericoptions.MySQLOptions `json:"mysql"    mapstructure:"mysql"`
+
+	// sqlite
+	SQLiteOptions *genericoptions.SQLiteOptions `json:"sqlite"   mapstructure:"sqlite"`
+
+	// 用户缓存：none、memory 或 redis
+	Cache *CacheOptions `json:"cache" mapstructure:"cache"`
+
+	// redis
+	RedisOptions *genericoptions.RedisOptions `json:"redis"    mapstructure:"redis"`
+
+	// 加密用户的 email 和 phone
+	Encryption *genericoptions.EncryptionOptions `json:"encryption" mapstructure:"encryption"`
 }
 
 // NewOptions 使用默认参数创建一个 Options 对象
@@ -33,8 +54,17 @@ func NewOptions() *Options {
 		InsecureServing:         genericoptions.NewInsecureServingOptions(),
 		SecureServing:           genericoptions.NewSecureServingOptions(),
 		FeatureOptions:          genericoptions.NewFeatureOptions(),
+		RateLimit:               genericoptions.NewRateLimitOptions(),
+		Idempotency:             genericoptions.NewIdempotencyOptions(),
+		GRPCOptions:             genericoptions.NewGRPCOptions(),
+		APIVersions:             genericoptions.NewAPIVersionOptions(),
 
-		MySQLOptions: genericoptions.NewMySQLOptions(),
+		Store:         NewStoreOptions(),
+		MySQLOptions:  genericoptions.NewMySQLOptions(),
+		SQLiteOptions: genericoptions.NewSQLiteOptions(),
+		Cache:         NewCacheOptions(),
+		RedisOptions:  genericoptions.NewRedisOptions(),
+		Encryption:    genericoptions.NewEncryptionOptions(),
 	}
 
 	return &o
@@ -42,16 +72,67 @@ func NewOptions() *Options {
 
 // Flags returns flags for a specific APIServer by section name.
 func (o *Options) Flags() (fss cliflag.NamedFlagSets) {
+	o.RateLimit.AddFlags(fss.FlagSet("ratelimit"))
+	o.Idempotency.AddFlags(fss.FlagSet("idempotency"))
+	o.GRPCOptions.AddFlags(fss.FlagSet("grpc"))
+	o.APIVersions.AddFlags(fss.FlagSet("api"))
+	o.Store.AddFlags(fss.FlagSet("store"))
 	o.MySQLOptions.AddFlags(fss.FlagSet("mysql"))
+	o.SQLiteOptions.AddFlags(fss.FlagSet("sqlite"))
+	o.Cache.AddFlags(fss.FlagSet("cache"))
+	o.RedisOptions.AddFlags(fss.FlagSet("redis"))
+	o.Encryption.AddFlags(fss.FlagSet("encryption"))
 
 	return fss
 }
 
+// FlagAliases returns the deprecated flag names and the flags they are translated to.
+func (o *Options) FlagAliases() map[string]string {
+	return map[string]string{
+		// 增加 sqlite 存储时 --store 重命名为 --store.driver
+		"store": "store.driver",
+	}
+}
+
 // Validate checks Options and return a slice of found errs.
 func (o *Options) Validate() []error {
 	var errs []error
 
-	errs = append(errs, o.MySQLOptions.Validate()...)
+	errs = append(errs, o.RateLimit.Validate()...)
+	errs = append(errs, o.Idempotency.Validate()...)
+	errs = append(errs, o.GRPCOptions.Validate()...)
+	errs = append(errs, o.APIVersions.Validate()...)
+	errs = append(errs, o.Store.Validate()...)
+
+	// 只校验使用的存储后端的配置，幂等记录保存在 mysql 中时同样需要 mysql 配置
+	if o.Store.Driver == StoreMySQL ||
+		(o.Idempotency.Enabled && o.Idempotency.Store == genericoptions.IdempotencyStoreMySQL) {
+		errs = append(errs, o.MySQLOptions.Validate()...)
+	}
+
+	if o.Store.Driver == StoreSQLite {
+		errs = append(errs, o.SQLiteOptions.Validate()...)
+	}
+
+	// 不使用 TLS 时 gRPC 请求没有客户端证书，无法按 --secure.client-auth 认证
+	if o.GRPCOptions.BindPort != 0 && !o.GRPCOptions.TLS && o.SecureServing.ClientAuth != genericoptions.ClientAuthNone {
+		errs = append(errs, fmt.Errorf("--secure.client-auth %s requires --grpc.tls, or set --grpc.bind-port=0 to disable gRPC",
+			o.SecureServing.ClientAuth))
+	}
+
+	errs = append(errs, o.Cache.Validate()...)
+	if o.Cache.Type == CacheRedis {
+		errs = append(errs, o.RedisOptions.Validate()...)
+	}
+
+	errs = append(errs, o.Encryption.Validate()...)
+	if o.Encryption.Enabled && o.Store.Driver == StoreMemory {
+		errs = append(errs, fmt.Errorf("--encryption.enabled is not supported by --store.driver %s", StoreMemory))
+	}
+	if o.Encryption.Enabled && o.Cache.Type == CacheRedis {
+		errs = append(errs, fmt.Errorf("--encryption.enabled is not supported by --cache.type %s, "+
+			"cached users contain the decrypted email, phone and password hash", CacheRedis))
+	}
 
 	return errs
 }
@@ -0,0 +1,50 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// apiserver 支持的存储类型。
+const (
+	// StoreMySQL 使用 MySQL 存储，连接配置见 --mysql.*。
+	StoreMySQL = "mysql"
+	// StoreSQLite 使用 sqlite 存储，配置见 --sqlite.*，用于无法部署 MySQL 的小型环境和 CI。
+	StoreSQLite = "sqlite"
+	// StoreMemory 使用内存存储，数据在进程退出后丢失，用于测试和演示。
+	StoreMemory = "memory"
+)
+
+// StoreOptions 包含选择 apiserver 存储后端的配置项。
+type StoreOptions struct {
+	Driver string `json:"driver" mapstructure:"driver"`
+}
+
+// NewStoreOptions creates a StoreOptions object with default parameters.
+func NewStoreOptions() *StoreOptions {
+	return &StoreOptions{
+		Driver: StoreMySQL,
+	}
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *StoreOptions) Validate() []error {
+	switch o.Driver {
+	case StoreMySQL, StoreSQLite, StoreMemory:
+		return nil
+	}
+
+	return []error{fmt.Errorf("--store.driver %q must be one of %s, %s or %s",
+		o.Driver, StoreMySQL, StoreSQLite, StoreMemory)}
+}
+
+// AddFlags adds flags related to the storage backend for a specific APIServer to the
+// specified FlagSet.
+func (o *StoreOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Driver, "store.driver", o.Driver, ""+
+		"Storage backend of the apiserver, one of mysql, sqlite or memory. "+
+		"The memory store loses all data on exit and is only suitable for tests and demos. "+
+		"--store is a deprecated alias of this flag.")
+}
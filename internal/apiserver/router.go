@@ -1,31 +1,138 @@
 package apiserver
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/config"
 	"github.com/tiandh987/SharkAgent/internal/apiserver/controller/v1/user"
-	"github.com/tiandh987/SharkAgent/internal/apiserver/store/mysql"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	genericapiserver "github.com/tiandh987/SharkAgent/internal/pkg/server"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
 )
 
-func initRouter(g *gin.Engine) {
-	installMiddleware(g)
-	installController(g)
+// ReadyzPath 是就绪检查的访问路径，存储可用时返回 200，否则返回 503。
+const ReadyzPath = "/readyz"
+
+// readyzTimeout 是就绪检查等待存储响应的最长时间。
+const readyzTimeout = 3 * time.Second
+
+// routeMiddlewares 是安装在 API 版本路由上的中间件，它们依赖认证设置的用户名，需要在认证之后执行，
+// 不能作为全局中间件安装。
+type routeMiddlewares struct {
+	// rateLimit 为 nil 时不限流
+	rateLimit gin.HandlerFunc
+	// idempotency 为 nil 时不处理 Idempotency-Key 请求头
+	idempotency gin.HandlerFunc
+}
+
+// handlers 返回开启的中间件。
+func (m routeMiddlewares) handlers() []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	for _, h := range []gin.HandlerFunc{m.rateLimit, m.idempotency} {
+		if h != nil {
+			handlers = append(handlers, h)
+		}
+	}
+
+	return handlers
+}
+
+func initRouter(s *genericapiserver.GenericAPIServer, cfg *config.Config, apiScheme *scheme.Scheme,
+	storeIns store.Factory, userEvents *watch.Broadcaster) error {
+	installMiddleware(s.Engine)
+
+	mws := routeMiddlewares{rateLimit: s.RateLimiter(), idempotency: s.Idempotency()}
+
+	return installController(s.Engine, cfg, apiScheme, storeIns, userEvents, mws)
 }
 
 func installMiddleware(g *gin.Engine) {
+	// 记录请求是否已经写入，同一个请求写入之后的读操作使用主库，见 store.WithSession
+	g.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(store.WithSession(c.Request.Context()))
+		c.Next()
+	})
+}
+
+// installController 为 scheme 中的每个 API 版本注册一组路由，例如 /v1/users 和 /v2/users。
+// storeIns 为 nil 时返回错误，不注册任何路由。
+func installController(g *gin.Engine, cfg *config.Config, apiScheme *scheme.Scheme, storeIns store.Factory,
+	userEvents *watch.Broadcaster, mws routeMiddlewares) error {
+	if storeIns == nil {
+		return fmt.Errorf("can not install controllers without a store")
+	}
+
+	g.GET(OpenAPIPath, openAPIHandler(g, apiScheme))
+	g.GET(ReadyzPath, readyzHandler(storeIns))
+
+	for _, version := range apiScheme.Versions() {
+		group := g.Group("/"+version.Name, middleware.Deprecation(version))
+		installVersion(group, cfg, storeIns, apiScheme, version.Name, userEvents, mws)
+	}
 
+	return nil
 }
 
-func installController(g *gin.Engine) (*gin.Engine) {
+// readyzHandler 检查存储是否可用，供负载均衡和 Kubernetes readinessProbe 使用。
+func readyzHandler(storeIns store.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readyzTimeout)
+		defer cancel()
 
-	storeIns, _ := mysql.GetMySQLFactoryOr(nil)
-	v1 := g.Group("/v1")
-	{
-		userController := user.NewUserController(storeIns)
-		userv1 := v1.Group("/users")
-		{
-			userv1.POST("", userController.Create)
+		if err := storeIns.Ping(ctx); err != nil {
+			core.WriteResponse(c, errors.WrapC(err, code.ErrDatabaseUnavailable, "store is not ready"), nil)
+
+			return
 		}
+
+		core.WriteResponse(c, nil, map[string]string{"status": "ok"})
 	}
+}
 
-	return g
-}
\ No newline at end of file
+// installVersion 注册一个 API 版本的路由，请求和响应使用该版本的类型。
+// 开启客户端证书认证后，注册用户不需要认证，但会识别带有证书的调用方，使按用户限流和幂等对其生效；
+// 其他路由都需要认证（request 模式下没有证书的请求直接放行）。
+func installVersion(group *gin.RouterGroup, cfg *config.Config, storeIns store.Factory, apiScheme *scheme.Scheme,
+	version string, userEvents *watch.Broadcaster, mws routeMiddlewares) {
+	userController := user.NewUserController(storeIns, userEvents, apiScheme, version)
+
+	var identify, authenticate middleware.AuthOperator
+	if clientAuth := cfg.SecureServing.ClientAuth; clientAuth != genericoptions.ClientAuthNone {
+		identify.SetStrategy(newCertAuth(storeIns, genericoptions.ClientAuthRequest))
+		authenticate.SetStrategy(newCertAuth(storeIns, clientAuth))
+	}
+
+	public := group.Group("/users")
+	if identify.Enabled() {
+		public.Use(identify.AuthFunc())
+	}
+	public.Use(mws.handlers()...)
+	{
+		public.POST("", userController.Create)
+	}
+
+	if authenticate.Enabled() {
+		group.Use(authenticate.AuthFunc())
+	}
+	group.Use(mws.handlers()...)
+
+	// 需要认证的用户路由，单个用户的响应带有 ETag，支持 If-None-Match 和 If-Match 条件请求；
+	// GET /{version}/users?watch=true 返回用户变更事件流
+	userGroup := group.Group("/users")
+	{
+		userGroup.GET("", userController.List)
+		userGroup.GET(":name", userController.Get)
+		userGroup.PUT(":name", userController.Update)
+		userGroup.PATCH(":name", userController.Patch)
+		userGroup.DELETE(":name", userController.Delete)
+	}
+}
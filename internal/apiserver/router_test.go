@@ -0,0 +1,144 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/config"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/options"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	"github.com/tiandh987/SharkAgent/internal/pkg/idempotency"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// newTestRouter 注册开启客户端证书认证的路由，存储中有 colin 和 tom 两个用户。
+func newTestRouter(t *testing.T, mws routeMiddlewares) (*gin.Engine, store.Factory) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	opts := options.NewOptions()
+	opts.SecureServing.ClientAuth = genericoptions.ClientAuthRequireAndVerify
+
+	cfg, err := config.CreateConfigFromOptions(opts)
+	require.NoError(t, err)
+
+	apiScheme, err := newScheme(cfg)
+	require.NoError(t, err)
+
+	storeIns := memory.NewFactory()
+	for _, name := range []string{"colin", "tom"} {
+		user := &v1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     1,
+			Nickname:   name,
+			Password:   "hash",
+			Email:      name + "@example.com",
+		}
+		require.NoError(t, storeIns.Users().Create(context.Background(), user, metav1.CreateOptions{}))
+	}
+
+	g := gin.New()
+	require.NoError(t, installController(g, cfg, apiScheme, storeIns, nil, mws))
+
+	return g, storeIns
+}
+
+// request 返回使用 CommonName 为 username 的客户端证书发出的请求，username 为空时不带证书。
+func request(method, path, username string) *http.Request {
+	return requestWithBody(method, path, username, nil)
+}
+
+func requestWithBody(method, path, username string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, path, body)
+	if username != "" {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: username}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	return req
+}
+
+func serve(g *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestRouter_RateLimitPerUser(t *testing.T) {
+	g, _ := newTestRouter(t, routeMiddlewares{
+		rateLimit: middleware.RateLimit(&middleware.RateLimitConfig{
+			Default: middleware.RateLimitRule{
+				PerIP:   middleware.Limit{QPS: 100, Burst: 100},
+				PerUser: middleware.Limit{QPS: 1, Burst: 1},
+			},
+		}),
+	})
+
+	assert.Equal(t, http.StatusOK, serve(g, request(http.MethodGet, "/v1/users/colin", "colin")).Code)
+
+	// 认证之后限流，colin 的令牌桶已经用完，tom 不受影响
+	w := serve(g, request(http.MethodGet, "/v1/users/colin", "colin"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get(middleware.HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusOK, serve(g, request(http.MethodGet, "/v1/users/colin", "tom")).Code)
+
+	// 认证失败的请求不会进入限流
+	assert.Equal(t, http.StatusUnauthorized, serve(g, request(http.MethodGet, "/v1/users/colin", "")).Code)
+}
+
+func TestRouter_IdempotencyPerUser(t *testing.T) {
+	g, _ := newTestRouter(t, routeMiddlewares{
+		idempotency: middleware.Idempotency(&middleware.IdempotencyConfig{
+			Store: idempotency.NewMemoryStore(),
+			TTL:   time.Hour,
+		}),
+	})
+
+	create := func(username string) *httptest.ResponseRecorder {
+		body := `{"metadata": {"name": "lee"}, "nickname": "lee", "password": "Lee@2022", "email": "lee@example.com"}`
+		req := requestWithBody(http.MethodPost, "/v1/users", username, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.HeaderIdempotencyKey, "create-lee")
+
+		return serve(g, req)
+	}
+
+	w := create("colin")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 注册用户不需要认证，但带有证书的调用方被识别，重试时重放响应
+	w = create("colin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	// 其他用户使用相同的 key 和请求体不会重放 colin 的响应，而是创建时发现用户已存在
+	w = create("tom")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Empty(t, w.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	// 匿名调用方按客户端 IP 隔离，不会重放 colin 的响应，重试时重放自己的响应
+	w = create("")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Empty(t, w.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	w = create("")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(middleware.HeaderIdempotentReplayed))
+}
@@ -9,5 +9,10 @@ func Run(cfg *config.Config) error {
 		return err
 	}
 
-	return server.PrepareRun().Run()
-}
\ No newline at end of file
+	prepared, err := server.PrepareRun()
+	if err != nil {
+		return err
+	}
+
+	return prepared.Run()
+}
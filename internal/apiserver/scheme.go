@@ -0,0 +1,22 @@
+package apiserver
+
+import (
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	v2 "github.com/tiandh987/SharkAgent/api/apiserver/v2"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/config"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// newScheme 注册 apiserver 提供的所有 API 版本，并按配置标记废弃的版本。
+// 新版本注册在已有版本之后，废弃版本的替代版本为最后注册的未废弃版本。
+func newScheme(cfg *config.Config) (*scheme.Scheme, error) {
+	s := scheme.NewScheme()
+	v1.AddToScheme(s)
+	v2.AddToScheme(s)
+
+	if err := cfg.APIVersions.ApplyTo(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
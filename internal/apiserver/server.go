@@ -1,11 +1,22 @@
 package apiserver
 
 import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
 	"github.com/tiandh987/SharkAgent/internal/apiserver/config"
+	srvv1 "github.com/tiandh987/SharkAgent/internal/apiserver/service/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
 	genericapiserver "github.com/tiandh987/SharkAgent/internal/pkg/server"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
 	"github.com/tiandh987/SharkAgent/pkg/log"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
 	"github.com/tiandh987/SharkAgent/pkg/shutdown"
 	"github.com/tiandh987/SharkAgent/pkg/shutdown/posixsignal"
+	"golang.org/x/sync/errgroup"
 )
 
 // apiServer 包含 通用apiserver、优雅退出
@@ -14,30 +25,78 @@ type apiServer struct {
 	gs *shutdown.GracefulShutdown
 
 	//redisOptions     *genericoptions.RedisOptions
-	//gRPCAPIServer    *grpcAPIServer
+
+	// gRPC 服务，--grpc.bind-port 为 0 时为 nil
+	gRPCAPIServer *grpcAPIServer
 
 	// apiserver 服务 - gin
 	genericAPIServer *genericapiserver.GenericAPIServer
+
+	// apiserver 运行时配置
+	cfg *config.Config
+
+	// 注册的 API 版本和类型
+	scheme *scheme.Scheme
+
+	// 分发用户变更事件，供 watch 使用
+	userEvents *watch.Broadcaster
+
+	// 用户存储，在所有服务关闭后关闭
+	storeIns store.Factory
+	stopOnce sync.Once
 }
 
-func (s *apiServer) PrepareRun() preparedAPIServer {
-	initRouter(s.genericAPIServer.Engine)
-	//
-	////s.initRedisStore()
-	//
-	//s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(string) error {
-	//	mysqlStore, _ := mysql.GetMySQLFactoryOr(nil)
-	//	if mysqlStore != nil {
-	//		_ = mysqlStore.Close()
-	//	}
-	//
-	//	s.gRPCAPIServer.Close()
-	//	s.genericAPIServer.Close()
-	//
-	//	return nil
-	//}))
-
-	return preparedAPIServer{s}
+// userEventsPollInterval 指定多久从事件日志中读取一次其他实例写入的用户事件。
+const userEventsPollInterval = time.Second
+
+// PrepareRun 注册路由和退出时的清理函数，存储没有初始化时返回错误。
+func (s *apiServer) PrepareRun() (preparedAPIServer, error) {
+	storeIns := store.Client()
+	if storeIns == nil {
+		return preparedAPIServer{}, fmt.Errorf("store is not initialized")
+	}
+
+	s.storeIns = storeIns
+	s.userEvents = watch.NewBroadcaster(srvv1.NewUserEventSource(storeIns), userEventsPollInterval)
+
+	if err := initRouter(s.genericAPIServer, s.cfg, s.scheme, storeIns, s.userEvents); err != nil {
+		return preparedAPIServer{}, err
+	}
+
+	//s.initRedisStore()
+
+	s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(string) error {
+		s.stop()
+
+		return nil
+	}))
+
+	return preparedAPIServer{s}, nil
+}
+
+// stop 结束 watch 事件流，关闭 gRPC 和 HTTP 服务，最后关闭存储，只会执行一次。
+// 存储在服务关闭后才关闭，优雅关闭期间正在处理的请求仍然可以访问数据库。
+func (s *apiServer) stop() {
+	s.stopOnce.Do(func() {
+		// 先结束所有 watch 事件流，否则 HTTP 服务需要等到超时才能关闭
+		s.userEvents.Shutdown()
+
+		if s.gRPCAPIServer != nil {
+			s.gRPCAPIServer.Close()
+		}
+		s.genericAPIServer.Close()
+
+		_ = s.storeIns.Close()
+	})
+}
+
+// stopOnError 在服务失败时关闭其他服务和存储，返回 err。
+func (s *apiServer) stopOnError(err error) error {
+	if err != nil {
+		s.stop()
+	}
+
+	return err
 }
 
 // ===================================================================
@@ -46,15 +105,36 @@ type preparedAPIServer struct {
 	*apiServer
 }
 
+// Run 启动 gRPC 和 HTTP 服务，直到服务被关闭。任意一个服务失败时关闭其他服务和存储并返回错误。
 func (s preparedAPIServer) Run() error {
-	//go s.gRPCAPIServer.Run()
+	// 在启动 HTTP 服务之前监听 gRPC 地址，监听失败时不需要等待 HTTP 服务启动后再关闭
+	var grpcListener net.Listener
+	if s.gRPCAPIServer != nil {
+		ln, err := s.gRPCAPIServer.Listen()
+		if err != nil {
+			return s.stopOnError(err)
+		}
+
+		grpcListener = ln
+	}
 
 	// start shutdown managers
 	if err := s.gs.Start(); err != nil {
 		log.Fatalf("start shutdown manager failed: %s", err.Error())
 	}
 
-	return s.genericAPIServer.Run()
+	var eg errgroup.Group
+	if s.gRPCAPIServer != nil {
+		eg.Go(func() error {
+			return s.stopOnError(s.gRPCAPIServer.Run(grpcListener))
+		})
+	}
+
+	eg.Go(func() error {
+		return s.stopOnError(s.genericAPIServer.Run())
+	})
+
+	return eg.Wait()
 }
 
 // ==================================================================
@@ -73,9 +153,30 @@ func createAPIServer(cfg *config.Config) (*apiServer, error) {
 		return nil, err
 	}
 
+	apiScheme, err := newScheme(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数据库在 --mysql.connect-timeout 内无法连接时启动失败，不会在没有存储的情况下提供服务
+	storeIns, err := initStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init store failed: %w", err)
+	}
+
+	var gRPCServer *grpcAPIServer
+	if cfg.GRPCOptions.BindPort != 0 {
+		if gRPCServer, err = newGRPCAPIServer(buildGRPCConfig(cfg, genericConfig), storeIns); err != nil {
+			return nil, err
+		}
+	}
+
 	server := &apiServer{
 		gs:               gs,
 		genericAPIServer: genericServer,
+		gRPCAPIServer:    gRPCServer,
+		cfg:              cfg,
+		scheme:           apiScheme,
 	}
 
 	return server, nil
@@ -106,5 +207,32 @@ func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Con
 		return
 	}
 
+	// 限流配置
+	if lastErr = cfg.RateLimit.ApplyTo(genericConfig); lastErr != nil {
+		return
+	}
+
+	// 幂等配置
+	if lastErr = cfg.Idempotency.ApplyTo(genericConfig, cfg.MySQLOptions); lastErr != nil {
+		return
+	}
+
 	return
 }
+
+// 基于 apiServer 的配置，生成 gRPC 服务的配置
+func buildGRPCConfig(cfg *config.Config, genericConfig *genericapiserver.Config) *grpcConfig {
+	c := &grpcConfig{
+		Addr:       cfg.GRPCOptions.Address(),
+		MaxMsgSize: cfg.GRPCOptions.MaxMsgSize,
+		ClientAuth: genericoptions.ClientAuthNone,
+	}
+
+	// 客户端证书认证只在使用 TLS 时生效，Options.Validate 拒绝不使用 TLS 时开启客户端认证
+	if cfg.GRPCOptions.TLS {
+		c.SecureServing = genericConfig.SecureServing
+		c.ClientAuth = cfg.SecureServing.ClientAuth
+	}
+
+	return c
+}
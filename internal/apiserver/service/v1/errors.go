@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"github.com/marmotedu/errors"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+)
+
+// userError 将存储层返回的错误转换为错误码，用户服务只在这里处理存储层的错误类型。
+func userError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var exists *store.AlreadyExistsError
+
+	switch {
+	case errors.As(err, &exists):
+		if exists.Field == "" {
+			return errors.WrapC(err, code.ErrUserAlreadyExist, "user already exists")
+		}
+
+		return errors.WrapC(err, code.ErrUserAlreadyExist, "user with the same %s already exists", exists.Field)
+	case errors.Is(err, store.ErrNotFound):
+		return errors.WrapC(err, code.ErrUserNotFound, "user not found")
+	case errors.Is(err, store.ErrConflict):
+		return errors.WrapC(err, code.ErrPreconditionFailed, "user was modified or deleted")
+	case errors.Is(err, store.ErrUnavailable):
+		return errors.WrapC(err, code.ErrDatabaseUnavailable, "database is unavailable")
+	case errors.Is(err, store.ErrCanceled):
+		return errors.WrapC(err, code.ErrDatabaseTimeout, "database operation was canceled or timed out")
+	default:
+		return errors.WrapC(err, code.ErrDatabase, "database error")
+	}
+}
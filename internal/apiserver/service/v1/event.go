@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// eventSource 将 store.EventStore 中某个资源的事件作为 watch.Source。
+type eventSource struct {
+	store    store.Factory
+	resource string
+}
+
+// NewUserEventSource 返回用户变更事件的 watch.Source，用于创建 watch.Broadcaster。
+func NewUserEventSource(factory store.Factory) watch.Source {
+	return &eventSource{store: factory, resource: store.UserResource}
+}
+
+func (s *eventSource) List(ctx context.Context, since uint64, limit int) ([]watch.Event, error) {
+	events, err := s.store.Events().List(ctx, s.resource, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]watch.Event, 0, len(events))
+	for _, e := range events {
+		ret = append(ret, watch.Event{
+			Type:            metav1.EventType(e.Type),
+			ResourceVersion: e.ID,
+			Object:          json.RawMessage(e.Object),
+		})
+	}
+
+	return ret, nil
+}
+
+func (s *eventSource) Latest(ctx context.Context) (uint64, error) {
+	return s.store.Events().Latest(ctx, s.resource)
+}
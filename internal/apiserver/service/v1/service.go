@@ -1,6 +1,9 @@
 package v1
 
-import "github.com/tiandh987/SharkAgent/internal/apiserver/store"
+import (
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+)
 
 // Service defines functions used to return resource interface.
 type Service interface {
@@ -9,15 +12,19 @@ type Service interface {
 
 type service struct {
 	store store.Factory
+
+	// userEvents 分发用户变更事件，为 nil 时不支持 watch
+	userEvents *watch.Broadcaster
 }
 
-func NewService(store store.Factory) Service {
+// NewService returns Service interface.
+func NewService(store store.Factory, userEvents *watch.Broadcaster) Service {
 	return &service{
-		store: store,
+		store:      store,
+		userEvents: userEvents,
 	}
 }
 
 func (s *service) Users() UserSrv {
 	return newUsers(s)
 }
-
@@ -6,33 +6,111 @@ import (
 	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
 	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
 	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+	"github.com/tiandh987/SharkAgent/pkg/fields"
 	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
-	"regexp"
+	"strconv"
 )
 
 type UserSrv interface {
 	Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error
+	Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error)
+	Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error
+	Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 }
 
 type userService struct {
-	store store.Factory
+	store  store.Factory
+	events *watch.Broadcaster
 }
 
 var _ UserSrv = (*userService)(nil)
 
 func newUsers(srv *service) *userService {
-	return &userService{store: srv.store}
+	return &userService{store: srv.store, events: srv.userEvents}
 }
 
 func (u *userService) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
 	if err := u.store.Users().Create(ctx, user, opts); err != nil {
-		if match, _ := regexp.MatchString("Duplicate entry '.*' for key 'idx_name'", err.Error()); match {
-			return errors.WithCode(code.ErrUserAlreadyExist, err.Error())
-		}
+		return userError(err)
+	}
+
+	u.notify()
+
+	return nil
+}
+
+func (u *userService) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	user, err := u.store.Users().Get(ctx, username, opts)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return user, nil
+}
 
-		return errors.WithCode(code.ErrDatabase, err.Error())
+func (u *userService) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
+	if err := u.store.Users().Update(ctx, user, opts); err != nil {
+		return userError(err)
 	}
 
+	u.notify()
+
 	return nil
 }
 
+func (u *userService) Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error {
+	if err := u.store.Users().Delete(ctx, username, opts); err != nil {
+		return userError(err)
+	}
+
+	u.notify()
+
+	return nil
+}
+
+func (u *userService) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+	if _, err := fields.ParseSelector(opts.FieldSelector); err != nil {
+		return nil, errors.WithCode(code.ErrValidation, err.Error())
+	}
+
+	users, err := u.store.Users().List(ctx, opts)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return users, nil
+}
+
+// Watch 返回 opts.ResourceVersion 之后的用户变更事件，ResourceVersion 为空时只返回之后发生的事件。
+func (u *userService) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	if u.events == nil {
+		return nil, errors.WithCode(code.ErrUnknown, "watch is not enabled")
+	}
+
+	var since uint64
+	if opts.ResourceVersion != "" {
+		rv, err := strconv.ParseUint(opts.ResourceVersion, 10, 64)
+		if err != nil {
+			return nil, errors.WithCode(code.ErrValidation, "invalid resourceVersion %q", opts.ResourceVersion)
+		}
+
+		since = rv
+	}
+
+	w, err := u.events.Watch(ctx, since)
+	if err != nil {
+		return nil, userError(err)
+	}
+
+	return w, nil
+}
+
+// notify 通知 Broadcaster 事件日志中有新的用户事件。
+func (u *userService) notify() {
+	if u.events != nil {
+		u.events.Notify()
+	}
+}
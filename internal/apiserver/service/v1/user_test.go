@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/watch"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+func TestUserService_Create(t *testing.T) {
+	ctx := context.Background()
+	srv := NewService(memory.NewFactory(), nil)
+
+	user := &v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}, Status: 1}
+	require.NoError(t, srv.Users().Create(ctx, user, metav1.CreateOptions{}))
+
+	err := srv.Users().Create(ctx, &v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}}, metav1.CreateOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserAlreadyExist))
+	assert.Contains(t, fmt.Sprintf("%-v", err), "user with the same name already exists")
+}
+
+func TestUserService_Errors(t *testing.T) {
+	ctx := context.Background()
+	srv := NewService(memory.NewFactory(), nil)
+
+	_, err := srv.Users().Get(ctx, "unknown", metav1.GetOptions{})
+	assert.True(t, errors.IsCode(err, code.ErrUserNotFound))
+
+	user := &v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}}
+	require.NoError(t, srv.Users().Create(ctx, user, metav1.CreateOptions{}))
+
+	stale := user.Version - 1
+	err = srv.Users().Delete(ctx, "colin", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{Version: &stale}})
+	assert.True(t, errors.IsCode(err, code.ErrPreconditionFailed))
+
+	_, err = srv.Users().List(ctx, metav1.ListOptions{FieldSelector: "name"})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+}
+
+func TestUserError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{store.NewAlreadyExistsError("instanceID", fmt.Errorf("duplicate")), code.ErrUserAlreadyExist},
+		{fmt.Errorf("%w: user colin", store.ErrNotFound), code.ErrUserNotFound},
+		{store.ErrConflict, code.ErrPreconditionFailed},
+		{store.NewUnavailableError(fmt.Errorf("connection refused")), code.ErrDatabaseUnavailable},
+		{store.NewCanceledError(context.DeadlineExceeded), code.ErrDatabaseTimeout},
+		{fmt.Errorf("syntax error"), code.ErrDatabase},
+	}
+
+	for _, tt := range tests {
+		err := userError(tt.err)
+		assert.True(t, errors.IsCode(err, tt.code), "%v", err)
+		// 原始错误保留在错误链中
+		assert.True(t, errors.Is(err, tt.err))
+	}
+
+	assert.Nil(t, userError(nil))
+}
+
+func TestUserService_Watch(t *testing.T) {
+	ctx := context.Background()
+	factory := memory.NewFactory()
+	events := watch.NewBroadcaster(NewUserEventSource(factory), time.Hour)
+	defer events.Shutdown()
+
+	srv := NewService(factory, events)
+
+	w, err := srv.Users().Watch(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	defer w.Stop()
+
+	require.NoError(t, srv.Users().Create(ctx, &v1.User{ObjectMeta: metav1.ObjectMeta{Name: "colin"}},
+		metav1.CreateOptions{}))
+	require.NoError(t, srv.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+
+	for _, want := range []metav1.EventType{metav1.Added, metav1.Deleted} {
+		select {
+		case e := <-w.ResultChan():
+			assert.Equal(t, want, e.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+	}
+
+	_, err = srv.Users().Watch(ctx, metav1.ListOptions{ResourceVersion: "abc"})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+}
@@ -0,0 +1,67 @@
+package apiserver
+
+import (
+	"github.com/tiandh987/SharkAgent/internal/apiserver/config"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/options"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/cache"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/mysql"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/sqlite"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// initStore 根据 --store.driver 创建存储，并设置为 store.Client()。
+func initStore(cfg *config.Config) (store.Factory, error) {
+	masterKey, err := cfg.Encryption.MasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var storeIns store.Factory
+
+	switch cfg.Store.Driver {
+	case options.StoreMemory:
+		log.Warn("Using the memory store, all data will be lost on exit")
+
+		storeIns = memory.NewFactory()
+	case options.StoreSQLite:
+		storeIns, err = sqlite.GetSQLiteFactoryOr(cfg.SQLiteOptions, masterKey)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		storeIns, err = mysql.GetMySQLFactoryOr(cfg.MySQLOptions, masterKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	storeIns, err = withCache(cfg, storeIns)
+	if err != nil {
+		_ = storeIns.Close()
+
+		return nil, err
+	}
+
+	store.SetClient(storeIns)
+
+	return storeIns, nil
+}
+
+// withCache 根据 --cache.type 为 storeIns 增加用户缓存。
+func withCache(cfg *config.Config, storeIns store.Factory) (store.Factory, error) {
+	switch cfg.Cache.Type {
+	case options.CacheMemory:
+		return cache.NewFactory(storeIns, cache.NewLRU(cfg.Cache.Size), cfg.Cache.TTL), nil
+	case options.CacheRedis:
+		client, err := cfg.RedisOptions.NewClient()
+		if err != nil {
+			return storeIns, err
+		}
+
+		return cache.NewFactory(storeIns, cache.NewRedis(client, "iam:user:"), cfg.Cache.TTL), nil
+	default:
+		return storeIns, nil
+	}
+}
@@ -0,0 +1,231 @@
+// Package cache 为 store.UserStore 提供按用户名读取的缓存。
+//
+// Factory 包装另一个 store.Factory：Users().Get 先读取缓存，未命中时读取存储并写入缓存；
+// Create、Update 和 Delete 完成后删除对应用户名的缓存。缓存出错时只记录日志并直接读写存储，
+// 不会使请求失败。
+//
+// 删除缓存与并发的读取之间存在竞争：读取在写入之前从存储读到旧数据，在删除缓存之后写入缓存时，
+// 旧数据最多保留 TTL。多个 apiserver 实例使用进程内缓存时，其他实例的修改同样在 TTL 后才可见，
+// 需要实例之间立即一致时使用 Redis 缓存。
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// 用户缓存的指标，通过 expvar 暴露在 /debug/vars。
+var (
+	cacheHits   = expvar.NewInt("user_cache_hits_total")
+	cacheMisses = expvar.NewInt("user_cache_misses_total")
+	cacheErrors = expvar.NewInt("user_cache_errors_total")
+)
+
+// Cache 是保存编码后用户的键值存储，实现需要并发安全。
+type Cache interface {
+	// Get 返回 key 的值，key 不存在或已经过期时 ok 为 false。
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 保存 key 的值，ttl 后过期。
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除 keys，不存在的 key 被忽略。
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// Stats 是缓存的命中统计。
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Errors uint64 `json:"errors"`
+}
+
+// Factory 是带有用户缓存的 store.Factory。
+type Factory struct {
+	store.Factory
+
+	cache Cache
+	ttl   time.Duration
+	stats *Stats
+
+	// tx 记录事务中写入的用户名，不在事务中时为 nil
+	tx *txNames
+}
+
+var _ store.Factory = (*Factory)(nil)
+
+type txNames struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// NewFactory 返回在 factory 之上使用 cache 缓存用户的 Factory，缓存的用户在 ttl 后过期。
+func NewFactory(factory store.Factory, cache Cache, ttl time.Duration) *Factory {
+	return &Factory{Factory: factory, cache: cache, ttl: ttl, stats: &Stats{}}
+}
+
+// Users 返回带有缓存的 UserStore。
+func (f *Factory) Users() store.UserStore {
+	return &users{UserStore: f.Factory.Users(), f: f}
+}
+
+// Tx 在事务中执行 fn。事务中的读取不使用缓存，避免缓存未提交的数据；
+// 事务中写入的用户在写入时和事务结束后各删除一次缓存，避免事务期间的读取将旧数据写回缓存。
+func (f *Factory) Tx(ctx context.Context, fn func(tx store.Factory) error) error {
+	names := &txNames{}
+
+	err := f.Factory.Tx(ctx, func(tx store.Factory) error {
+		return fn(&Factory{Factory: tx, cache: f.cache, ttl: f.ttl, stats: f.stats, tx: names})
+	})
+
+	names.mu.Lock()
+	f.invalidate(ctx, names.names...)
+	names.mu.Unlock()
+
+	return err
+}
+
+// Close 关闭存储和缓存。
+func (f *Factory) Close() error {
+	if f.tx != nil {
+		return f.Factory.Close()
+	}
+
+	err := f.Factory.Close()
+	if cerr := f.cache.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// Stats 返回缓存的命中统计。
+func (f *Factory) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&f.stats.Hits),
+		Misses: atomic.LoadUint64(&f.stats.Misses),
+		Errors: atomic.LoadUint64(&f.stats.Errors),
+	}
+}
+
+// get 从缓存读取用户，缓存出错或数据无法解码时视为未命中。
+func (f *Factory) get(ctx context.Context, username string) (*v1.User, bool) {
+	data, ok, err := f.cache.Get(ctx, username)
+	if err != nil {
+		f.fail(ctx, "get", err)
+
+		return nil, false
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	user := &v1.User{}
+	if err := json.Unmarshal(data, user); err != nil {
+		f.fail(ctx, "decode", err)
+
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (f *Factory) set(ctx context.Context, user *v1.User) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		f.fail(ctx, "encode", err)
+
+		return
+	}
+
+	if err := f.cache.Set(ctx, user.Name, data, f.ttl); err != nil {
+		f.fail(ctx, "set", err)
+	}
+}
+
+func (f *Factory) invalidate(ctx context.Context, usernames ...string) {
+	if len(usernames) == 0 {
+		return
+	}
+
+	if f.tx != nil {
+		f.tx.mu.Lock()
+		f.tx.names = append(f.tx.names, usernames...)
+		f.tx.mu.Unlock()
+	}
+
+	// 请求被取消时仍然需要删除缓存
+	if err := f.cache.Delete(context.Background(), usernames...); err != nil {
+		f.fail(ctx, "delete", err)
+	}
+}
+
+func (f *Factory) fail(ctx context.Context, op string, err error) {
+	atomic.AddUint64(&f.stats.Errors, 1)
+	cacheErrors.Add(1)
+	log.L(ctx).Warnf("User cache %s failed: %s", op, err.Error())
+}
+
+type users struct {
+	store.UserStore
+	f *Factory
+}
+
+// Get 先读取缓存。事务中或同一个请求已经写入（store.Written）时直接读取存储，也不写入缓存。
+func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	if u.f.tx != nil || store.Written(ctx) {
+		return u.UserStore.Get(ctx, username, opts)
+	}
+
+	if user, ok := u.f.get(ctx, username); ok {
+		atomic.AddUint64(&u.f.stats.Hits, 1)
+		cacheHits.Add(1)
+
+		return user, nil
+	}
+
+	atomic.AddUint64(&u.f.stats.Misses, 1)
+	cacheMisses.Add(1)
+
+	user, err := u.UserStore.Get(ctx, username, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u.f.set(ctx, user)
+
+	return user, nil
+}
+
+// Create 创建用户后删除缓存。Create、Update 和 Delete 出错时同样删除缓存：超时等错误发生时写入可能已经提交。
+func (u *users) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
+	err := u.UserStore.Create(ctx, user, opts)
+	u.f.invalidate(ctx, user.Name)
+
+	return err
+}
+
+// Update 按用户名删除缓存，用户名在创建后不能修改。
+func (u *users) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
+	err := u.UserStore.Update(ctx, user, opts)
+	u.f.invalidate(ctx, user.Name)
+
+	return err
+}
+
+// Delete 删除用户后删除缓存。
+func (u *users) Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error {
+	err := u.UserStore.Delete(ctx, username, opts)
+	u.f.invalidate(ctx, username)
+
+	return err
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/memory"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+func newUser(name string) *v1.User {
+	return &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     1,
+		Nickname:   name,
+		Password:   "hash",
+		Email:      name + "@example.com",
+	}
+}
+
+// setup 创建带有缓存的 Factory，返回的 backend 是没有缓存的底层存储。
+func setup(t *testing.T, c Cache) (f *Factory, backend store.Factory) {
+	t.Helper()
+
+	backend = memory.NewFactory()
+	require.NoError(t, backend.Users().Create(context.Background(), newUser("colin"), metav1.CreateOptions{}))
+
+	return NewFactory(backend, c, time.Minute), backend
+}
+
+func testCache(t *testing.T, c Cache) {
+	ctx := context.Background()
+	f, backend := setup(t, c)
+
+	user, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "colin", user.Nickname)
+	assert.Equal(t, Stats{Misses: 1}, f.Stats())
+
+	// 绕过缓存修改存储，命中的缓存返回旧数据
+	user.Nickname = "changed"
+	require.NoError(t, backend.Users().Update(ctx, user, metav1.UpdateOptions{}))
+
+	got, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "colin", got.Nickname)
+	assert.Equal(t, Stats{Hits: 1, Misses: 1}, f.Stats())
+
+	// 通过缓存修改时删除缓存
+	got.Nickname = "updated"
+	require.NoError(t, f.Users().Update(ctx, got, metav1.UpdateOptions{}))
+
+	got, err = f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "updated", got.Nickname)
+	assert.Equal(t, Stats{Hits: 1, Misses: 2}, f.Stats())
+
+	require.NoError(t, f.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+
+	_, err = f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+
+	// 不存在的用户不被缓存
+	require.NoError(t, f.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{}))
+	_, err = f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+}
+
+func TestFactory_LRU(t *testing.T) {
+	testCache(t, NewLRU(10))
+}
+
+func TestFactory_Redis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	testCache(t, NewRedis(client, "iam:user:"))
+
+	// 缓存的 key 带有前缀，并设置了过期时间
+	f, _ := setup(t, NewRedis(client, "iam:user:"))
+	_, err := f.Users().Get(context.Background(), "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("iam:user:colin"))
+	assert.Equal(t, time.Minute, mr.TTL("iam:user:colin"))
+
+	mr.FastForward(time.Minute)
+	assert.False(t, mr.Exists("iam:user:colin"))
+}
+
+func TestFactory_ReadYourWrites(t *testing.T) {
+	ctx := store.WithSession(context.Background())
+	f, backend := setup(t, NewLRU(10))
+
+	user, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	user.Nickname = "changed"
+	require.NoError(t, backend.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	store.MarkWritten(ctx)
+
+	// 已经写入的请求直接读取存储
+	got, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "changed", got.Nickname)
+	assert.Equal(t, Stats{Misses: 1}, f.Stats())
+}
+
+func TestFactory_Tx(t *testing.T) {
+	ctx := context.Background()
+	f, _ := setup(t, NewLRU(10))
+
+	_, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	err = f.Tx(ctx, func(tx store.Factory) error {
+		user, err := tx.Users().Get(ctx, "colin", metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		user.Nickname = "changed"
+		if err := tx.Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+
+		// 事务中读取未提交的数据，不写入缓存
+		_, err = tx.Users().Get(ctx, "colin", metav1.GetOptions{})
+
+		return err
+	})
+	require.NoError(t, err)
+
+	got, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "changed", got.Nickname)
+	assert.Equal(t, Stats{Misses: 2}, f.Stats())
+}
+
+type errCache struct{}
+
+func (errCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("cache is down")
+}
+
+func (errCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("cache is down")
+}
+
+func (errCache) Delete(context.Context, ...string) error { return errors.New("cache is down") }
+
+func (errCache) Close() error { return nil }
+
+func TestFactory_CacheErrors(t *testing.T) {
+	ctx := context.Background()
+	f, _ := setup(t, errCache{})
+
+	user, err := f.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, f.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	assert.Equal(t, Stats{Misses: 1, Errors: 3}, f.Stats())
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Second))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// 读取 a 后 b 成为最久没有使用的 key
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+	assert.Equal(t, 2, c.Len())
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+
+	// a 过期
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	require.NoError(t, c.Delete(ctx, "c", "unknown"))
+	assert.Equal(t, 0, c.Len())
+}
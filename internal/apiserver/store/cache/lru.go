@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU 是进程内的缓存，超过 size 个 key 时淘汰最久没有使用的 key。
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+
+	// now 返回当前时间，测试时替换
+	now func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+var _ Cache = (*LRU)(nil)
+
+// NewLRU 创建最多保存 size 个 key 的 LRU 缓存。
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+		now:   time.Now,
+	}
+}
+
+// Get 返回 key 的值，过期的 key 被删除。
+func (c *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry, _ := e.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(e)
+
+		return nil, false, nil
+	}
+
+	c.ll.MoveToFront(e)
+
+	return entry.value, true, nil
+}
+
+// Set 保存 key 的值，超过容量时淘汰最久没有使用的 key。
+func (c *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if e, ok := c.items[key]; ok {
+		entry, _ := e.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.ll.MoveToFront(e)
+
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+
+	return nil
+}
+
+// Delete 删除 keys。
+func (c *LRU) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if e, ok := c.items[key]; ok {
+			c.remove(e)
+		}
+	}
+
+	return nil
+}
+
+// Len 返回缓存中 key 的个数，包括已经过期但还没有被删除的 key。
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRU) Close() error {
+	return nil
+}
+
+// remove 删除一个元素，调用方需要持有锁。
+func (c *LRU) remove(e *list.Element) {
+	entry, _ := e.Value.(*lruEntry)
+	delete(c.items, entry.key)
+	c.ll.Remove(e)
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis 是保存在 Redis 中的缓存，多个 apiserver 实例共享，一个实例删除的缓存对所有实例立即生效。
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ Cache = (*Redis)(nil)
+
+// NewRedis 创建使用 client 的缓存，所有 key 带有 prefix 前缀。
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Get 返回 key 的值。
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+// Set 保存 key 的值，过期由 Redis 处理。
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// Delete 删除 keys。
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.prefix+key)
+	}
+
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// Close 关闭 Redis 连接。
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 存储层返回的错误。各个存储实现将驱动的错误（例如 MySQL 的错误码 1062）转换为这些错误，
+// 服务层使用 errors.Is 判断错误类型并转换为 internal/pkg/code 中的错误码，不依赖驱动的错误信息。
+var (
+	// ErrNotFound 表示记录不存在。
+	ErrNotFound = errors.New("record not found")
+
+	// ErrAlreadyExists 表示记录违反了唯一约束，冲突的字段见 AlreadyExistsError。
+	ErrAlreadyExists = errors.New("record already exists")
+
+	// ErrConflict 表示记录在读取后被修改或删除，带有 Preconditions 的更新或删除失败。
+	ErrConflict = errors.New("record was modified or deleted")
+
+	// ErrUnavailable 表示存储暂时不可用，例如连接失败、锁等待超时或死锁，可以稍后重试。
+	ErrUnavailable = errors.New("store is unavailable")
+
+	// ErrCanceled 表示操作因为请求被取消或超过截止时间（包括默认的查询超时时间）而中止。
+	ErrCanceled = errors.New("store operation was canceled or timed out")
+
+	// ErrNestedTransaction 表示在 Factory.Tx 的事务中再次调用了 Tx。
+	ErrNestedTransaction = errors.New("nested transaction is not supported")
+)
+
+// AlreadyExistsError 是违反唯一约束时返回的错误，errors.Is(err, ErrAlreadyExists) 为 true。
+type AlreadyExistsError struct {
+	// Field 是冲突的字段名，与 json 标签相同，例如 name、instanceID；无法确定时为空
+	Field string
+	// Err 是驱动返回的原始错误
+	Err error
+}
+
+// NewAlreadyExistsError 创建 field 字段违反唯一约束的错误。
+func NewAlreadyExistsError(field string, err error) error {
+	return &AlreadyExistsError{Field: field, Err: err}
+}
+
+func (e *AlreadyExistsError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %v", ErrAlreadyExists, e.Err)
+	}
+
+	return fmt.Sprintf("%s with the same %s: %v", ErrAlreadyExists, e.Field, e.Err)
+}
+
+// Is 使 errors.Is(err, ErrAlreadyExists) 返回 true。
+func (e *AlreadyExistsError) Is(target error) bool {
+	return target == ErrAlreadyExists
+}
+
+func (e *AlreadyExistsError) Unwrap() error {
+	return e.Err
+}
+
+// NewUnavailableError 将驱动返回的临时错误包装为 ErrUnavailable。
+func NewUnavailableError(err error) error {
+	return fmt.Errorf("%w: %v", ErrUnavailable, err)
+}
+
+// NewCanceledError 将取消或超时导致的错误包装为 ErrCanceled。
+func NewCanceledError(err error) error {
+	return fmt.Errorf("%w: %v", ErrCanceled, err)
+}
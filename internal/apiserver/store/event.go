@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// UserResource 是用户变更事件的资源名称。
+const UserResource = "users"
+
+// Event 是一条资源变更事件，和资源在同一个事务中写入 resource_event 表（outbox），
+// 用于 watch 在重启后从任意 resourceVersion 恢复。
+type Event struct {
+	// ID 即事件的 resourceVersion
+	ID        uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	Resource  string    `gorm:"column:resource"`
+	Type      string    `gorm:"column:type"`
+	Name      string    `gorm:"column:name"`
+	Object    string    `gorm:"column:object"`
+	CreatedAt time.Time `gorm:"column:createdAt"`
+}
+
+// TableName maps to mysql table name.
+func (e *Event) TableName() string {
+	return "resource_event"
+}
+
+// EventStore defines the resource event storage interface.
+// 事件的 ID 必须按写入事务的提交顺序递增，watch 按 ID 读取，较小的 ID 晚于较大的 ID 提交时会被跳过。
+type EventStore interface {
+	// List 返回 resource 的 ID 大于 since 的最多 limit 个事件，按 ID 升序排列。
+	List(ctx context.Context, resource string, since uint64, limit int) ([]*Event, error)
+	// Latest 返回 resource 最新事件的 ID，没有事件时返回 0。
+	Latest(ctx context.Context, resource string) (uint64, error)
+}
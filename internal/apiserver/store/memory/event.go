@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// maxEvents 是内存存储保留的事件数量，超过后丢弃最早的事件，避免事件无限增长。
+// 从被丢弃的事件之前恢复的 watch 会错过这些事件，内存存储只用于测试和演示环境。
+const maxEvents = 10000
+
+type events struct {
+	ds *datastore
+}
+
+func newEvents(ds *datastore) *events {
+	return &events{ds}
+}
+
+// List return the events of resource after since.
+func (e *events) List(ctx context.Context, resource string, since uint64, limit int) ([]*store.Event, error) {
+	e.ds.mu.RLock()
+	defer e.ds.mu.RUnlock()
+
+	var ret []*store.Event
+	for _, event := range e.ds.events {
+		if len(ret) >= limit {
+			break
+		}
+
+		if event.Resource == resource && event.ID > since {
+			c := *event
+			ret = append(ret, &c)
+		}
+	}
+
+	return ret, nil
+}
+
+// Latest return the id of the latest event of resource.
+func (e *events) Latest(ctx context.Context, resource string) (uint64, error) {
+	e.ds.mu.RLock()
+	defer e.ds.mu.RUnlock()
+
+	for i := len(e.ds.events) - 1; i >= 0; i-- {
+		if e.ds.events[i].Resource == resource {
+			return e.ds.events[i].ID, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// createEvent 写入一条资源变更事件，调用方需要持有写锁。最多保留 maxEvents 个事件。
+// 事件 ID 在所有资源之间递增，与 MySQL 的 AUTO_INCREMENT 相同。
+func (ds *datastore) createEvent(resource string, eventType metav1.EventType, name string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", resource, err)
+	}
+
+	ds.eventID++
+	ds.events = append(ds.events, &store.Event{
+		ID:        ds.eventID,
+		Resource:  resource,
+		Type:      string(eventType),
+		Name:      name,
+		Object:    string(data),
+		CreatedAt: time.Now(),
+	})
+
+	if len(ds.events) > maxEvents {
+		ds.events[0] = nil
+		ds.events = ds.events[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package memory 实现了基于内存的 store.Factory，数据在进程退出后丢失。
+// 它与 MySQL 存储具有相同的唯一性约束（name、instanceID）、错误和列表过滤行为，
+// 用于单元测试和不依赖数据库的演示环境。
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+)
+
+type datastore struct {
+	// mu 保护所有数据，写操作持有写锁，相当于 MySQL 存储中的事务
+	mu sync.RWMutex
+
+	users  map[uint64]*v1.User
+	userID uint64
+
+	events  []*store.Event
+	eventID uint64
+
+	// inTx 表示这是 Tx 中使用的快照
+	inTx bool
+}
+
+var _ store.Factory = (*datastore)(nil)
+
+// NewFactory 创建一个空的内存存储，每次调用返回独立的实例。
+func NewFactory() store.Factory {
+	return &datastore{
+		users: map[uint64]*v1.User{},
+	}
+}
+
+func (ds *datastore) Users() store.UserStore {
+	return newUsers(ds)
+}
+
+func (ds *datastore) Events() store.EventStore {
+	return newEvents(ds)
+}
+
+// Tx 在数据的快照上执行 fn，fn 返回 nil 时用快照替换数据，返回错误或 panic 时丢弃快照。
+// 执行期间持有写锁，其他读写操作等待事务结束，相当于 MySQL 的串行化隔离级别。
+func (ds *datastore) Tx(ctx context.Context, fn func(tx store.Factory) error) error {
+	if ds.inTx {
+		return store.ErrNestedTransaction
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	// 存储中的用户在修改时整体替换，不会原地修改，快照只需要复制 map 和切片
+	tx := &datastore{
+		users:   make(map[uint64]*v1.User, len(ds.users)),
+		userID:  ds.userID,
+		events:  append([]*store.Event(nil), ds.events...),
+		eventID: ds.eventID,
+		inTx:    true,
+	}
+	for id, user := range ds.users {
+		tx.users[id] = user
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	ds.users, ds.userID = tx.users, tx.userID
+	ds.events, ds.eventID = tx.events, tx.eventID
+
+	return nil
+}
+
+// Ping 总是返回 nil，内存存储总是可用。
+func (ds *datastore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (ds *datastore) Close() error {
+	if ds.inTx {
+		return fmt.Errorf("can not close the store inside a transaction")
+	}
+
+	return nil
+}
+
+// defaultLimit 是 List 没有指定 limit 时返回的最大记录数，与 MySQL 存储相同。
+const defaultLimit = 1000
+
+// unpointer 将 List 的 offset 和 limit 转换为切片使用的值，没有指定时不跳过记录并返回最多 defaultLimit 条记录。
+func unpointer(offset *int64, limit *int64) (int, int) {
+	o, l := 0, defaultLimit
+
+	if offset != nil {
+		o = int(*offset)
+	}
+
+	if limit != nil {
+		l = int(*limit)
+	}
+
+	return o, l
+}
+
+// copyUser 返回用户的副本，避免调用方修改存储中的数据。
+// Extend 和 MySQL 存储一样经过 JSON 编码和解码，不保存的字段（totalPolicy）被清空。
+func copyUser(user *v1.User) *v1.User {
+	c := *user
+	c.TotalPolicy = 0
+	c.Extend = nil
+
+	if len(user.Extend) != 0 {
+		if data, err := json.Marshal(user.Extend); err == nil {
+			_ = json.Unmarshal(data, &c.Extend)
+		}
+	}
+
+	return &c
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/pkg/fields"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+type users struct {
+	ds *datastore
+}
+
+func newUsers(ds *datastore) *users {
+	return &users{ds}
+}
+
+// Create creates a new user account.
+// name、instanceID 或指定的 ID 重复时返回 store.AlreadyExistsError。
+func (u *users) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
+	u.ds.mu.Lock()
+	defer u.ds.mu.Unlock()
+
+	if err := u.checkUnique(user, 0); err != nil {
+		return err
+	}
+
+	// 与 AUTO_INCREMENT 相同，指定的 ID 不能重复，之后分配的 ID 大于所有已使用的 ID
+	if user.ID == 0 {
+		user.ID = u.ds.userID + 1
+	} else if _, ok := u.ds.users[user.ID]; ok {
+		return store.NewAlreadyExistsError("id", fmt.Errorf("duplicate id %d", user.ID))
+	}
+
+	if user.ID > u.ds.userID {
+		u.ds.userID = user.ID
+	}
+
+	if user.Version == 0 {
+		user.Version = 1
+	}
+
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+
+	u.ds.users[user.ID] = copyUser(user)
+
+	if err := u.createUserEvent(metav1.Added, user); err != nil {
+		delete(u.ds.users, user.ID)
+
+		return err
+	}
+
+	return nil
+}
+
+// Get return an user by the user identifier.
+func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	user := u.getByName(username)
+	if user == nil {
+		return nil, fmt.Errorf("%w: user %s", store.ErrNotFound, username)
+	}
+
+	return copyUser(user), nil
+}
+
+// Update updates an user account information.
+// opts.Preconditions 不为空时，只有 version 没有变化才会更新，否则返回 store.ErrConflict。每次更新都会增加 version。
+func (u *users) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
+	u.ds.mu.Lock()
+	defer u.ds.mu.Unlock()
+
+	current, ok := u.ds.users[user.ID]
+	if p := opts.Preconditions; ok && p != nil && p.Version != nil && current.Version != *p.Version {
+		ok = false
+	}
+
+	if !ok {
+		if opts.Preconditions != nil {
+			return fmt.Errorf("%w: user %d was modified or deleted", store.ErrConflict, user.ID)
+		}
+
+		return nil
+	}
+
+	if err := u.checkUnique(user, user.ID); err != nil {
+		return err
+	}
+
+	user.Version = current.Version + 1
+	user.UpdatedAt = time.Now()
+	u.ds.users[user.ID] = copyUser(user)
+
+	if err := u.createUserEvent(metav1.Modified, user); err != nil {
+		u.ds.users[user.ID] = current
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete deletes the user by the user identifier.
+// opts.Preconditions 不为空时，只有 version 没有变化才会删除，否则返回 store.ErrConflict。
+func (u *users) Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error {
+	u.ds.mu.Lock()
+	defer u.ds.mu.Unlock()
+
+	user := u.getByName(username)
+	if user == nil {
+		if opts.Preconditions != nil {
+			return fmt.Errorf("%w: user %s was deleted", store.ErrConflict, username)
+		}
+
+		return nil
+	}
+
+	if p := opts.Preconditions; p != nil && p.Version != nil && user.Version != *p.Version {
+		return fmt.Errorf("%w: user %s was modified or deleted", store.ErrConflict, username)
+	}
+
+	if err := u.createUserEvent(metav1.Deleted, user); err != nil {
+		return err
+	}
+
+	delete(u.ds.users, user.ID)
+
+	return nil
+}
+
+// List return all users.
+// 与 MySQL 存储相同，支持 fieldSelector name=xxx 按用户名模糊匹配（不区分大小写），email=xxx 按 email 等值匹配
+// （不区分大小写），只返回可用（status = 1）的用户。
+func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+	selector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	username, _ := selector.RequiresExactMatch("name")
+	username = strings.ToLower(username)
+	email, byEmail := selector.RequiresExactMatch("email")
+	email = strings.TrimSpace(email)
+
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	var matched []*v1.User
+	for _, user := range u.ds.users {
+		if user.Status != 1 || !strings.Contains(strings.ToLower(user.Name), username) {
+			continue
+		}
+
+		if byEmail && !strings.EqualFold(user.Email, email) {
+			continue
+		}
+
+		matched = append(matched, user)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID > matched[j].ID
+	})
+
+	ret := &v1.UserList{Items: []*v1.User{}}
+	ret.TotalCount = int64(len(matched))
+
+	offset, limit := unpointer(opts.Offset, opts.Limit)
+	if offset < 0 || offset > len(matched) {
+		offset = len(matched)
+	}
+
+	matched = matched[offset:]
+	if limit >= 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	for _, user := range matched {
+		ret.Items = append(ret.Items, copyUser(user))
+	}
+
+	return ret, nil
+}
+
+// getByName 返回存储中的用户，调用方需要持有锁。
+func (u *users) getByName(username string) *v1.User {
+	for _, user := range u.ds.users {
+		if user.Name == username {
+			return user
+		}
+	}
+
+	return nil
+}
+
+// checkUnique 检查 name 和 instanceID 的唯一索引，exclude 为更新的用户 ID，调用方需要持有锁。
+// 与 MySQL 的 NULL 一样，空的 instanceID 不参与唯一性检查。
+func (u *users) checkUnique(user *v1.User, exclude uint64) error {
+	for id, existing := range u.ds.users {
+		if id == exclude {
+			continue
+		}
+
+		if existing.Name == user.Name {
+			return store.NewAlreadyExistsError("name", fmt.Errorf("duplicate name %q", user.Name))
+		}
+
+		if user.InstanceID != "" && existing.InstanceID == user.InstanceID {
+			return store.NewAlreadyExistsError("instanceID", fmt.Errorf("duplicate instanceID %q", user.InstanceID))
+		}
+	}
+
+	return nil
+}
+
+// createUserEvent 写入用户变更事件，事件中不包含密码。调用方需要持有写锁。
+func (u *users) createUserEvent(eventType metav1.EventType, user *v1.User) error {
+	object := *user
+	object.Password = ""
+
+	return u.ds.createEvent(store.UserResource, eventType, user.Name, &object)
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+func newUser(name string) *v1.User {
+	return &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Extend: map[string]interface{}{"emails": []string{"a"}}},
+		Status:     1,
+		Nickname:   name,
+		Password:   "hash",
+		Email:      name + "@example.com",
+	}
+}
+
+func TestUsers_CreateGet(t *testing.T) {
+	ctx := context.Background()
+	s := NewFactory()
+
+	user := newUser("colin")
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	assert.Equal(t, uint64(1), user.ID)
+	assert.False(t, user.CreatedAt.IsZero())
+
+	got, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	// Extend 与 MySQL 存储一样经过 JSON 往返
+	assert.Equal(t, []interface{}{"a"}, got.Extend["emails"])
+
+	// 返回的是副本
+	got.Nickname = "changed"
+	got, _ = s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.Equal(t, "colin", got.Nickname)
+
+	err = s.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{})
+	assertAlreadyExists(t, err, "name")
+
+	a, b := newUser("a"), newUser("b")
+	a.InstanceID, b.InstanceID = "user-1", "user-1"
+	require.NoError(t, s.Users().Create(ctx, a, metav1.CreateOptions{}))
+	err = s.Users().Create(ctx, b, metav1.CreateOptions{})
+	assertAlreadyExists(t, err, "instanceID")
+
+	_, err = s.Users().Get(ctx, "unknown", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+}
+
+func TestUsers_UpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewFactory()
+
+	user := newUser("colin")
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+
+	assert.Equal(t, uint64(1), user.Version)
+
+	stale := user.Version - 1
+	user.Nickname = "lee"
+	err := s.Users().Update(ctx, user, metav1.UpdateOptions{Preconditions: &metav1.Preconditions{Version: &stale}})
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	current := user.Version
+	require.NoError(t, s.Users().Update(ctx, user,
+		metav1.UpdateOptions{Preconditions: &metav1.Preconditions{Version: &current}}))
+
+	got, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "lee", got.Nickname)
+	assert.Equal(t, current+1, got.Version)
+
+	err = s.Users().Delete(ctx, "colin", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{Version: &current}})
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	require.NoError(t, s.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+	require.NoError(t, s.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+	err = s.Users().Delete(ctx, "colin", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{}})
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	// 不存在的用户不会被更新创建
+	require.NoError(t, s.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	_, err = s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+}
+
+func TestUsers_List(t *testing.T) {
+	ctx := context.Background()
+	s := NewFactory()
+
+	for _, name := range []string{"colin", "Colin2", "alice", "disabled-colin"} {
+		user := newUser(name)
+		if name == "disabled-colin" {
+			user.Status = 0
+		}
+		require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	}
+
+	list, err := s.Users().List(ctx, metav1.ListOptions{FieldSelector: "name=colin"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.TotalCount)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "Colin2", list.Items[0].Name)
+
+	offset, limit := int64(1), int64(1)
+	list, err = s.Users().List(ctx, metav1.ListOptions{Offset: &offset, Limit: &limit})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.TotalCount)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Colin2", list.Items[0].Name)
+
+	// email 等值匹配，不区分大小写
+	list, err = s.Users().List(ctx, metav1.ListOptions{FieldSelector: "email=COLIN@example.com"})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "colin", list.Items[0].Name)
+
+	_, err = s.Users().List(ctx, metav1.ListOptions{FieldSelector: "name"})
+	assert.Error(t, err)
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	s := NewFactory()
+
+	latest, err := s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Zero(t, latest)
+
+	user := newUser("colin")
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	require.NoError(t, s.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	require.NoError(t, s.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+
+	events, err := s.Events().List(ctx, store.UserResource, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, string(metav1.Modified), events[0].Type)
+	assert.Equal(t, string(metav1.Deleted), events[1].Type)
+	assert.NotContains(t, events[1].Object, "hash")
+
+	latest, err = s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), latest)
+}
+
+func TestEvents_Limit(t *testing.T) {
+	ctx := context.Background()
+	ds := NewFactory().(*datastore)
+
+	for i := 0; i < maxEvents+10; i++ {
+		require.NoError(t, ds.createEvent(store.UserResource, metav1.Modified, "colin", newUser("colin")))
+	}
+	assert.Len(t, ds.events, maxEvents)
+
+	// 最早的事件被丢弃，ID 保持递增
+	events, err := ds.Events().List(ctx, store.UserResource, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, uint64(11), events[0].ID)
+
+	latest, err := ds.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(maxEvents+10), latest)
+}
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+	s := NewFactory()
+
+	// 提交：事务中创建的用户和事件在提交后可见
+	require.NoError(t, s.Tx(ctx, func(tx store.Factory) error {
+		if err := tx.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+
+		_, err := tx.Users().Get(ctx, "colin", metav1.GetOptions{})
+
+		return err
+	}))
+
+	_, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	latest, err := s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+
+	// 回滚：fn 返回错误时之前的修改全部撤销，错误原样返回
+	err = s.Tx(ctx, func(tx store.Factory) error {
+		if err := tx.Users().Create(ctx, newUser("alice"), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+
+		return tx.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{})
+	})
+	assert.True(t, errors.Is(err, store.ErrAlreadyExists))
+
+	_, err = s.Users().Get(ctx, "alice", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+
+	// panic 时回滚并继续 panic
+	assert.Panics(t, func() {
+		_ = s.Tx(ctx, func(tx store.Factory) error {
+			_ = tx.Users().Delete(ctx, "colin", metav1.DeleteOptions{})
+			panic("boom")
+		})
+	})
+
+	_, err = s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	got, err := s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Equal(t, latest, got)
+
+	// 嵌套事务
+	err = s.Tx(ctx, func(tx store.Factory) error {
+		return tx.Tx(ctx, func(store.Factory) error { return nil })
+	})
+	assert.True(t, errors.Is(err, store.ErrNestedTransaction))
+}
+
+func assertAlreadyExists(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var exists *store.AlreadyExistsError
+	require.True(t, errors.As(err, &exists), "unexpected error: %v", err)
+	assert.True(t, errors.Is(err, store.ErrAlreadyExists))
+	assert.Equal(t, field, exists.Field)
+}
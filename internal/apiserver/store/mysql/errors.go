@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"gorm.io/gorm"
+)
+
+// MySQL 服务端错误码，见 https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	erDupEntry         = 1062
+	erConCount         = 1040
+	erLockWaitTimeout  = 1205
+	erLockDeadlock     = 1213
+	erServerShutdown   = 1053
+	erQueryInterrupted = 1317
+	erQueryTimeout     = 3024
+)
+
+// uniqueKeyFields 将唯一索引名映射为字段名，索引定义见 migrations。
+var uniqueKeyFields = map[string]string{
+	"PRIMARY":           "id",
+	"idx_name":          "name",
+	"instanceID_UNIQUE": "instanceID",
+}
+
+// translateError 将 gorm 和 MySQL 驱动返回的错误转换为 store 中定义的错误，无法识别的错误原样返回。
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", store.ErrNotFound, err)
+	}
+
+	// 请求取消或超时不是数据库的问题，与 ErrUnavailable 区分开，重试没有意义
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return store.NewCanceledError(err)
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case erDupEntry:
+			return store.NewAlreadyExistsError(duplicateField(mysqlErr.Message), err)
+		case erConCount, erLockWaitTimeout, erLockDeadlock, erServerShutdown, erQueryInterrupted:
+			return store.NewUnavailableError(err)
+		case erQueryTimeout:
+			// 超过服务端的 max_execution_time
+			return store.NewCanceledError(err)
+		}
+
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysqldriver.ErrInvalidConn) || errors.As(err, &netErr) {
+		return store.NewUnavailableError(err)
+	}
+
+	return err
+}
+
+// duplicateField 从 1062 错误的信息中取出冲突的索引并返回对应的字段名。
+// 不同版本的信息格式不同：MySQL 5.7 为 for key 'idx_name'，MySQL 8.0 为 for key 'user.idx_name'。
+func duplicateField(message string) string {
+	i := strings.LastIndex(message, "for key '")
+	if i < 0 {
+		return ""
+	}
+
+	key := strings.TrimSuffix(message[i+len("for key '"):], "'")
+	if j := strings.LastIndex(key, "."); j >= 0 {
+		key = key[j+1:]
+	}
+
+	return uniqueKeyFields[key]
+}
@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{"not found", gorm.ErrRecordNotFound, store.ErrNotFound},
+		{"duplicate", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'colin' for key 'idx_name'"},
+			store.ErrAlreadyExists},
+		{"deadlock", &mysqldriver.MySQLError{Number: 1213, Message: "Deadlock found"}, store.ErrUnavailable},
+		{"bad conn", mysqldriver.ErrInvalidConn, store.ErrUnavailable},
+		{"canceled", context.Canceled, store.ErrCanceled},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), store.ErrCanceled},
+		{"max execution time", &mysqldriver.MySQLError{Number: 3024, Message: "Query execution was interrupted"},
+			store.ErrCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, errors.Is(translateError(tt.err), tt.target))
+		})
+	}
+
+	assert.Nil(t, translateError(nil))
+
+	other := &mysqldriver.MySQLError{Number: 1146, Message: "Table 'iam.user' doesn't exist"}
+	assert.Equal(t, error(other), translateError(other))
+
+	var exists *store.AlreadyExistsError
+	err := translateError(fmt.Errorf("create: %w",
+		&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'user-1' for key 'user.instanceID_UNIQUE'"}))
+	assert.True(t, errors.As(err, &exists))
+	assert.Equal(t, "instanceID", exists.Field)
+}
+
+func TestDuplicateField(t *testing.T) {
+	assert.Equal(t, "name", duplicateField("Duplicate entry 'colin' for key 'idx_name'"))
+	assert.Equal(t, "name", duplicateField("Duplicate entry 'colin' for key 'user.idx_name'"))
+	assert.Equal(t, "id", duplicateField("Duplicate entry '1' for key 'PRIMARY'"))
+	assert.Equal(t, "", duplicateField("Duplicate entry 'x' for key 'idx_other'"))
+	assert.Equal(t, "", duplicateField("unexpected"))
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), 0)
+	defer cancel()
+
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	ctx, cancel = withTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	// 请求的截止时间更早时以请求为准
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+
+	ctx, cancel = withTimeout(parent, time.Hour)
+	defer cancel()
+
+	want, _ := parent.Deadline()
+	deadline, _ = ctx.Deadline()
+	assert.Equal(t, want, deadline)
+}
@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/pii"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"gorm.io/gorm"
+)
+
+// events 总是读取主库：写入后 Notify 的 watch 立即读取事件，从库的复制延迟会使其读不到新事件。
+type events struct {
+	db      *gorm.DB
+	timeout time.Duration
+	keys    *encryption.Keyring
+}
+
+func newEvents(ds *datastore) *events {
+	return &events{db: ds.db, timeout: ds.queryTimeout, keys: ds.keys}
+}
+
+// List return the events of resource after since.
+// 用户事件中加密的 email 和 phone 被解密。
+func (e *events) List(ctx context.Context, resource string, since uint64, limit int) ([]*store.Event, error) {
+	ctx, cancel := withTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var ret []*store.Event
+
+	err := e.db.WithContext(ctx).
+		Where("resource = ? AND id > ?", resource, since).
+		Order("id").
+		Limit(limit).
+		Find(&ret).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := pii.OpenEvents(ctx, e.keys, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Latest return the id of the latest event of resource.
+func (e *events) Latest(ctx context.Context, resource string) (uint64, error) {
+	ctx, cancel := withTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var latest *uint64
+
+	err := e.db.WithContext(ctx).Model(&store.Event{}).
+		Where("resource = ?", resource).
+		Select("MAX(id)").
+		Scan(&latest).Error
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	if latest == nil {
+		return 0, nil
+	}
+
+	return *latest, nil
+}
+
+// createEvent 在事务 tx 中写入一条资源变更事件。
+// 事件的 id 从 resource_event_sequence 分配而不是使用自增 id：更新计数器的行锁持有到事务提交，
+// 其他事务只能在提交之后分配更大的 id，List 按 id 读取时不会因为事务提交顺序不同而跳过事件。
+func createEvent(tx *gorm.DB, resource string, eventType metav1.EventType, name string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", resource, err)
+	}
+
+	id, err := nextEventID(tx)
+	if err != nil {
+		return err
+	}
+
+	event := &store.Event{
+		ID:       id,
+		Resource: resource,
+		Type:     string(eventType),
+		Name:     name,
+		Object:   string(data),
+	}
+
+	return tx.Create(event).Error
+}
+
+// nextEventID 在事务 tx 中分配下一个事件 id。
+func nextEventID(tx *gorm.DB) (uint64, error) {
+	result := tx.Exec("UPDATE `resource_event_sequence` SET `value` = `value` + 1 WHERE `id` = 1")
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("resource_event_sequence is not initialized, run the migrations first")
+	}
+
+	var id uint64
+	if err := tx.Raw("SELECT `value` FROM `resource_event_sequence` WHERE `id` = 1").Scan(&id).Error; err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
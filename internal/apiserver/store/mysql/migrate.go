@@ -0,0 +1,21 @@
+package mysql
+
+import (
+	"embed"
+
+	"github.com/tiandh987/SharkAgent/pkg/migrate"
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// NewMigrator 创建在 db 上执行 MySQL 表结构迁移的 Migrator。
+func NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	ms, err := migrate.FromFS(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(db, ms)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `idempotency_record` (
+    `idempotencyKey` char(64) NOT NULL COMMENT 'sha256 of the caller scoped Idempotency-Key',
+    `fingerprint` char(64) NOT NULL COMMENT 'sha256 of the request method, path and body',
+    `completed` tinyint(1) NOT NULL DEFAULT 0 COMMENT '1: response saved, 0: request in progress',
+    `statusCode` int(3) NOT NULL DEFAULT 0,
+    `contentType` varchar(255) NOT NULL DEFAULT '',
+    `body` longblob DEFAULT NULL,
+    `expiresAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`idempotencyKey`),
+    KEY `idx_expiresAt` (`expiresAt`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `resource_event` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'resourceVersion of the event',
+    `resource` varchar(64) NOT NULL,
+    `type` varchar(16) NOT NULL COMMENT 'ADDED, MODIFIED or DELETED',
+    `name` varchar(64) NOT NULL,
+    `object` longtext NOT NULL COMMENT 'json encoded resource',
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `idx_resource_id` (`resource`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,7 @@
+-- phone 已经加密时无法缩短，回滚前需要关闭加密并恢复明文
+ALTER TABLE `user`
+    DROP KEY `idx_emailIndex`,
+    DROP COLUMN `emailIndex`,
+    MODIFY `phone` varchar(20) DEFAULT NULL;
+
+DROP TABLE IF EXISTS `data_key`;
@@ -0,0 +1,13 @@
+-- 数据密钥被主密钥加密后保存，加密值中记录数据密钥的版本，数据密钥不能删除
+CREATE TABLE IF NOT EXISTS `data_key` (
+    `version` int(10) unsigned NOT NULL,
+    `key` varchar(255) NOT NULL COMMENT 'base64 encoded data key encrypted by the master key',
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- 加密后的 phone 超过 20 个字符，emailIndex 是 email 的盲索引，用于按 email 等值查询
+ALTER TABLE `user`
+    MODIFY `phone` varchar(255) DEFAULT NULL,
+    ADD COLUMN `emailIndex` char(64) DEFAULT NULL COMMENT 'blind index of email' AFTER `email`,
+    ADD KEY `idx_emailIndex` (`emailIndex`);
@@ -0,0 +1,3 @@
+-- version 在每次写入时加 1，用于 ETag 和 If-Match，timestamp 只精确到秒，无法区分同一秒内的两次写入
+ALTER TABLE `user`
+    ADD COLUMN `version` bigint(20) unsigned NOT NULL DEFAULT 1 COMMENT 'incremented on every write' AFTER `extendShadow`;
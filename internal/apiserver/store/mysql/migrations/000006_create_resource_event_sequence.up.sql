@@ -0,0 +1,10 @@
+-- 事件的 id 在事务中从这一行分配，行锁持有到提交，id 的顺序与提交顺序一致。
+-- AUTO_INCREMENT 在插入时分配，事务提交顺序不同时 watch 会跳过较小 id 的事件
+CREATE TABLE IF NOT EXISTS `resource_event_sequence` (
+    `id` tinyint(3) unsigned NOT NULL,
+    `value` bigint(20) unsigned NOT NULL COMMENT 'id of the latest resource event',
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+INSERT INTO `resource_event_sequence` (`id`, `value`)
+    SELECT 1, COALESCE(MAX(`id`), 0) FROM `resource_event`;
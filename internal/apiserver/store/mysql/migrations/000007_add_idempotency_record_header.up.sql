@@ -0,0 +1,3 @@
+-- header 保存 handler 设置的响应头（JSON），例如 ETag 和 Location，重放时一起返回
+ALTER TABLE `idempotency_record`
+    ADD COLUMN `header` text DEFAULT NULL COMMENT 'JSON encoded response headers set by the handler' AFTER `contentType`;
@@ -1,34 +1,104 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
 	"github.com/tiandh987/SharkAgent/internal/pkg/logger"
 	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
 	"github.com/tiandh987/SharkAgent/pkg/db"
+	"github.com/tiandh987/SharkAgent/pkg/log"
 	"gorm.io/gorm"
 	"sync"
+	"time"
 )
 
 var (
-	mysqlFactory store.Factory
-	once         sync.Once
+	// mu 保护 mysqlFactory，mysqlFactory 在 Close 后重置为 nil，之后可以重新创建
+	mu           sync.Mutex
+	mysqlFactory *datastore
+)
+
+// 启动时连接数据库的重试间隔，从 initialBackoff 开始每次翻倍，最多 maxBackoff。
+var (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
 )
 
 type datastore struct {
+	// db 是主库，事务中为 Tx 开启的事务
 	db *gorm.DB
-
-	// can include two database instance if needed
-	// docker *grom.DB
-	// db *gorm.DB
+	// cluster 包含主库和只读从库，没有配置从库或在事务中时为 nil，读写都使用 db
+	cluster *db.Cluster
+	// inTx 表示 db 是 Tx 开启的事务
+	inTx bool
+	// queryTimeout 是每个存储方法的默认超时时间，0 表示不限制
+	queryTimeout time.Duration
+	// keys 加密用户的 email 和 phone，没有启用加密时为 nil
+	keys *encryption.Keyring
 }
 
 func (ds *datastore) Users() store.UserStore {
 	return newUsers(ds)
 }
 
+func (ds *datastore) Events() store.EventStore {
+	return newEvents(ds)
+}
+
+// Tx 在一个数据库事务中执行 fn，回滚和提交由 gorm 的 Transaction 完成，panic 时同样回滚。
+// 事务中各个存储的方法仍然使用 Transaction，gorm 将其转换为 SAVEPOINT。
+func (ds *datastore) Tx(ctx context.Context, fn func(tx store.Factory) error) error {
+	if ds.inTx {
+		return store.ErrNestedTransaction
+	}
+
+	var fnErr error
+
+	err := ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(&datastore{db: tx, inTx: true, queryTimeout: ds.queryTimeout, keys: ds.keys})
+
+		return fnErr
+	})
+	if err != nil && err == fnErr {
+		// fn 返回的错误已经由各个存储转换
+		return err
+	}
+
+	return translateError(err)
+}
+
+// Ping 检查主库是否可用。从库的可用性由 db.Cluster 的健康检查维护，从库都不可用时读操作使用主库，不影响就绪状态。
+func (ds *datastore) Ping(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, ds.queryTimeout)
+	defer cancel()
+
+	return translateError(ds.db.WithContext(ctx).Exec("SELECT 1").Error)
+}
+
+// Close 关闭数据库连接，关闭的是 GetMySQLFactoryOr 返回的实例时，下一次调用 GetMySQLFactoryOr 重新连接数据库。
 func (ds *datastore) Close() error {
+	if ds.inTx {
+		return fmt.Errorf("can not close the store inside a transaction")
+	}
+
+	mu.Lock()
+	if mysqlFactory == ds {
+		mysqlFactory = nil
+	}
+	mu.Unlock()
+
+	return ds.closeDB()
+}
+
+// closeDB 关闭主库和从库的连接。
+func (ds *datastore) closeDB() error {
+	if ds.cluster != nil {
+		return ds.cluster.Close()
+	}
+
 	db, err := ds.db.DB()
 	if err != nil {
 		return errors.Wrap(err, "get gorm db instance failed")
@@ -37,39 +107,151 @@ func (ds *datastore) Close() error {
 	return db.Close()
 }
 
-// GetMySQLFactoryOr 使用给定的配置创建一个 mysql 工厂
-func GetMySQLFactoryOr(opts *genericoptions.MySQLOptions) (store.Factory, error) {
-	if opts == nil && mysqlFactory == nil {
-		return nil, fmt.Errorf("failed to get mysql store fatory")
-	}
-
-	var err error
-	var dbIns *gorm.DB
-
-	once.Do(func() {
-		options := &db.Options{
-			Host:                  opts.Host,
-			Username:              opts.Username,
-			Password:              opts.Password,
-			Database:              opts.Database,
-			MaxIdleConnections:    opts.MaxIdleConnections,
-			MaxOpenConnections:    opts.MaxOpenConnections,
-			MaxConnectionLifeTime: opts.MaxConnectionLifeTime,
-			LogLevel:              opts.LogLevel,
-			Logger:                logger.New(opts.LogLevel),
-		}
-		dbIns, err = db.New(options)
+// reader 返回读操作使用的数据库。请求已经写入（store.Written）时使用主库，保证读到自己的写入，
+// 否则使用健康的从库。
+func (ds *datastore) reader(ctx context.Context) *gorm.DB {
+	if ds.cluster == nil || store.Written(ctx) {
+		return ds.db
+	}
+
+	return ds.cluster.Replica()
+}
+
+// read 在 reader 返回的数据库上执行读操作 fn，从库不可用时将其标记为不可用，并在主库上重试。
+func (ds *datastore) read(ctx context.Context, fn func(db *gorm.DB) error) error {
+	rdb := ds.reader(ctx)
+
+	err := fn(rdb.WithContext(ctx))
+	if err != nil && rdb != ds.db && errors.Is(translateError(err), store.ErrUnavailable) {
+		ds.cluster.MarkDown(rdb)
+
+		err = fn(ds.db.WithContext(ctx))
+	}
+
+	return err
+}
+
+// GetMySQLFactoryOr 返回 mysql 工厂，还没有创建或已经被关闭时使用 opts 创建。
+// 数据库无法连接时按指数退避重试，超过 opts.ConnectTimeout 后返回最后一次连接的错误。
+// masterKey 不为 nil 时加密用户的 email 和 phone，见 pii。已经创建时忽略 opts 和 masterKey，opts 可以为 nil。
+func GetMySQLFactoryOr(opts *genericoptions.MySQLOptions, masterKey []byte) (store.Factory, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if mysqlFactory != nil {
+		return mysqlFactory, nil
+	}
+
+	if opts == nil {
+		return nil, fmt.Errorf("mysql store is not initialized, mysql options are required")
+	}
+
+	var ds *datastore
 
-		// uncomment the following line if you need auto migration the given models
-		// not suggested in production environment.
-		// migrateDatabase(dbIns)
+	err := retry(opts.ConnectTimeout, func() error {
+		var err error
+		ds, err = newDatastore(opts)
 
-		mysqlFactory = &datastore{dbIns}
+		return err
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mysql %s: %w", opts.Host, err)
+	}
+
+	if masterKey != nil {
+		// 数据密钥保存在主库中
+		if ds.keys, err = encryption.LoadKeyring(context.Background(), ds.db, masterKey); err != nil {
+			_ = ds.closeDB()
 
-	if mysqlFactory == nil || err != nil {
-		return nil, fmt.Errorf("failed to get mysql store fatory, mysqlFactory: %+v, error: %w", mysqlFactory, err)
+			return nil, fmt.Errorf("load data keys: %w", err)
+		}
 	}
 
+	mysqlFactory = ds
+
 	return mysqlFactory, nil
 }
+
+// newDatastore 连接主库和从库。表结构不会自动迁移，使用 apiserver migrate 子命令执行，见 NewMigrator。
+func newDatastore(opts *genericoptions.MySQLOptions) (*datastore, error) {
+	options := &db.Options{
+		Host:                  opts.Host,
+		Username:              opts.Username,
+		Password:              opts.Password,
+		Database:              opts.Database,
+		MaxIdleConnections:    opts.MaxIdleConnections,
+		MaxOpenConnections:    opts.MaxOpenConnections,
+		MaxConnectionLifeTime: opts.MaxConnectionLifeTime,
+		LogLevel:              opts.LogLevel,
+		Logger:                logger.New(opts.LogLevel),
+	}
+
+	if len(opts.Replicas) == 0 {
+		dbIns, err := db.New(options)
+		if err != nil {
+			return nil, err
+		}
+
+		return &datastore{db: dbIns, queryTimeout: opts.QueryTimeout}, nil
+	}
+
+	cluster, err := db.NewCluster(options, opts.Replicas, opts.ReplicaHealthCheck)
+	if err != nil {
+		return nil, err
+	}
+
+	return &datastore{db: cluster.Primary(), cluster: cluster, queryTimeout: opts.QueryTimeout}, nil
+}
+
+// retry 调用 connect 直到成功或超过 timeout，两次调用之间等待的时间从 initialBackoff 开始翻倍，最多 maxBackoff。
+// 剩余时间不足以等待下一次重试时返回最后一次的错误，timeout 为 0 时只调用一次。
+func retry(timeout time.Duration, connect func() error) error {
+	deadline := time.Now().Add(timeout)
+	backoff := initialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := connect()
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		log.Warnf("Connect to mysql failed (attempt %d), retry in %s: %s", attempt, backoff, err.Error())
+		time.Sleep(backoff)
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// withTimeout 返回设置了默认查询超时时间的 context，ctx 有更早的截止时间时以 ctx 为准。
+// 超时或 ctx 被取消时，驱动中止正在执行的查询，返回的错误由 translateError 转换为 store.ErrCanceled。
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// defaultLimit 是 List 没有指定 limit 时返回的最大记录数。
+const defaultLimit = 1000
+
+// unpointer 将 List 的 offset 和 limit 转换为 gorm 使用的值，没有指定时不跳过记录并返回最多 defaultLimit 条记录。
+func unpointer(offset *int64, limit *int64) (int, int) {
+	o, l := 0, defaultLimit
+
+	if offset != nil {
+		o = int(*offset)
+	}
+
+	if limit != nil {
+		l = int(*limit)
+	}
+
+	return o, l
+}
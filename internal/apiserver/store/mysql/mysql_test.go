@@ -0,0 +1,78 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	"github.com/tiandh987/SharkAgent/pkg/db"
+)
+
+func TestRetry(t *testing.T) {
+	initialBackoff, maxBackoff = time.Millisecond, 4*time.Millisecond
+	defer func() { initialBackoff, maxBackoff = 500*time.Millisecond, 10*time.Second }()
+
+	down := errors.New("connection refused")
+
+	attempts := 0
+	err := retry(time.Second, func() error {
+		if attempts++; attempts < 5 {
+			return down
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, attempts)
+
+	// 超过 timeout 后返回最后一次的错误
+	attempts = 0
+	err = retry(20*time.Millisecond, func() error {
+		attempts++
+
+		return down
+	})
+	assert.True(t, errors.Is(err, down))
+	assert.Greater(t, attempts, 1)
+
+	// timeout 为 0 时只尝试一次
+	attempts = 0
+	err = retry(0, func() error {
+		attempts++
+
+		return down
+	})
+	assert.True(t, errors.Is(err, down))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestGetMySQLFactoryOr(t *testing.T) {
+	_, err := GetMySQLFactoryOr(nil, nil)
+	assert.EqualError(t, err, "mysql store is not initialized, mysql options are required")
+
+	opts := genericoptions.NewMySQLOptions()
+	opts.Host, opts.ConnectTimeout = "127.0.0.1:1", 0
+	_, err = GetMySQLFactoryOr(opts, nil)
+	assert.Error(t, err)
+
+	// Close 之后重新创建
+	dbIns, err := db.NewSQLite(&db.SQLiteOptions{Path: ":memory:", JournalMode: "MEMORY", BusyTimeout: time.Second})
+	require.NoError(t, err)
+
+	mu.Lock()
+	mysqlFactory = &datastore{db: dbIns}
+	mu.Unlock()
+
+	factory, err := GetMySQLFactoryOr(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, factory.Ping(context.Background()))
+	require.NoError(t, factory.Close())
+
+	_, err = GetMySQLFactoryOr(nil, nil)
+	assert.Error(t, err)
+}
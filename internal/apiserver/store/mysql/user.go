@@ -2,20 +2,230 @@ package mysql
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/marmotedu/errors"
 	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/pii"
+	"github.com/tiandh987/SharkAgent/pkg/fields"
 	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
 	"gorm.io/gorm"
 )
 
 type users struct {
-	db *gorm.DB
+	ds *datastore
 }
 
 func newUsers(ds *datastore) *users {
-	return &users{ds.db}
+	return &users{ds}
 }
 
 // Create creates a new user account.
+// 用户和 ADDED 事件在同一个事务中写入。
 func (u *users) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
-	return u.db.Create(&user).Error
+	sealed, emailIndex, err := pii.Seal(u.ds.keys, user)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, u.ds.queryTimeout)
+	defer cancel()
+
+	store.MarkWritten(ctx)
+
+	err = u.ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sealed).Error; err != nil {
+			return err
+		}
+
+		if err := pii.SetEmailIndex(tx, sealed.ID, emailIndex); err != nil {
+			return err
+		}
+
+		return createUserEvent(tx, metav1.Added, sealed)
+	})
+	pii.Unseal(user, sealed)
+
+	return translateError(err)
+}
+
+// Get return an user by the user identifier.
+// 配置了从库时读取从库，同一个请求已经写入时读取主库。
+func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	ctx, cancel := withTimeout(ctx, u.ds.queryTimeout)
+	defer cancel()
+
+	user := &v1.User{}
+	err := u.ds.read(ctx, func(db *gorm.DB) error {
+		return db.Where("name = ?", username).First(&user).Error
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := pii.Open(ctx, u.ds.keys, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Update updates an user account information.
+// opts.Preconditions 不为空时，只有 version 没有变化才会更新，否则返回 store.ErrConflict。
+// 每次更新都会增加 version，并在同一个事务中写入 MODIFIED 事件。
+func (u *users) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
+	sealed, emailIndex, err := pii.Seal(u.ds.keys, user)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, u.ds.queryTimeout)
+	defer cancel()
+
+	store.MarkWritten(ctx)
+
+	err = u.ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先增加 version，version 总会变化，RowsAffected 为 0 说明记录不存在或者前置条件不满足，
+		// 同时锁住记录，保证 version 与写入的内容一一对应
+		db := tx.Model(&v1.User{}).Where("id = ?", user.ID)
+		if p := opts.Preconditions; p != nil && p.Version != nil {
+			db = db.Where("version = ?", *p.Version)
+		}
+
+		result := db.UpdateColumn("version", gorm.Expr("version + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			if opts.Preconditions != nil {
+				return fmt.Errorf("%w: user %d was modified or deleted", store.ErrConflict, user.ID)
+			}
+
+			return nil
+		}
+
+		// 使用 Select("*") 更新所有字段，并且不会在记录不存在时像 Save 一样创建新记录
+		if err := tx.Select("*").Omit("version").Updates(sealed).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&v1.User{}).Where("id = ?", user.ID).Pluck("version", &sealed.Version).Error; err != nil {
+			return err
+		}
+
+		if err := pii.SetEmailIndex(tx, sealed.ID, emailIndex); err != nil {
+			return err
+		}
+
+		return createUserEvent(tx, metav1.Modified, sealed)
+	})
+	pii.Unseal(user, sealed)
+
+	return translateError(err)
+}
+
+// Delete deletes the user by the user identifier.
+// opts.Preconditions 不为空时，只有 version 没有变化才会删除，否则返回 store.ErrConflict。
+// 用户被删除时，在同一个事务中写入包含删除前状态的 DELETED 事件。
+func (u *users) Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error {
+	ctx, cancel := withTimeout(ctx, u.ds.queryTimeout)
+	defer cancel()
+
+	store.MarkWritten(ctx)
+
+	err := u.ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &v1.User{}
+		if err := tx.Where("name = ?", username).First(user).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if opts.Preconditions != nil {
+				return fmt.Errorf("%w: user %s was deleted", store.ErrConflict, username)
+			}
+
+			return nil
+		}
+
+		db := tx
+		if opts.Unscoped {
+			db = db.Unscoped()
+		}
+
+		db = db.Where("id = ?", user.ID)
+		if p := opts.Preconditions; p != nil && p.Version != nil {
+			db = db.Where("version = ?", *p.Version)
+		}
+
+		result := db.Delete(&v1.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			if opts.Preconditions != nil {
+				return fmt.Errorf("%w: user %s was modified or deleted", store.ErrConflict, username)
+			}
+
+			return nil
+		}
+
+		return createUserEvent(tx, metav1.Deleted, user)
+	})
+
+	return translateError(err)
+}
+
+// List return all users.
+// 支持 fieldSelector name=xxx 按用户名模糊匹配，email=xxx 按 email 等值匹配（不区分大小写），
+// 只返回可用（status = 1）的用户。与 Get 一样优先读取从库。
+func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+	selector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, u.ds.queryTimeout)
+	defer cancel()
+
+	ret := &v1.UserList{}
+	offset, limit := unpointer(opts.Offset, opts.Limit)
+	username, _ := selector.RequiresExactMatch("name")
+	email, byEmail := selector.RequiresExactMatch("email")
+
+	err = u.ds.read(ctx, func(db *gorm.DB) error {
+		db = db.Where("name like ? and status = 1", "%"+username+"%")
+		if byEmail {
+			db = pii.WhereEmail(db, u.ds.keys, email)
+		}
+
+		return db.Offset(offset).
+			Limit(limit).
+			Order("id desc").
+			Find(&ret.Items).
+			Offset(-1).
+			Limit(-1).
+			Count(&ret.TotalCount).Error
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	for _, user := range ret.Items {
+		if err := pii.Open(ctx, u.ds.keys, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// createUserEvent 写入用户变更事件，事件中不包含密码。
+func createUserEvent(tx *gorm.DB, eventType metav1.EventType, user *v1.User) error {
+	object := *user
+	object.Password = ""
+
+	return createEvent(tx, store.UserResource, eventType, user.Name, &object)
 }
@@ -0,0 +1,138 @@
+// Package pii 加密用户表中的个人信息（email 和 phone），由 mysql 和 sqlite 存储共用。
+//
+// 写入时加密 email 和 phone，并将 email 的盲索引写入 emailIndex 列，按 email 查询时使用盲索引；
+// 读取时解密。用户变更事件中的用户与用户表一样保存加密后的值。
+// Keyring 为 nil 表示没有启用加密，此时读到加密的值会返回错误，避免将密文返回给客户端。
+package pii
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	"gorm.io/gorm"
+)
+
+// 加密的字段名，与用户名一起作为加密的附加数据，也用于计算盲索引。
+const (
+	fieldEmail = "email"
+	fieldPhone = "phone"
+)
+
+// Seal 返回 email 和 phone 加密后的 user 副本，以及 email 的盲索引。keys 为 nil 时返回 user 本身和空的索引。
+// 加密值与用户名绑定，不能被复制到其他用户的行中使用，用户名创建后不能修改。
+func Seal(keys *encryption.Keyring, user *v1.User) (*v1.User, string, error) {
+	if keys == nil {
+		return user, "", nil
+	}
+
+	sealed := *user
+
+	var err error
+	if sealed.Email, err = keys.Encrypt(fieldEmail, user.Name, user.Email); err != nil {
+		return nil, "", err
+	}
+
+	if sealed.Phone, err = keys.Encrypt(fieldPhone, user.Name, user.Phone); err != nil {
+		return nil, "", err
+	}
+
+	return &sealed, keys.BlindIndex(fieldEmail, user.Email), nil
+}
+
+// Unseal 将 Seal 返回的 sealed 在写入后得到的字段（ID、时间等）复制到 user，email 和 phone 保持明文。
+func Unseal(user, sealed *v1.User) {
+	if user == sealed {
+		return
+	}
+
+	email, phone := user.Email, user.Phone
+	*user = *sealed
+	user.Email, user.Phone = email, phone
+}
+
+// Open 解密从数据库读到的 user 的 email 和 phone。
+func Open(ctx context.Context, keys *encryption.Keyring, user *v1.User) error {
+	if keys == nil {
+		if encryption.IsEncrypted(user.Email) || encryption.IsEncrypted(user.Phone) {
+			return fmt.Errorf("user %s is encrypted but --encryption.enabled is false", user.Name)
+		}
+
+		return nil
+	}
+
+	var err error
+	if user.Email, err = decrypt(ctx, keys, fieldEmail, user.Name, user.Email); err != nil {
+		return fmt.Errorf("user %s: %w", user.Name, err)
+	}
+
+	if user.Phone, err = decrypt(ctx, keys, fieldPhone, user.Name, user.Phone); err != nil {
+		return fmt.Errorf("user %s: %w", user.Name, err)
+	}
+
+	return nil
+}
+
+// decrypt 解密用户 name 的 field 字段的值，启用加密之前写入的明文原样返回。
+func decrypt(ctx context.Context, keys *encryption.Keyring, field, name, value string) (string, error) {
+	if !encryption.IsEncrypted(value) {
+		return value, nil
+	}
+
+	return keys.Decrypt(ctx, field, name, value)
+}
+
+// OpenEvents 解密用户变更事件中的用户，其他资源的事件不变。
+func OpenEvents(ctx context.Context, keys *encryption.Keyring, events []*store.Event) error {
+	for _, event := range events {
+		if event.Resource != store.UserResource {
+			continue
+		}
+
+		user := &v1.User{}
+		if err := json.Unmarshal([]byte(event.Object), user); err != nil {
+			return fmt.Errorf("decode user event %d: %w", event.ID, err)
+		}
+
+		if !encryption.IsEncrypted(user.Email) && !encryption.IsEncrypted(user.Phone) {
+			continue
+		}
+
+		if err := Open(ctx, keys, user); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(user)
+		if err != nil {
+			return fmt.Errorf("encode user event %d: %w", event.ID, err)
+		}
+
+		event.Object = string(data)
+	}
+
+	return nil
+}
+
+// SetEmailIndex 在 tx 中写入用户 id 的 email 盲索引，index 为空（没有启用加密）时不写入。
+// emailIndex 不是 v1.User 的字段，因此在写入用户之后单独更新，不修改 updatedAt。
+func SetEmailIndex(tx *gorm.DB, id uint64, index string) error {
+	if index == "" {
+		return nil
+	}
+
+	return tx.Table("user").Where("id = ?", id).UpdateColumn("emailIndex", index).Error
+}
+
+// WhereEmail 返回按 email 等值查询的条件，启用加密时使用盲索引。
+// 启用加密之前写入、还没有重新加密的用户没有盲索引，不会被查到，见 Reencrypt。
+func WhereEmail(db *gorm.DB, keys *encryption.Keyring, email string) *gorm.DB {
+	if keys == nil {
+		return db.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email)))
+	}
+
+	return db.Where("emailIndex = ?", keys.BlindIndex(fieldEmail, email))
+}
@@ -0,0 +1,135 @@
+package pii
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	"gorm.io/gorm"
+)
+
+// row 是重新加密时读取的用户表的列。
+type row struct {
+	ID    uint64 `gorm:"column:id"`
+	Name  string `gorm:"column:name"`
+	Email string `gorm:"column:email"`
+	Phone string `gorm:"column:phone"`
+}
+
+// ReencryptResult 是 Reencrypt 的结果。
+type ReencryptResult struct {
+	// Updated 是重新加密的用户数
+	Updated int
+	// Skipped 是读取后被其他请求修改、没有重新加密的用户数，再次执行 Reencrypt 即可
+	Skipped int
+}
+
+// Reencrypt 按 id 顺序每次读取 batchSize 个用户，将没有使用当前数据密钥加密的 email 和 phone
+// （包括启用加密之前写入的明文和没有与用户名绑定的旧格式加密值）使用当前数据密钥重新加密，并写入 email 的盲索引。
+// 每一批在一个事务中执行，中断后再次执行会跳过已经使用当前数据密钥加密的用户。
+// 只有 email 和 phone 在读取后没有变化时才会更新，不会覆盖 apiserver 同时写入的值，也不修改 updatedAt。
+// progress 不为 nil 时在每一批完成后调用。
+func Reencrypt(ctx context.Context, db *gorm.DB, keys *encryption.Keyring, batchSize int,
+	progress func(ReencryptResult)) (ReencryptResult, error) {
+	var (
+		result ReencryptResult
+		lastID uint64
+	)
+
+	current := keys.Current()
+
+	for {
+		var rows []*row
+
+		err := db.WithContext(ctx).Table("user").
+			Select("id, name, email, COALESCE(phone, '') AS phone").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return result, err
+		}
+
+		if len(rows) == 0 {
+			return result, nil
+		}
+
+		err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, r := range rows {
+				if upToDate(r.Email, current) && upToDate(r.Phone, current) {
+					continue
+				}
+
+				updated, err := reencrypt(ctx, tx, keys, r)
+				if err != nil {
+					return err
+				}
+
+				if updated {
+					result.Updated++
+				} else {
+					result.Skipped++
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+
+		lastID = rows[len(rows)-1].ID
+
+		if progress != nil {
+			progress(result)
+		}
+	}
+}
+
+// upToDate 判断值是否已经使用 version 版本的数据密钥加密，空值不需要加密。
+func upToDate(value string, version uint32) bool {
+	if value == "" {
+		return true
+	}
+
+	v, ok := encryption.KeyVersion(value)
+
+	return ok && v == version
+}
+
+// reencrypt 重新加密一个用户，用户在读取后被修改时返回 false。
+func reencrypt(ctx context.Context, tx *gorm.DB, keys *encryption.Keyring, r *row) (bool, error) {
+	email, err := decrypt(ctx, keys, fieldEmail, r.Name, r.Email)
+	if err != nil {
+		return false, fmt.Errorf("user %s: %w", r.Name, err)
+	}
+
+	phone, err := decrypt(ctx, keys, fieldPhone, r.Name, r.Phone)
+	if err != nil {
+		return false, fmt.Errorf("user %s: %w", r.Name, err)
+	}
+
+	sealedEmail, err := keys.Encrypt(fieldEmail, r.Name, email)
+	if err != nil {
+		return false, err
+	}
+
+	sealedPhone, err := keys.Encrypt(fieldPhone, r.Name, phone)
+	if err != nil {
+		return false, err
+	}
+
+	result := tx.Table("user").
+		Where("id = ? AND email = ? AND COALESCE(phone, '') = ?", r.ID, r.Email, r.Phone).
+		UpdateColumns(map[string]interface{}{
+			"email":      sealedEmail,
+			"phone":      sealedPhone,
+			"emailIndex": keys.BlindIndex(fieldEmail, email),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
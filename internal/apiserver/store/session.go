@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// 存储使用只读从库时，从库的复制延迟可能使请求读不到自己刚写入的数据。
+// 一次请求的所有存储操作使用 WithSession 返回的 context，写操作调用 MarkWritten，
+// 之后同一个请求中的读操作使用 Written 判断是否需要读取主库。
+
+type sessionKey struct{}
+
+type session struct {
+	written int32
+}
+
+// WithSession 返回记录读写状态的 context，ctx 已经有 session 时原样返回。
+func WithSession(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// MarkWritten 记录 ctx 所属的请求已经写入，ctx 没有 session 时不做任何事情。
+func MarkWritten(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		atomic.StoreInt32(&s.written, 1)
+	}
+}
+
+// Written 返回 ctx 所属的请求是否已经写入。
+func Written(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+
+	return ok && atomic.LoadInt32(&s.written) == 1
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	MarkWritten(ctx)
+	assert.False(t, Written(ctx))
+
+	ctx = WithSession(ctx)
+	assert.False(t, Written(ctx))
+
+	// 派生的 context 共享同一个 session
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	MarkWritten(child)
+	assert.True(t, Written(ctx))
+	assert.True(t, Written(WithSession(ctx)))
+}
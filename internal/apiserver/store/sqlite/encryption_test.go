@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/pii"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	"github.com/tiandh987/SharkAgent/pkg/db"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"gorm.io/gorm"
+)
+
+var masterKey = bytes.Repeat([]byte{1}, encryption.MasterKeySize)
+
+// newEncryptedFactory 返回加密用户的 Factory 和其使用的数据库，用于检查保存的值。
+func newEncryptedFactory(t *testing.T) (store.Factory, *gorm.DB) {
+	t.Helper()
+
+	dbIns, err := db.NewSQLite(&db.SQLiteOptions{Path: ":memory:", JournalMode: "MEMORY", BusyTimeout: time.Second})
+	require.NoError(t, err)
+
+	s, err := NewFactory(dbIns, masterKey)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s, dbIns
+}
+
+type storedUser struct {
+	Email      string `gorm:"column:email"`
+	Phone      string `gorm:"column:phone"`
+	EmailIndex string `gorm:"column:emailIndex"`
+}
+
+func stored(t *testing.T, dbIns *gorm.DB, name string) *storedUser {
+	t.Helper()
+
+	row := &storedUser{}
+	require.NoError(t, dbIns.Table("user").Where("name = ?", name).
+		Select("email, COALESCE(phone, '') AS phone, COALESCE(emailIndex, '') AS emailIndex").Take(row).Error)
+
+	return row
+}
+
+func TestEncryption(t *testing.T) {
+	ctx := context.Background()
+	s, dbIns := newEncryptedFactory(t)
+
+	user := newUser("colin")
+	user.Phone = "18128840000"
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	assert.Equal(t, "colin@example.com", user.Email)
+
+	row := stored(t, dbIns, "colin")
+	assert.True(t, encryption.IsEncrypted(row.Email))
+	assert.True(t, encryption.IsEncrypted(row.Phone))
+	assert.Len(t, row.EmailIndex, 64)
+
+	got, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "colin@example.com", got.Email)
+	assert.Equal(t, "18128840000", got.Phone)
+
+	got.Email = "lee@example.com"
+	require.NoError(t, s.Users().Update(ctx, got, metav1.UpdateOptions{}))
+	assert.Equal(t, "lee@example.com", got.Email)
+	assert.NotEqual(t, row.EmailIndex, stored(t, dbIns, "colin").EmailIndex)
+
+	// 使用盲索引按 email 查询，不区分大小写
+	require.NoError(t, s.Users().Create(ctx, newUser("tom"), metav1.CreateOptions{}))
+
+	list, err := s.Users().List(ctx, metav1.ListOptions{FieldSelector: "email=LEE@example.com"})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "colin", list.Items[0].Name)
+	assert.Equal(t, "lee@example.com", list.Items[0].Email)
+
+	// 事件中保存加密的值，读取时解密
+	var object string
+	require.NoError(t, dbIns.Model(&store.Event{}).Select("object").Order("id").Limit(1).Scan(&object).Error)
+	assert.NotContains(t, object, "colin@example.com")
+
+	events, err := s.Events().List(ctx, store.UserResource, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	eventUser := &v1.User{}
+	require.NoError(t, json.Unmarshal([]byte(events[0].Object), eventUser))
+	assert.Equal(t, "colin@example.com", eventUser.Email)
+
+	// 密文与用户名绑定，复制到其他用户的行后无法解密
+	require.NoError(t, dbIns.Table("user").Where("name = ?", "tom").
+		Update("phone", stored(t, dbIns, "colin").Phone).Error)
+
+	_, err = s.Users().Get(ctx, "tom", metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// 没有启用加密时不会返回密文
+	plain, err := NewFactory(dbIns, nil)
+	require.NoError(t, err)
+
+	_, err = plain.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx := context.Background()
+
+	dbIns, err := db.NewSQLite(&db.SQLiteOptions{Path: ":memory:", JournalMode: "MEMORY", BusyTimeout: time.Second})
+	require.NoError(t, err)
+
+	// 启用加密之前写入的明文
+	plain, err := NewFactory(dbIns, nil)
+	require.NoError(t, err)
+
+	legacy := newUser("legacy")
+	legacy.Phone = "18128840000"
+	require.NoError(t, plain.Users().Create(ctx, legacy, metav1.CreateOptions{}))
+
+	s, err := NewFactory(dbIns, masterKey)
+	require.NoError(t, err)
+	require.NoError(t, s.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{}))
+	require.NoError(t, s.Users().Create(ctx, newUser("tom"), metav1.CreateOptions{}))
+
+	// 明文可以读取，但还没有盲索引
+	got, err := s.Users().Get(ctx, "legacy", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "legacy@example.com", got.Email)
+
+	list, err := s.Users().List(ctx, metav1.ListOptions{FieldSelector: "email=legacy@example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+
+	keys, err := encryption.LoadKeyring(ctx, dbIns, masterKey)
+	require.NoError(t, err)
+
+	version, err := keys.Rotate(ctx)
+	require.NoError(t, err)
+
+	var batches int
+	result, err := pii.Reencrypt(ctx, dbIns, keys, 2, func(pii.ReencryptResult) { batches++ })
+	require.NoError(t, err)
+	assert.Equal(t, pii.ReencryptResult{Updated: 3}, result)
+	assert.Equal(t, 2, batches)
+
+	for _, name := range []string{"legacy", "colin", "tom"} {
+		v, ok := encryption.KeyVersion(stored(t, dbIns, name).Email)
+		assert.True(t, ok)
+		assert.Equal(t, version, v)
+	}
+
+	assert.True(t, strings.HasPrefix(stored(t, dbIns, "legacy").Phone, "enc2:"))
+
+	// 已经使用最新的数据密钥加密的用户被跳过
+	result, err = pii.Reencrypt(ctx, dbIns, keys, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, pii.ReencryptResult{}, result)
+
+	// 使用旧数据密钥的实例读取新版本加密的用户
+	got, err = s.Users().Get(ctx, "legacy", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "legacy@example.com", got.Email)
+	assert.Equal(t, "18128840000", got.Phone)
+
+	list, err = s.Users().List(ctx, metav1.ListOptions{FieldSelector: "email=legacy@example.com"})
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 1)
+}
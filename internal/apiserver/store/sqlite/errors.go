@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marmotedu/errors"
+	"github.com/mattn/go-sqlite3"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"gorm.io/gorm"
+)
+
+// translateError 将 gorm 和 sqlite 驱动返回的错误转换为 store 中定义的错误，无法识别的错误原样返回。
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", store.ErrNotFound, err)
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return store.NewCanceledError(err)
+	}
+
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch {
+	case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique, sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey:
+		return store.NewAlreadyExistsError(constraintField(sqliteErr.Error()), err)
+	case sqliteErr.Code == sqlite3.ErrBusy, sqliteErr.Code == sqlite3.ErrLocked:
+		// 等待 --sqlite.busy-timeout 后仍然无法获取锁
+		return store.NewUnavailableError(err)
+	case sqliteErr.Code == sqlite3.ErrInterrupt:
+		// context 被取消时驱动调用 sqlite3_interrupt 中止查询
+		return store.NewCanceledError(err)
+	}
+
+	return err
+}
+
+// constraintField 从 UNIQUE constraint failed: user.name 形式的错误信息中取出冲突的字段名，
+// 联合唯一索引有多个字段时返回空字符串。
+func constraintField(message string) string {
+	i := strings.LastIndex(message, ": ")
+	if i < 0 {
+		return ""
+	}
+
+	column := message[i+2:]
+	if strings.Contains(column, ",") {
+		return ""
+	}
+
+	if j := strings.LastIndex(column, "."); j >= 0 {
+		column = column[j+1:]
+	}
+
+	return column
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store/pii"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+	"gorm.io/gorm"
+)
+
+type events struct {
+	db   *gorm.DB
+	keys *encryption.Keyring
+}
+
+func newEvents(ds *datastore) *events {
+	return &events{db: ds.db, keys: ds.keys}
+}
+
+// List return the events of resource after since.
+// 用户事件中加密的 email 和 phone 被解密。
+func (e *events) List(ctx context.Context, resource string, since uint64, limit int) ([]*store.Event, error) {
+	var ret []*store.Event
+
+	err := e.db.WithContext(ctx).
+		Where("resource = ? AND id > ?", resource, since).
+		Order("id").
+		Limit(limit).
+		Find(&ret).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := pii.OpenEvents(ctx, e.keys, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Latest return the id of the latest event of resource.
+func (e *events) Latest(ctx context.Context, resource string) (uint64, error) {
+	var latest *uint64
+
+	err := e.db.WithContext(ctx).Model(&store.Event{}).
+		Where("resource = ?", resource).
+		Select("MAX(id)").
+		Scan(&latest).Error
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	if latest == nil {
+		return 0, nil
+	}
+
+	return *latest, nil
+}
+
+// createEvent 在事务 tx 中写入一条资源变更事件。
+// sqlite 同一时间只有一个写事务，自增 id 的顺序与提交顺序一致。
+func createEvent(tx *gorm.DB, resource string, eventType metav1.EventType, name string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", resource, err)
+	}
+
+	event := &store.Event{
+		Resource: resource,
+		Type:     string(eventType),
+		Name:     name,
+		Object:   string(data),
+	}
+
+	return tx.Create(event).Error
+}
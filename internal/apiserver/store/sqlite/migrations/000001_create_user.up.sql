@@ -0,0 +1,19 @@
+-- 与 MySQL 的表结构对应，索引名称保持一致。
+-- name 与 MySQL 默认的排序规则一样不区分大小写。
+CREATE TABLE IF NOT EXISTS `user` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `instanceID` varchar(32) DEFAULT NULL,
+    `name` varchar(45) NOT NULL COLLATE NOCASE,
+    `status` int(1) DEFAULT 1,
+    `nickname` varchar(30) NOT NULL,
+    `password` varchar(255) NOT NULL,
+    `email` varchar(256) NOT NULL,
+    `phone` varchar(20) DEFAULT NULL,
+    `isAdmin` tinyint(1) NOT NULL DEFAULT 0,
+    `extendShadow` text DEFAULT NULL,
+    `loginedAt` datetime DEFAULT NULL,
+    `createdAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updatedAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_name` ON `user` (`name`);
+CREATE UNIQUE INDEX IF NOT EXISTS `instanceID_UNIQUE` ON `user` (`instanceID`);
@@ -0,0 +1,11 @@
+-- 版本号与 MySQL 的迁移保持一致
+CREATE TABLE IF NOT EXISTS `idempotency_record` (
+    `idempotencyKey` char(64) NOT NULL PRIMARY KEY,
+    `fingerprint` char(64) NOT NULL,
+    `completed` tinyint(1) NOT NULL DEFAULT 0,
+    `statusCode` int(3) NOT NULL DEFAULT 0,
+    `contentType` varchar(255) NOT NULL DEFAULT '',
+    `body` blob DEFAULT NULL,
+    `expiresAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_expiresAt` ON `idempotency_record` (`expiresAt`);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `resource_event` (
+    `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `resource` varchar(64) NOT NULL,
+    `type` varchar(16) NOT NULL,
+    `name` varchar(64) NOT NULL,
+    `object` text NOT NULL,
+    `createdAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_resource_id` ON `resource_event` (`resource`, `id`);
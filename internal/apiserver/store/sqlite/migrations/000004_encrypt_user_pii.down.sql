@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_emailIndex`;
+ALTER TABLE `user` DROP COLUMN `emailIndex`;
+DROP TABLE IF EXISTS `data_key`;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `data_key` (
+    `version` INTEGER PRIMARY KEY,
+    `key` varchar(255) NOT NULL,
+    `createdAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- sqlite 不限制 varchar 的长度，phone 不需要修改
+ALTER TABLE `user` ADD COLUMN `emailIndex` char(64) DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS `idx_emailIndex` ON `user` (`emailIndex`);
@@ -0,0 +1,2 @@
+-- version 在每次写入时加 1，用于 ETag 和 If-Match
+ALTER TABLE `user` ADD COLUMN `version` INTEGER NOT NULL DEFAULT 1;
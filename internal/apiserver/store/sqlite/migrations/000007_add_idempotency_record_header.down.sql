@@ -0,0 +1 @@
+ALTER TABLE `idempotency_record` DROP COLUMN `header`;
@@ -0,0 +1,2 @@
+-- 版本号与 MySQL 的迁移保持一致，SQLite 不需要 000006 的 resource_event_sequence
+ALTER TABLE `idempotency_record` ADD COLUMN `header` text DEFAULT NULL;
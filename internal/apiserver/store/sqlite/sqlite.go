@@ -0,0 +1,142 @@
+// Package sqlite 实现了基于 sqlite 的 store.Factory。
+// 它与 MySQL 存储使用相同的表结构和查询，用于无法部署 MySQL 的小型环境，
+// 以及需要真实 SQL 语义但没有数据库容器的 CI。
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/internal/pkg/encryption"
+	genericoptions "github.com/tiandh987/SharkAgent/internal/pkg/options"
+	"gorm.io/gorm"
+)
+
+var (
+	sqliteFactory store.Factory
+	once          sync.Once
+)
+
+type datastore struct {
+	db *gorm.DB
+	// inTx 表示 db 是 Tx 开启的事务
+	inTx bool
+	// keys 加密用户的 email 和 phone，没有启用加密时为 nil
+	keys *encryption.Keyring
+}
+
+func (ds *datastore) Users() store.UserStore {
+	return newUsers(ds)
+}
+
+func (ds *datastore) Events() store.EventStore {
+	return newEvents(ds)
+}
+
+// Tx 在一个数据库事务中执行 fn，回滚和提交由 gorm 的 Transaction 完成，panic 时同样回滚。
+// 事务中各个存储的方法仍然使用 Transaction，gorm 将其转换为 SAVEPOINT。
+func (ds *datastore) Tx(ctx context.Context, fn func(tx store.Factory) error) error {
+	if ds.inTx {
+		return store.ErrNestedTransaction
+	}
+
+	var fnErr error
+
+	err := ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(&datastore{db: tx, inTx: true, keys: ds.keys})
+
+		return fnErr
+	})
+	if err != nil && err == fnErr {
+		// fn 返回的错误已经由各个存储转换
+		return err
+	}
+
+	return translateError(err)
+}
+
+// Ping 检查数据库是否可用。
+func (ds *datastore) Ping(ctx context.Context) error {
+	return translateError(ds.db.WithContext(ctx).Exec("SELECT 1").Error)
+}
+
+func (ds *datastore) Close() error {
+	if ds.inTx {
+		return fmt.Errorf("can not close the store inside a transaction")
+	}
+
+	db, err := ds.db.DB()
+	if err != nil {
+		return errors.Wrap(err, "get gorm db instance failed")
+	}
+
+	return db.Close()
+}
+
+// NewFactory 使用已经打开的 sqlite 数据库创建 store.Factory。
+// sqlite 数据库只由本机的 apiserver 使用，因此在这里执行所有未执行的表结构迁移。
+// masterKey 不为 nil 时加密用户的 email 和 phone，见 pii。
+func NewFactory(db *gorm.DB, masterKey []byte) (store.Factory, error) {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Up(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "migrate sqlite schema failed")
+	}
+
+	ds := &datastore{db: db}
+	if masterKey != nil {
+		if ds.keys, err = encryption.LoadKeyring(context.Background(), db, masterKey); err != nil {
+			return nil, errors.Wrap(err, "load data keys failed")
+		}
+	}
+
+	return ds, nil
+}
+
+// GetSQLiteFactoryOr 使用给定的配置创建一个 sqlite 工厂
+func GetSQLiteFactoryOr(opts *genericoptions.SQLiteOptions, masterKey []byte) (store.Factory, error) {
+	if opts == nil && sqliteFactory == nil {
+		return nil, fmt.Errorf("failed to get sqlite store fatory")
+	}
+
+	var err error
+
+	once.Do(func() {
+		var dbIns *gorm.DB
+		if dbIns, err = opts.NewClient(); err != nil {
+			return
+		}
+
+		sqliteFactory, err = NewFactory(dbIns, masterKey)
+	})
+
+	if sqliteFactory == nil || err != nil {
+		return nil, fmt.Errorf("failed to get sqlite store fatory, sqliteFactory: %+v, error: %w", sqliteFactory, err)
+	}
+
+	return sqliteFactory, nil
+}
+
+// defaultLimit 是 List 没有指定 limit 时返回的最大记录数，与 MySQL 存储相同。
+const defaultLimit = 1000
+
+// unpointer 将 List 的 offset 和 limit 转换为 gorm 使用的值，没有指定时不跳过记录并返回最多 defaultLimit 条记录。
+func unpointer(offset *int64, limit *int64) (int, int) {
+	o, l := 0, defaultLimit
+
+	if offset != nil {
+		o = int(*offset)
+	}
+
+	if limit != nil {
+		l = int(*limit)
+	}
+
+	return o, l
+}
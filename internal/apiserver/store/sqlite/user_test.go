@@ -0,0 +1,251 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tiandh987/SharkAgent/api/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/apiserver/store"
+	"github.com/tiandh987/SharkAgent/pkg/db"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+func newFactory(t *testing.T) store.Factory {
+	t.Helper()
+
+	dbIns, err := db.NewSQLite(&db.SQLiteOptions{Path: ":memory:", JournalMode: "MEMORY", BusyTimeout: time.Second})
+	require.NoError(t, err)
+
+	s, err := NewFactory(dbIns, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	// 再次创建表结构不会出错
+	_, err = NewFactory(dbIns, nil)
+	require.NoError(t, err)
+
+	return s
+}
+
+func newUser(name string) *v1.User {
+	return &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: name, InstanceID: "user-" + name},
+		Status:     1,
+		Nickname:   name,
+		Password:   "hash",
+		Email:      name + "@example.com",
+	}
+}
+
+func TestUsers_CreateGet(t *testing.T) {
+	ctx := context.Background()
+	s := newFactory(t)
+
+	user := newUser("colin")
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	assert.Equal(t, uint64(1), user.ID)
+
+	got, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	assert.Equal(t, "colin@example.com", got.Email)
+
+	// 与 MySQL 一样，用户名唯一且不区分大小写
+	dup := newUser("COLIN")
+	err = s.Users().Create(ctx, dup, metav1.CreateOptions{})
+	assertAlreadyExists(t, err, "name")
+
+	a, b := newUser("a"), newUser("b")
+	b.InstanceID = a.InstanceID
+	require.NoError(t, s.Users().Create(ctx, a, metav1.CreateOptions{}))
+	err = s.Users().Create(ctx, b, metav1.CreateOptions{})
+	assertAlreadyExists(t, err, "instanceID")
+
+	// 没有指定 instanceID 时根据 ID 生成
+	c, d := newUser("c"), newUser("d")
+	c.InstanceID, d.InstanceID = "", ""
+	require.NoError(t, s.Users().Create(ctx, c, metav1.CreateOptions{}))
+	require.NoError(t, s.Users().Create(ctx, d, metav1.CreateOptions{}))
+	assert.Equal(t, fmt.Sprintf("user-%d", d.ID), d.InstanceID)
+
+	got, err = s.Users().Get(ctx, "c", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("user-%d", c.ID), got.InstanceID)
+
+	_, err = s.Users().Get(ctx, "unknown", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+}
+
+func TestUsers_UpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newFactory(t)
+
+	user := newUser("colin")
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+
+	stale := user.Version - 1
+	user.Nickname = "lee"
+	err := s.Users().Update(ctx, user, metav1.UpdateOptions{Preconditions: &metav1.Preconditions{Version: &stale}})
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	got, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), got.Version)
+
+	current := got.Version
+	require.NoError(t, s.Users().Update(ctx, user,
+		metav1.UpdateOptions{Preconditions: &metav1.Preconditions{Version: &current}}))
+	assert.Equal(t, current+1, user.Version)
+
+	// 同一秒内的多次写入也会产生不同的 version
+	require.NoError(t, s.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	assert.Equal(t, current+2, user.Version)
+
+	got, err = s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "lee", got.Nickname)
+	assert.Equal(t, current+2, got.Version)
+
+	err = s.Users().Delete(ctx, "colin", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{Version: &current}})
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	require.NoError(t, s.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+	require.NoError(t, s.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+	err = s.Users().Delete(ctx, "colin", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{}})
+	assert.True(t, errors.Is(err, store.ErrConflict))
+
+	// 不存在的用户不会被更新创建
+	require.NoError(t, s.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	_, err = s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+}
+
+func TestUsers_List(t *testing.T) {
+	ctx := context.Background()
+	s := newFactory(t)
+
+	for _, name := range []string{"colin", "Colin2", "alice", "disabled-colin"} {
+		user := newUser(name)
+		if name == "disabled-colin" {
+			user.Status = 0
+		}
+		require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	}
+
+	list, err := s.Users().List(ctx, metav1.ListOptions{FieldSelector: "name=colin"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.TotalCount)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "Colin2", list.Items[0].Name)
+
+	offset, limit := int64(1), int64(1)
+	list, err = s.Users().List(ctx, metav1.ListOptions{Offset: &offset, Limit: &limit})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.TotalCount)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Colin2", list.Items[0].Name)
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	s := newFactory(t)
+
+	latest, err := s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Zero(t, latest)
+
+	user := newUser("colin")
+	require.NoError(t, s.Users().Create(ctx, user, metav1.CreateOptions{}))
+	require.NoError(t, s.Users().Update(ctx, user, metav1.UpdateOptions{}))
+	require.NoError(t, s.Users().Delete(ctx, "colin", metav1.DeleteOptions{}))
+
+	events, err := s.Events().List(ctx, store.UserResource, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, string(metav1.Modified), events[0].Type)
+	assert.Equal(t, string(metav1.Deleted), events[1].Type)
+	assert.NotContains(t, events[1].Object, "hash")
+
+	latest, err = s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), latest)
+}
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+	s := newFactory(t)
+
+	// 提交：事务中创建的用户和事件在提交后可见
+	require.NoError(t, s.Tx(ctx, func(tx store.Factory) error {
+		if err := tx.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+
+		_, err := tx.Users().Get(ctx, "colin", metav1.GetOptions{})
+
+		return err
+	}))
+
+	_, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+	latest, err := s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+
+	// 回滚：fn 返回错误时之前的修改全部撤销，错误原样返回
+	err = s.Tx(ctx, func(tx store.Factory) error {
+		if err := tx.Users().Create(ctx, newUser("alice"), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+
+		return tx.Users().Create(ctx, newUser("colin"), metav1.CreateOptions{})
+	})
+	assert.True(t, errors.Is(err, store.ErrAlreadyExists))
+
+	_, err = s.Users().Get(ctx, "alice", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrNotFound))
+
+	// panic 时回滚并继续 panic
+	assert.Panics(t, func() {
+		_ = s.Tx(ctx, func(tx store.Factory) error {
+			_ = tx.Users().Delete(ctx, "colin", metav1.DeleteOptions{})
+			panic("boom")
+		})
+	})
+
+	_, err = s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	got, err := s.Events().Latest(ctx, store.UserResource)
+	require.NoError(t, err)
+	assert.Equal(t, latest, got)
+
+	// 嵌套事务
+	err = s.Tx(ctx, func(tx store.Factory) error {
+		return tx.Tx(ctx, func(store.Factory) error { return nil })
+	})
+	assert.True(t, errors.Is(err, store.ErrNestedTransaction))
+}
+
+func TestUsers_Canceled(t *testing.T) {
+	s := newFactory(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Users().Get(ctx, "colin", metav1.GetOptions{})
+	assert.True(t, errors.Is(err, store.ErrCanceled), "unexpected error: %v", err)
+}
+
+func assertAlreadyExists(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var exists *store.AlreadyExistsError
+	require.True(t, errors.As(err, &exists), "unexpected error: %v", err)
+	assert.True(t, errors.Is(err, store.ErrAlreadyExists))
+	assert.Equal(t, field, exists.Field)
+}
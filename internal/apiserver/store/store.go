@@ -1,7 +1,29 @@
 package store
 
+import "context"
+
+var client Factory
+
 // Factory defines the iam platform storage interface.
 type Factory interface {
 	Users() UserStore
+	Events() EventStore
+	// Tx 在一个数据库事务中执行 fn，fn 中通过 tx 获取的存储共享该事务。
+	// fn 返回错误或 panic 时回滚事务，panic 在回滚后继续向上传递；fn 返回 nil 时提交事务。
+	// fn 中不能再使用外层的 Factory，否则语句不在事务中，并且可能等待事务持有的锁或连接；
+	// 在 tx 上再次调用 Tx 返回 ErrNestedTransaction。
+	Tx(ctx context.Context, fn func(tx Factory) error) error
+	// Ping 检查存储是否可用，用于就绪检查，不可用时返回 ErrUnavailable 等存储错误。
+	Ping(ctx context.Context) error
 	Close() error
-}
\ No newline at end of file
+}
+
+// Client return the store client instance.
+func Client() Factory {
+	return client
+}
+
+// SetClient set the iam store client.
+func SetClient(factory Factory) {
+	client = factory
+}
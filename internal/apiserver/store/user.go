@@ -7,6 +7,12 @@ import (
 )
 
 // UserStore defines the user storage interface.
+// 返回的错误见 errors.go：Get 在用户不存在时返回 ErrNotFound，Create 在用户名或 instanceID 重复时返回
+// AlreadyExistsError，Update 和 Delete 在 Preconditions 不满足时返回 ErrConflict。
 type UserStore interface {
 	Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error
+	Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error)
+	Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error
+	Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
 }
@@ -23,12 +23,36 @@ const (
 
 	// ErrPageNotFound - 404: Page not found.
 	ErrPageNotFound
+
+	// ErrTooManyRequests - 429: Too many requests, please retry later.
+	ErrTooManyRequests
+
+	// ErrMethodNotAllowed - 405: Method not allowed.
+	ErrMethodNotAllowed
+
+	// ErrIdempotencyKeyInvalid - 400: The `Idempotency-Key` header is invalid.
+	ErrIdempotencyKeyInvalid
+
+	// ErrIdempotencyKeyMismatch - 409: The `Idempotency-Key` was used by a different request.
+	ErrIdempotencyKeyMismatch
+
+	// ErrIdempotencyKeyInUse - 409: A request with the same `Idempotency-Key` is being processed.
+	ErrIdempotencyKeyInUse
+
+	// ErrPreconditionFailed - 412: The resource was modified, precondition failed.
+	ErrPreconditionFailed
 )
 
 // common: database errors.
 const (
 	// ErrDatabase - 500: Database error.
 	ErrDatabase int = iota + 100101
+
+	// ErrDatabaseUnavailable - 503: Database is unavailable, please retry later.
+	ErrDatabaseUnavailable
+
+	// ErrDatabaseTimeout - 504: Database operation was canceled or timed out.
+	ErrDatabaseTimeout
 )
 
 // common: authorization and authentication errors.
@@ -53,6 +77,12 @@ const (
 
 	// PermissionDenied - 403: Permission denied.
 	ErrPermissionDenied
+
+	// ErrMissingClientCert - 401: A verified client certificate is required.
+	ErrMissingClientCert
+
+	// ErrUnknownClientCert - 401: Client certificate does not map to any user.
+	ErrUnknownClientCert
 )
 
 // common: encode/decode errors.
@@ -72,12 +102,21 @@ const (
 	// ErrDecodingJSON - 500: JSON data could not be decoded.
 	ErrDecodingJSON
 
-	// ErrInvalidYaml - 500: Data is not valid Yaml.
+	// ErrInvalidYaml - 400: Data is not valid Yaml.
 	ErrInvalidYaml
 
 	// ErrEncodingYaml - 500: Yaml data could not be encoded.
 	ErrEncodingYaml
 
-	// ErrDecodingYaml - 500: Yaml data could not be decoded.
+	// ErrDecodingYaml - 400: Yaml data could not be decoded.
 	ErrDecodingYaml
+
+	// ErrEncodingProtobuf - 500: Protobuf data could not be encoded.
+	ErrEncodingProtobuf
+
+	// ErrUnsupportedMediaType - 415: The request Content-Type is not supported.
+	ErrUnsupportedMediaType
+
+	// ErrNotAcceptable - 406: None of the media types in the Accept header is supported.
+	ErrNotAcceptable
 )
\ No newline at end of file
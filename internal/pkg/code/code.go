@@ -0,0 +1,104 @@
+package code
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/marmotedu/errors"
+)
+
+// ErrCode 实现了 `github.com/marmotedu/errors`.Coder 接口。
+type ErrCode struct {
+	// C refers to the code of the ErrCode.
+	C int
+
+	// HTTP status that should be used for the associated error code.
+	HTTP int
+
+	// External (user) facing error text.
+	Ext string
+
+	// Ref specify the reference document.
+	Ref string
+}
+
+var _ errors.Coder = &ErrCode{}
+
+// Code returns the integer code of ErrCode.
+func (coder ErrCode) Code() int {
+	return coder.C
+}
+
+// String implements stringer. String returns the external error message,
+// if any.
+func (coder ErrCode) String() string {
+	return coder.Ext
+}
+
+// Reference returns the reference document.
+func (coder ErrCode) Reference() string {
+	return coder.Ref
+}
+
+// HTTPStatus returns the associated HTTP status code, if any. Otherwise,
+// returns 200.
+func (coder ErrCode) HTTPStatus() int {
+	if coder.HTTP == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return coder.HTTP
+}
+
+// allowedHTTPStatus 列出了错误码允许映射的 HTTP 状态码。
+var allowedHTTPStatus = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusBadRequest:           {},
+	http.StatusUnauthorized:         {},
+	http.StatusForbidden:            {},
+	http.StatusNotFound:             {},
+	http.StatusMethodNotAllowed:     {},
+	http.StatusNotAcceptable:        {},
+	http.StatusConflict:             {},
+	http.StatusPreconditionFailed:   {},
+	http.StatusUnsupportedMediaType: {},
+	http.StatusTooManyRequests:      {},
+	http.StatusInternalServerError:  {},
+	http.StatusServiceUnavailable:   {},
+	http.StatusGatewayTimeout:       {},
+}
+
+// registered 保存所有已注册的错误码，用于生成文档。
+var registered []ErrCode
+
+// List 返回所有已注册的错误码，按错误码升序排列。
+func List() []ErrCode {
+	codes := make([]ErrCode, len(registered))
+	copy(codes, registered)
+
+	sort.Slice(codes, func(i, j int) bool { return codes[i].C < codes[j].C })
+
+	return codes
+}
+
+// register 将错误码注册到 `github.com/marmotedu/errors`，由 codegen 生成的代码调用。
+func register(code int, httpStatus int, message string, refs ...string) {
+	if _, ok := allowedHTTPStatus[httpStatus]; !ok {
+		panic("http code not in `200 400 401 403 404 405 406 409 412 415 429 500 503 504`")
+	}
+
+	var reference string
+	if len(refs) > 0 {
+		reference = refs[0]
+	}
+
+	coder := &ErrCode{
+		C:    code,
+		HTTP: httpStatus,
+		Ext:  message,
+		Ref:  reference,
+	}
+
+	errors.MustRegister(coder)
+	registered = append(registered, *coder)
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 SharkAgent <xxx@163.com>. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Code generated by "codegen -type=int"; DO NOT EDIT.
+
+package code
+
+// init register error codes defines in this source code to `github.com/marmotedu/errors`
+func init() {
+	register(ErrUserNotFound, 404, "User not found")
+	register(ErrUserAlreadyExist, 400, "User already exist")
+	register(ErrReachMaxCount, 400, "Secret reach the max count")
+	register(ErrSecretNotFound, 404, "Secret not found")
+	register(ErrPolicyNotFound, 404, "Policy not found")
+	register(ErrSuccess, 200, "OK")
+	register(ErrUnknown, 500, "Internal server error")
+	register(ErrBind, 400, "Error occurred while binding the request body to the struct")
+	register(ErrValidation, 400, "Validation failed")
+	register(ErrTokenInvalid, 401, "Token invalid")
+	register(ErrPageNotFound, 404, "Page not found")
+	register(ErrTooManyRequests, 429, "Too many requests, please retry later")
+	register(ErrMethodNotAllowed, 405, "Method not allowed")
+	register(ErrIdempotencyKeyInvalid, 400, "The `Idempotency-Key` header is invalid")
+	register(ErrIdempotencyKeyMismatch, 409, "The `Idempotency-Key` was used by a different request")
+	register(ErrIdempotencyKeyInUse, 409, "A request with the same `Idempotency-Key` is being processed")
+	register(ErrPreconditionFailed, 412, "The resource was modified, precondition failed")
+	register(ErrDatabase, 500, "Database error")
+	register(ErrDatabaseUnavailable, 503, "Database is unavailable, please retry later")
+	register(ErrDatabaseTimeout, 504, "Database operation was canceled or timed out")
+	register(ErrEncrypt, 401, "Error occurred while encrypting the user password")
+	register(ErrSignatureInvalid, 401, "Signature is invalid")
+	register(ErrExpired, 401, "Token expired")
+	register(ErrInvalidAuthHeader, 401, "Invalid authorization header")
+	register(ErrMissingHeader, 401, "The `Authorization` header was empty")
+	register(ErrPasswordIncorrect, 401, "Password was incorrect")
+	register(ErrPermissionDenied, 403, "Permission denied")
+	register(ErrMissingClientCert, 401, "A verified client certificate is required")
+	register(ErrUnknownClientCert, 401, "Client certificate does not map to any user")
+	register(ErrEncodingFailed, 500, "Encoding failed due to an error with the data")
+	register(ErrDecodingFailed, 500, "Decoding failed due to an error with the data")
+	register(ErrInvalidJSON, 500, "Data is not valid JSON")
+	register(ErrEncodingJSON, 500, "JSON data could not be encoded")
+	register(ErrDecodingJSON, 500, "JSON data could not be decoded")
+	register(ErrInvalidYaml, 400, "Data is not valid Yaml")
+	register(ErrEncodingYaml, 500, "Yaml data could not be encoded")
+	register(ErrDecodingYaml, 400, "Yaml data could not be decoded")
+	register(ErrEncodingProtobuf, 500, "Protobuf data could not be encoded")
+	register(ErrUnsupportedMediaType, 415, "The request Content-Type is not supported")
+	register(ErrNotAcceptable, 406, "None of the media types in the Accept header is supported")
+}
@@ -0,0 +1,44 @@
+package encryption
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type gormKeySource struct {
+	db *gorm.DB
+}
+
+// NewGormKeySource 返回将数据密钥保存在 db 的 data_key 表中的 KeySource。
+// 配置了只读从库时需要使用主库，否则可能读不到刚刚创建的数据密钥。
+func NewGormKeySource(db *gorm.DB) KeySource {
+	return &gormKeySource{db: db}
+}
+
+func (s *gormKeySource) List(ctx context.Context) ([]*DataKey, error) {
+	var keys []*DataKey
+	if err := s.db.WithContext(ctx).Order("version").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *gormKeySource) Create(ctx context.Context, key *DataKey) error {
+	return s.db.WithContext(ctx).Create(key).Error
+}
+
+// LoadKeyring 使用主密钥和 db 中的数据密钥创建 Keyring，还没有数据密钥时创建版本 1。
+func LoadKeyring(ctx context.Context, db *gorm.DB, masterKey []byte) (*Keyring, error) {
+	keys, err := NewKeyring(masterKey, NewGormKeySource(db))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keys.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,350 @@
+// Package encryption 实现字段级的信封加密：字段的值使用 AES-256-GCM 数据密钥加密，
+// 数据密钥使用主密钥加密后保存在数据库中（见 DataKey），主密钥只保存在文件或环境变量中。
+//
+// 加密后的值格式为 enc2:<数据密钥版本>:<base64(nonce + 密文)>。值中带有数据密钥的版本，
+// 轮换数据密钥后，使用旧版本加密的值仍然可以解密，因此数据密钥不会被删除。
+// 字段名和记录的标识作为附加数据参与认证，加密值不能被复制到其他字段或其他记录中使用。
+//
+// 需要等值查询的字段（例如 email）额外保存盲索引：使用由主密钥派生的密钥计算 HMAC-SHA256，
+// 相同的值得到相同的索引，可以在数据库中按索引查询，但不能从索引还原出原始值。
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MasterKeySize 是主密钥的长度，主密钥和数据密钥都是 AES-256 密钥。
+const MasterKeySize = 32
+
+// prefix 是加密后的值的前缀，没有前缀的值是启用加密之前写入的明文。
+const prefix = "enc2:"
+
+var (
+	// ErrUnknownKey 表示加密值使用的数据密钥版本不存在。
+	ErrUnknownKey = errors.New("unknown data key version")
+	// ErrNotEncrypted 表示要解密的值不是 Encrypt 返回的加密值。
+	ErrNotEncrypted = errors.New("value is not encrypted")
+)
+
+// DataKey 是被主密钥加密的数据密钥。
+type DataKey struct {
+	Version uint32 `gorm:"primary_key;column:version"`
+	// Key 是主密钥加密后的数据密钥，base64 编码
+	Key       string    `gorm:"column:key"`
+	CreatedAt time.Time `gorm:"column:createdAt"`
+}
+
+// TableName maps to mysql table name.
+func (k *DataKey) TableName() string {
+	return "data_key"
+}
+
+// KeySource 保存所有版本的数据密钥。
+type KeySource interface {
+	// List 返回所有数据密钥。
+	List(ctx context.Context) ([]*DataKey, error)
+	// Create 保存新的数据密钥，版本已经存在时返回错误。
+	Create(ctx context.Context, key *DataKey) error
+}
+
+// Keyring 使用最新版本的数据密钥加密，使用值中记录的版本解密，并发安全。
+type Keyring struct {
+	master   cipher.AEAD
+	indexKey []byte
+	source   KeySource
+
+	mu      sync.RWMutex
+	keys    map[uint32]cipher.AEAD
+	current uint32
+}
+
+// NewKeyring 使用主密钥创建 Keyring，数据密钥从 source 中读取，使用前需要调用 Load。
+func NewKeyring(masterKey []byte, source KeySource) (*Keyring, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(masterKey))
+	}
+
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 盲索引使用由主密钥派生的独立密钥，不直接使用主密钥
+	mac := hmac.New(sha256.New, masterKey)
+	mac.Write([]byte("blind-index"))
+
+	return &Keyring{
+		master:   master,
+		indexKey: mac.Sum(nil),
+		source:   source,
+		keys:     map[uint32]cipher.AEAD{},
+	}, nil
+}
+
+// Load 从 source 加载所有数据密钥，还没有数据密钥时创建版本 1。
+// 主密钥无法解密数据密钥时返回错误，通常是配置了错误的主密钥。
+func (k *Keyring) Load(ctx context.Context) error {
+	keys, err := k.source.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list data keys: %w", err)
+	}
+
+	if len(keys) == 0 {
+		// 多个实例同时第一次启动时只有一个能创建成功，其他实例读取创建成功的数据密钥
+		createErr := k.create(ctx, 1)
+
+		if keys, err = k.source.List(ctx); err != nil {
+			return fmt.Errorf("list data keys: %w", err)
+		}
+
+		if len(keys) == 0 {
+			return fmt.Errorf("create data key: %w", createErr)
+		}
+	}
+
+	return k.add(keys)
+}
+
+// Rotate 创建新版本的数据密钥，之后的加密使用新版本，返回新的版本号。
+// 已经加密的值仍然使用旧版本加密，可以继续解密，需要使用新版本时由调用方解密后重新加密。
+func (k *Keyring) Rotate(ctx context.Context) (uint32, error) {
+	if err := k.Load(ctx); err != nil {
+		return 0, err
+	}
+
+	version := k.Current() + 1
+	if err := k.create(ctx, version); err != nil {
+		return 0, fmt.Errorf("create data key %d: %w", version, err)
+	}
+
+	if err := k.Load(ctx); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Current 返回加密使用的数据密钥版本。
+func (k *Keyring) Current() uint32 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.current
+}
+
+// Encrypt 使用当前版本的数据密钥加密记录 id 的 field 字段的值 plaintext，空字符串不加密。
+// field 和 id 作为附加数据参与认证，加密值不能被复制到其他字段或其他记录中使用。
+// id 必须是记录创建后不会改变的标识。
+func (k *Keyring) Encrypt(field, id, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	k.mu.RLock()
+	version, aead := k.current, k.keys[k.current]
+	k.mu.RUnlock()
+
+	if aead == nil {
+		return "", fmt.Errorf("%w: keyring is not loaded", ErrUnknownKey)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), associatedData(field, id))
+
+	return prefix + strconv.FormatUint(uint64(version), 10) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密记录 id 的 field 字段的值 value，value 必须是 Encrypt 返回的加密值，否则返回 ErrNotEncrypted。
+// 值使用的数据密钥版本不存在时重新加载数据密钥，其他实例轮换数据密钥后不需要重启。
+func (k *Keyring) Decrypt(ctx context.Context, field, id, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("decrypt %s: %w", field, ErrNotEncrypted)
+	}
+
+	version, data, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := k.key(ctx, version)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("decrypt %s: ciphertext is too short", field)
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], associatedData(field, id))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s: %w", field, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// BlindIndex 返回 field 字段的值 value 的盲索引。value 去掉首尾空白并转换为小写后计算，
+// 与 MySQL 默认的排序规则一样，等值查询不区分大小写。
+func (k *Keyring) BlindIndex(field, value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted 判断 value 是否是 Encrypt 返回的加密值。
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyVersion 返回加密值使用的数据密钥版本，value 没有加密时 ok 为 false。
+func KeyVersion(value string) (version uint32, ok bool) {
+	if !IsEncrypted(value) {
+		return 0, false
+	}
+
+	version, _, err := parse(value)
+
+	return version, err == nil
+}
+
+func (k *Keyring) key(ctx context.Context, version uint32) (cipher.AEAD, error) {
+	k.mu.RLock()
+	aead, ok := k.keys[version]
+	k.mu.RUnlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	if err := k.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	aead, ok = k.keys[version]
+	k.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+
+	return aead, nil
+}
+
+// create 生成版本为 version 的数据密钥，使用主密钥加密后保存到 source。
+func (k *Keyring) create(ctx context.Context, version uint32) error {
+	raw := make([]byte, MasterKeySize)
+	if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+		return err
+	}
+
+	nonce := make([]byte, k.master.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	wrapped := k.master.Seal(nonce, nonce, raw, dataKeyAD(version))
+
+	return k.source.Create(ctx, &DataKey{Version: version, Key: base64.StdEncoding.EncodeToString(wrapped)})
+}
+
+// add 解密并加入还没有加载的数据密钥，版本最大的数据密钥用于加密。
+func (k *Keyring) add(keys []*DataKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, dk := range keys {
+		if _, ok := k.keys[dk.Version]; !ok {
+			aead, err := k.unwrap(dk)
+			if err != nil {
+				return err
+			}
+
+			k.keys[dk.Version] = aead
+		}
+
+		if dk.Version > k.current {
+			k.current = dk.Version
+		}
+	}
+
+	return nil
+}
+
+func (k *Keyring) unwrap(dk *DataKey) (cipher.AEAD, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(dk.Key)
+	if err != nil || len(wrapped) < k.master.NonceSize() {
+		return nil, fmt.Errorf("data key %d is malformed", dk.Version)
+	}
+
+	nonceSize := k.master.NonceSize()
+
+	raw, err := k.master.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], dataKeyAD(dk.Version))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key %d failed, the master key may be wrong: %w", dk.Version, err)
+	}
+
+	return newAEAD(raw)
+}
+
+// associatedData 返回加密字段值使用的附加数据，将值与字段和记录绑定。
+func associatedData(field, id string) []byte {
+	return []byte(field + "\x00" + id)
+}
+
+// dataKeyAD 将数据密钥与版本绑定，加密后的数据密钥不能被换到其他版本使用。
+func dataKeyAD(version uint32) []byte {
+	return []byte("data_key:" + strconv.FormatUint(uint64(version), 10))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// parse 解析 enc2:<version>:<base64> 格式的加密值。
+func parse(value string) (uint32, []byte, error) {
+	value = strings.TrimPrefix(value, prefix)
+
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return 0, nil, errors.New("malformed encrypted value")
+	}
+
+	version, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, nil, fmt.Errorf("malformed data key version %q", parts[0])
+	}
+
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	return uint32(version), data, nil
+}
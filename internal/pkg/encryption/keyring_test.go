@@ -0,0 +1,168 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySource 是保存在内存中的 KeySource。
+type memorySource struct {
+	mu   sync.Mutex
+	keys []*DataKey
+}
+
+func (s *memorySource) List(ctx context.Context) ([]*DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*DataKey(nil), s.keys...), nil
+}
+
+func (s *memorySource) Create(ctx context.Context, key *DataKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.keys {
+		if k.Version == key.Version {
+			return fmt.Errorf("data key %d already exists", key.Version)
+		}
+	}
+
+	s.keys = append(s.keys, key)
+
+	return nil
+}
+
+func newKeyring(t *testing.T, master []byte, source KeySource) *Keyring {
+	t.Helper()
+
+	keys, err := NewKeyring(master, source)
+	require.NoError(t, err)
+	require.NoError(t, keys.Load(context.Background()))
+
+	return keys
+}
+
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	master := bytes.Repeat([]byte{1}, MasterKeySize)
+	source := &memorySource{}
+
+	keys := newKeyring(t, master, source)
+	assert.Equal(t, uint32(1), keys.Current())
+	assert.Len(t, source.keys, 1)
+
+	sealed, err := keys.Encrypt("email", "colin", "colin@example.com")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "enc2:1:"))
+	assert.NotContains(t, sealed, "colin")
+
+	version, ok := KeyVersion(sealed)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), version)
+
+	// 相同的值每次加密的结果不同
+	again, err := keys.Encrypt("email", "colin", "colin@example.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	plaintext, err := keys.Decrypt(ctx, "email", "colin", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "colin@example.com", plaintext)
+
+	// 加密值不能在其他字段或其他记录中使用
+	_, err = keys.Decrypt(ctx, "phone", "colin", sealed)
+	assert.Error(t, err)
+
+	_, err = keys.Decrypt(ctx, "email", "tom", sealed)
+	assert.Error(t, err)
+
+	// 空值不加密，明文和其他格式的值不能解密
+	empty, err := keys.Encrypt("phone", "colin", "")
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+
+	_, err = keys.Decrypt(ctx, "email", "colin", "legacy@example.com")
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+
+	_, err = keys.Decrypt(ctx, "email", "colin", strings.Replace(sealed, "enc2:", "enc:", 1))
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+
+	_, ok = KeyVersion("legacy@example.com")
+	assert.False(t, ok)
+
+	_, err = keys.Decrypt(ctx, "email", "colin", "enc2:1:not base64")
+	assert.Error(t, err)
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	ctx := context.Background()
+	master := bytes.Repeat([]byte{1}, MasterKeySize)
+	source := &memorySource{}
+
+	keys := newKeyring(t, master, source)
+	old, err := keys.Encrypt("email", "colin", "colin@example.com")
+	require.NoError(t, err)
+
+	// 另一个实例使用同一个 source，第一次启动时不会再创建数据密钥
+	other := newKeyring(t, master, source)
+	assert.Len(t, source.keys, 1)
+
+	version, err := keys.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), version)
+	assert.Equal(t, uint32(2), keys.Current())
+
+	sealed, err := keys.Encrypt("email", "colin", "colin@example.com")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, "enc2:2:"))
+
+	// 旧版本加密的值仍然可以解密
+	plaintext, err := keys.Decrypt(ctx, "email", "colin", old)
+	require.NoError(t, err)
+	assert.Equal(t, "colin@example.com", plaintext)
+
+	// 其他实例读到新版本加密的值时重新加载数据密钥
+	assert.Equal(t, uint32(1), other.Current())
+	plaintext, err = other.Decrypt(ctx, "email", "colin", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "colin@example.com", plaintext)
+	assert.Equal(t, uint32(2), other.Current())
+
+	_, err = keys.Decrypt(ctx, "email", "colin", "enc2:9:"+strings.SplitN(sealed, ":", 3)[2])
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+}
+
+func TestKeyring_WrongMasterKey(t *testing.T) {
+	source := &memorySource{}
+	newKeyring(t, bytes.Repeat([]byte{1}, MasterKeySize), source)
+
+	keys, err := NewKeyring(bytes.Repeat([]byte{2}, MasterKeySize), source)
+	require.NoError(t, err)
+	assert.Error(t, keys.Load(context.Background()))
+
+	_, err = NewKeyring([]byte("short"), source)
+	assert.Error(t, err)
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	keys := newKeyring(t, bytes.Repeat([]byte{1}, MasterKeySize), &memorySource{})
+	other := newKeyring(t, bytes.Repeat([]byte{2}, MasterKeySize), &memorySource{})
+
+	index := keys.BlindIndex("email", "colin@example.com")
+	assert.Len(t, index, 64)
+	assert.Equal(t, index, keys.BlindIndex("email", " Colin@Example.com "))
+	assert.NotEqual(t, index, keys.BlindIndex("phone", "colin@example.com"))
+	assert.NotEqual(t, index, keys.BlindIndex("email", "lin@example.com"))
+
+	// 盲索引依赖主密钥
+	assert.NotEqual(t, index, other.BlindIndex("email", "colin@example.com"))
+}
@@ -0,0 +1,90 @@
+// Package etag 计算资源的 ETag 并处理 If-None-Match 和 If-Match 条件请求。
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+)
+
+// 条件请求相关的请求头和响应头。
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// New 根据资源的版本信息（例如 ID 和 version）计算强 ETag，返回值包含双引号。
+func New(parts ...interface{}) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%v\x00", p)
+	}
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// NotModified 设置 ETag 响应头，并在 GET/HEAD 请求的 If-None-Match 匹配 etag 时返回 304。
+// 返回 true 表示已经写入了响应，调用方不需要再返回资源。
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header(HeaderETag, etag)
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match 使用弱比较
+	if !matchETag(c.GetHeader(HeaderIfNoneMatch), etag, false) {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+
+	return true
+}
+
+// CheckIfMatch 检查 If-Match 请求头，请求头存在但不匹配当前资源的 etag 时返回 code.ErrPreconditionFailed。
+// 返回 true 表示请求带有 If-Match，调用方需要在存储层原子地检查资源没有被修改。
+func CheckIfMatch(c *gin.Context, etag string) (bool, error) {
+	ifMatch := c.GetHeader(HeaderIfMatch)
+	if ifMatch == "" {
+		return false, nil
+	}
+
+	// If-Match 使用强比较，弱 ETag 不会匹配
+	if !matchETag(ifMatch, etag, true) {
+		return true, errors.WithCode(code.ErrPreconditionFailed, "%s %s does not match the current ETag", HeaderIfMatch, ifMatch)
+	}
+
+	return true, nil
+}
+
+// matchETag 判断逗号分隔的 ETag 列表（或 *）中是否有与 etag 匹配的值。
+func matchETag(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+
+			candidate = candidate[2:]
+		}
+
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
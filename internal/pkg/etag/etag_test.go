@@ -0,0 +1,96 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+)
+
+func newTestContext(method string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/", nil)
+
+	return c, w
+}
+
+func TestNew(t *testing.T) {
+	assert.Equal(t, New(1, 100), New(1, 100))
+	assert.NotEqual(t, New(1, 100), New(1, 101))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, New(1, 100))
+}
+
+func TestNotModified(t *testing.T) {
+	etag := New(1, 100)
+
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", http.MethodGet, "", false},
+		{"match", http.MethodGet, etag, true},
+		{"weak match", http.MethodGet, `"other", W/` + etag, true},
+		{"any", http.MethodHead, "*", true},
+		{"mismatch", http.MethodGet, `"other"`, false},
+		{"not get", http.MethodPut, etag, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestContext(tt.method)
+			c.Request.Header.Set(HeaderIfNoneMatch, tt.ifNoneMatch)
+
+			assert.Equal(t, tt.want, NotModified(c, etag))
+			assert.Equal(t, etag, w.Header().Get(HeaderETag))
+
+			if tt.want {
+				c.Writer.WriteHeaderNow()
+				assert.Equal(t, http.StatusNotModified, w.Code)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	etag := New(1, 100)
+
+	tests := []struct {
+		name            string
+		ifMatch         string
+		wantConditional bool
+		wantErr         bool
+	}{
+		{"no header", "", false, false},
+		{"match", `"other", ` + etag, true, false},
+		{"any", "*", true, false},
+		{"weak never matches", "W/" + etag, true, true},
+		{"mismatch", `"other"`, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(http.MethodPut)
+			c.Request.Header.Set(HeaderIfMatch, tt.ifMatch)
+
+			conditional, err := CheckIfMatch(c, etag)
+			assert.Equal(t, tt.wantConditional, conditional)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.IsCode(err, code.ErrPreconditionFailed))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
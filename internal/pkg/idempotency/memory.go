@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 指定多久清理一次过期的记录。
+const sweepInterval = time.Minute
+
+type memoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*Record
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore 返回一个保存在进程内存中的 Store，只适用于单实例部署。
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records: make(map[string]*Record),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, rec *Record) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if existing, ok := s.records[rec.Key]; ok && !existing.Expired(now) {
+		return copyRecord(existing), nil
+	}
+
+	s.records[rec.Key] = copyRecord(rec)
+
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	completed := copyRecord(rec)
+	completed.Completed = true
+	s.records[rec.Key] = completed
+
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+// sweep 定期删除过期的记录，调用方需要持有锁。
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, rec := range s.records {
+		if rec.Expired(now) {
+			delete(s.records, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+func copyRecord(rec *Record) *Record {
+	c := *rec
+	c.Header = rec.Header.Clone()
+	c.Body = append([]byte(nil), rec.Body...)
+
+	return &c
+}
@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore().(*memoryStore)
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	rec := &Record{Key: "k", Fingerprint: "f", ExpiresAt: now.Add(time.Minute)}
+
+	existing, err := s.Reserve(ctx, rec)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = s.Reserve(ctx, &Record{Key: "k", Fingerprint: "other", ExpiresAt: now.Add(time.Minute)})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "f", existing.Fingerprint)
+	assert.False(t, existing.Completed)
+
+	rec.StatusCode, rec.Body = 201, []byte("ok")
+	require.NoError(t, s.Complete(ctx, rec))
+
+	existing, _ = s.Reserve(ctx, rec)
+	require.NotNil(t, existing)
+	assert.True(t, existing.Completed)
+	assert.Equal(t, []byte("ok"), existing.Body)
+
+	// 过期的记录视为不存在，并在清理时被删除
+	now = now.Add(2 * time.Minute)
+	existing, _ = s.Reserve(ctx, &Record{Key: "k2", ExpiresAt: now.Add(time.Minute)})
+	assert.Nil(t, existing)
+	assert.NotContains(t, s.records, "k")
+
+	require.NoError(t, s.Delete(ctx, "k2"))
+	existing, _ = s.Reserve(ctx, &Record{Key: "k2", ExpiresAt: now.Add(time.Minute)})
+	assert.Nil(t, existing)
+}
@@ -0,0 +1,154 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sweepBatchSize 限制每次清理删除的过期记录数量，避免长时间锁表。
+const sweepBatchSize = 1000
+
+// record 是 idempotency_record 表对应的 gorm 模型。
+type record struct {
+	Key         string    `gorm:"column:idempotencyKey;primaryKey"`
+	Fingerprint string    `gorm:"column:fingerprint"`
+	Completed   bool      `gorm:"column:completed"`
+	StatusCode  int       `gorm:"column:statusCode"`
+	ContentType string    `gorm:"column:contentType"`
+	Header      []byte    `gorm:"column:header"`
+	Body        []byte    `gorm:"column:body"`
+	ExpiresAt   time.Time `gorm:"column:expiresAt"`
+}
+
+// TableName maps to mysql table name.
+func (record) TableName() string {
+	return "idempotency_record"
+}
+
+type mysqlStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMySQLStore 返回一个基于 MySQL idempotency_record 表的 Store，适用于多实例部署。
+func NewMySQLStore(db *gorm.DB) Store {
+	return &mysqlStore{db: db, now: time.Now}
+}
+
+func (s *mysqlStore) Reserve(ctx context.Context, rec *Record) (*Record, error) {
+	now := s.now()
+	s.sweep(ctx, now)
+
+	db := s.db.WithContext(ctx)
+
+	// 过期的记录视为不存在
+	if err := db.Where("idempotencyKey = ? AND expiresAt <= ?", rec.Key, now).Delete(&record{}).Error; err != nil {
+		return nil, errors.Wrap(err, "delete expired idempotency record")
+	}
+
+	row := fromRecord(rec)
+
+	// 插入失败（主键冲突）时不做任何修改，通过影响的行数判断是否已存在
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "create idempotency record")
+	}
+
+	if result.RowsAffected > 0 {
+		return nil, nil
+	}
+
+	existing := &record{}
+	if err := db.Where("idempotencyKey = ?", rec.Key).First(existing).Error; err != nil {
+		return nil, errors.Wrap(err, "get idempotency record")
+	}
+
+	return existing.toRecord()
+}
+
+func (s *mysqlStore) Complete(ctx context.Context, rec *Record) error {
+	err := s.db.WithContext(ctx).Model(&record{}).Where("idempotencyKey = ?", rec.Key).Updates(map[string]interface{}{
+		"completed":   true,
+		"statusCode":  rec.StatusCode,
+		"contentType": rec.ContentType,
+		"header":      encodeHeader(rec.Header),
+		"body":        rec.Body,
+	}).Error
+
+	return errors.Wrap(err, "complete idempotency record")
+}
+
+func (s *mysqlStore) Delete(ctx context.Context, key string) error {
+	err := s.db.WithContext(ctx).Where("idempotencyKey = ?", key).Delete(&record{}).Error
+
+	return errors.Wrap(err, "delete idempotency record")
+}
+
+// sweep 定期分批删除过期的记录，失败时等待下一次清理。
+func (s *mysqlStore) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	s.db.WithContext(ctx).Where("expiresAt <= ?", now).Limit(sweepBatchSize).Delete(&record{})
+}
+
+func fromRecord(rec *Record) *record {
+	return &record{
+		Key:         rec.Key,
+		Fingerprint: rec.Fingerprint,
+		Completed:   rec.Completed,
+		StatusCode:  rec.StatusCode,
+		ContentType: rec.ContentType,
+		Header:      encodeHeader(rec.Header),
+		Body:        rec.Body,
+		ExpiresAt:   rec.ExpiresAt,
+	}
+}
+
+func (r *record) toRecord() (*Record, error) {
+	rec := &Record{
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		Completed:   r.Completed,
+		StatusCode:  r.StatusCode,
+		ContentType: r.ContentType,
+		Body:        r.Body,
+		ExpiresAt:   r.ExpiresAt,
+	}
+
+	if len(r.Header) > 0 {
+		if err := json.Unmarshal(r.Header, &rec.Header); err != nil {
+			return nil, errors.Wrap(err, "decode idempotency record header")
+		}
+	}
+
+	return rec, nil
+}
+
+// encodeHeader 将响应头编码为 JSON 保存在 header 列中，没有响应头时保存 NULL。
+func encodeHeader(h http.Header) []byte {
+	if len(h) == 0 {
+		return nil
+	}
+
+	// http.Header 只包含字符串，编码不会失败
+	data, _ := json.Marshal(h)
+
+	return data
+}
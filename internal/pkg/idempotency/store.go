@@ -0,0 +1,41 @@
+// Package idempotency 保存带有 Idempotency-Key 的请求的指纹和响应，用于重试时重放响应。
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Record 是一个 Idempotency-Key 对应的请求记录。
+type Record struct {
+	// Key 是按调用方隔离后的 Idempotency-Key。
+	Key string
+	// Fingerprint 是请求方法、路径和请求体的摘要，用于识别复用 key 的不同请求。
+	Fingerprint string
+	// Completed 为 false 表示第一次请求仍在处理中。
+	Completed bool
+
+	StatusCode  int
+	ContentType string
+	// Header 是 handler 设置的其他响应头，例如 ETag 和 Location，重放时一起返回。
+	Header http.Header
+	Body   []byte
+
+	ExpiresAt time.Time
+}
+
+// Expired 判断记录在 now 时是否已经过期。
+func (r *Record) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Store 保存 Idempotency-Key 记录，实现需要保证 Reserve 的原子性。
+type Store interface {
+	// Reserve 在 key 不存在或已过期时保存 rec 并返回 nil；否则不做修改，返回已存在的记录。
+	Reserve(ctx context.Context, rec *Record) (*Record, error)
+	// Complete 保存请求的响应，并将记录标记为已完成。
+	Complete(ctx context.Context, rec *Record) error
+	// Delete 删除记录，请求失败时调用，使客户端可以使用相同的 key 重试。
+	Delete(ctx context.Context, key string) error
+}
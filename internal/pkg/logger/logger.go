@@ -61,21 +61,21 @@ func (l *logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
 // Info print info.
 func (l logger) Info(ctx context.Context, msg string, data ...interface{}) {
 	if l.LogLevel >= Info {
-		l.Printf(l.infoStr+msg, append([]interface{}{fileWithLineNum()}, data...)...)
+		l.Printf(requestIDPrefix(ctx)+l.infoStr+msg, append([]interface{}{fileWithLineNum()}, data...)...)
 	}
 }
 
 // Warn print warn messages.
 func (l logger) Warn(ctx context.Context, msg string, data ...interface{}) {
 	if l.LogLevel >= Warn {
-		l.Printf(l.warnStr+msg, append([]interface{}{fileWithLineNum()}, data...)...)
+		l.Printf(requestIDPrefix(ctx)+l.warnStr+msg, append([]interface{}{fileWithLineNum()}, data...)...)
 	}
 }
 
 // Error print error messages.
 func (l logger) Error(ctx context.Context, msg string, data ...interface{}) {
 	if l.LogLevel >= Error {
-		l.Printf(l.errStr+msg, append([]interface{}{fileWithLineNum()}, data...)...)
+		l.Printf(requestIDPrefix(ctx)+l.errStr+msg, append([]interface{}{fileWithLineNum()}, data...)...)
 	}
 }
 
@@ -90,28 +90,44 @@ func (l logger) Trace(ctx context.Context, begin time.Time, fc func() (string, i
 	case err != nil && l.LogLevel >= Error:
 		sql, rows := fc()
 		if rows == -1 {
-			l.Printf(l.traceErrStr, fileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, "-", sql)
+			l.Printf(requestIDPrefix(ctx)+l.traceErrStr, fileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, "-", sql)
 		} else {
-			l.Printf(l.traceErrStr, fileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+			l.Printf(requestIDPrefix(ctx)+l.traceErrStr, fileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
 		}
 	case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= Warn:
 		sql, rows := fc()
 		slowLog := fmt.Sprintf("SLOW SQL >= %v", l.SlowThreshold)
 		if rows == -1 {
-			l.Printf(l.traceWarnStr, fileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, "-", sql)
+			l.Printf(requestIDPrefix(ctx)+l.traceWarnStr, fileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, "-", sql)
 		} else {
-			l.Printf(l.traceWarnStr, fileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+			l.Printf(requestIDPrefix(ctx)+l.traceWarnStr, fileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
 		}
 	case l.LogLevel >= Info:
 		sql, rows := fc()
 		if rows == -1 {
-			l.Printf(l.traceStr, fileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, "-", sql)
+			l.Printf(requestIDPrefix(ctx)+l.traceStr, fileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, "-", sql)
 		} else {
-			l.Printf(l.traceStr, fileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, rows, sql)
+			l.Printf(requestIDPrefix(ctx)+l.traceStr, fileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, rows, sql)
 		}
 	}
 }
 
+// requestIDPrefix 返回 ctx 中 requestID 对应的日志前缀，用于将 SQL 日志与 HTTP 请求关联起来。
+// ctx 中没有 requestID 时返回空字符串。
+func requestIDPrefix(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestID, ok := ctx.Value(log.KeyRequestID).(string)
+	if !ok || requestID == "" {
+		return ""
+	}
+
+	// requestID 会被拼接到格式化字符串中，需要转义其中的 %
+	return "[" + log.KeyRequestID + ":" + strings.ReplaceAll(requestID, "%", "%%") + "] "
+}
+
 func fileWithLineNum() string {
 	for i := 4; i < 15; i++ {
 		_, file, line, ok := runtime.Caller(i)
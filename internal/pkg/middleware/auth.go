@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// AuthStrategy defines the set of methods used to do resource authentication.
+type AuthStrategy interface {
+	AuthFunc() gin.HandlerFunc
+}
+
+// AuthOperator used to switch between different authentication strategy.
+type AuthOperator struct {
+	strategy AuthStrategy
+}
+
+// SetStrategy used to set to another authentication strategy.
+func (operator *AuthOperator) SetStrategy(strategy AuthStrategy) {
+	operator.strategy = strategy
+}
+
+// Enabled 判断是否设置了认证策略。
+func (operator *AuthOperator) Enabled() bool {
+	return operator.strategy != nil
+}
+
+// AuthFunc execute resource authentication.
+func (operator *AuthOperator) AuthFunc() gin.HandlerFunc {
+	return operator.strategy.AuthFunc()
+}
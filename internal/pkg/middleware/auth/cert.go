@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// ErrUnknownIdentity 表示证书中的身份没有对应的可用用户，lookup 返回的其他错误（例如数据库不可用）会中止认证。
+var ErrUnknownIdentity = errors.New("identity does not map to any user")
+
+// CertStrategy defines client certificate (mutual TLS) authentication strategy.
+// 客户端证书必须已经在 TLS 握手阶段通过 --secure.client-ca-file 校验。
+type CertStrategy struct {
+	lookup func(ctx context.Context, identity string) (string, error)
+	// optional 为 true 时（--secure.client-auth=request）没有证书的请求不经认证直接放行
+	optional bool
+}
+
+var _ middleware.AuthStrategy = &CertStrategy{}
+
+// NewCertStrategy create client certificate strategy with a lookup function.
+// lookup 根据证书中的身份（CommonName 或 SAN）返回对应的用户名，找不到用户时返回包装 ErrUnknownIdentity 的错误。
+// optional 为 true 时没有客户端证书的请求不需要认证，带有证书的请求仍然需要映射为用户。
+func NewCertStrategy(lookup func(ctx context.Context, identity string) (string, error), optional bool) CertStrategy {
+	return CertStrategy{
+		lookup:   lookup,
+		optional: optional,
+	}
+}
+
+// AuthFunc defines client certificate strategy as the gin authentication middleware.
+func (c CertStrategy) AuthFunc() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.TLS == nil || len(ctx.Request.TLS.VerifiedChains) == 0 {
+			if c.optional {
+				ctx.Next()
+
+				return
+			}
+
+			core.WriteResponse(ctx, errors.WithCode(code.ErrMissingClientCert, "no verified client certificate"), nil)
+			ctx.Abort()
+
+			return
+		}
+
+		leaf := ctx.Request.TLS.VerifiedChains[0][0]
+		username, err := c.resolve(ctx, leaf)
+		if err != nil {
+			core.WriteResponse(ctx, err, nil)
+			ctx.Abort()
+
+			return
+		}
+
+		ctx.Set(log.KeyUsername, username)
+		ctx.Next()
+	}
+}
+
+// resolve 按 Identities 的顺序返回第一个映射到用户的身份对应的用户名。
+// 没有身份映射到用户时返回 code.ErrUnknownClientCert，lookup 返回其他错误时返回 code.ErrDatabase。
+func (c CertStrategy) resolve(ctx context.Context, leaf *x509.Certificate) (string, error) {
+	for _, identity := range Identities(leaf) {
+		username, err := c.lookup(ctx, identity)
+		if err == nil {
+			return username, nil
+		}
+
+		if !errors.Is(err, ErrUnknownIdentity) {
+			return "", errors.WrapC(err, code.ErrDatabase, "look up user for client certificate identity %s", identity)
+		}
+
+		log.L(ctx).Debugf("client certificate identity %s does not map to a user: %s", identity, err.Error())
+	}
+
+	return "", errors.WithCode(code.ErrUnknownClientCert, "no user found for client certificate %s", leaf.Subject.String())
+}
+
+// Identities 返回客户端证书中可以映射为用户的身份，按 CommonName、DNS SAN、Email SAN、URI SAN 的顺序排列。
+func Identities(cert *x509.Certificate) []string {
+	var identities []string
+
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+
+	return identities
+}
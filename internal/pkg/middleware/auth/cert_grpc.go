@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor defines client certificate strategy as the gRPC unary authentication interceptor.
+// 与 REST API 的 AuthFunc 使用相同的身份映射，认证通过后 context 中 log.KeyUsername 的值为用户名。
+// 健康检查服务不需要认证。
+func (c CertStrategy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if skipGRPCAuth(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := c.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor defines client certificate strategy as the gRPC stream authentication interceptor.
+func (c CertStrategy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skipGRPCAuth(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := c.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate 使用 TLS 握手阶段校验过的客户端证书认证 gRPC 请求。
+func (c CertStrategy) authenticate(ctx context.Context) (context.Context, error) {
+	var chains [][]*x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			chains = tlsInfo.State.VerifiedChains
+		}
+	}
+
+	if len(chains) == 0 {
+		if c.optional {
+			return ctx, nil
+		}
+
+		return nil, status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+
+	username, err := c.resolve(ctx, chains[0][0])
+	if err != nil {
+		if errors.IsCode(err, code.ErrDatabase) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return context.WithValue(ctx, log.KeyUsername, username), nil
+}
+
+func skipGRPCAuth(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// authenticatedStream 使用认证后的 context 替换 grpc.ServerStream 的 context。
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
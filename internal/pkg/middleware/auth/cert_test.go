@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+func TestIdentities(t *testing.T) {
+	uri, err := url.Parse("spiffe://example.com/colin")
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "colin"},
+		DNSNames:       []string{"colin.example.com"},
+		EmailAddresses: []string{"colin@example.com"},
+		URIs:           []*url.URL{uri},
+	}
+
+	assert.Equal(t, []string{"colin", "colin.example.com", "colin@example.com", "spiffe://example.com/colin"},
+		Identities(cert))
+	assert.Empty(t, Identities(&x509.Certificate{}))
+}
+
+// users 是测试使用的 lookup，down 为 true 时模拟数据库不可用。
+type users struct {
+	names map[string]bool
+	down  bool
+}
+
+func (u *users) lookup(ctx context.Context, identity string) (string, error) {
+	if u.down {
+		return "", fmt.Errorf("connection refused")
+	}
+
+	if !u.names[identity] {
+		return "", fmt.Errorf("%w: %s", ErrUnknownIdentity, identity)
+	}
+
+	return identity, nil
+}
+
+func newTestEngine(strategy CertStrategy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	g := gin.New()
+	g.Use(strategy.AuthFunc())
+	g.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(log.KeyUsername))
+	})
+
+	return g
+}
+
+func serve(g *gin.Engine, cert *x509.Certificate) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+
+	var resp struct {
+		Code int `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp.Code
+}
+
+func TestCertStrategy_AuthFunc(t *testing.T) {
+	u := &users{names: map[string]bool{"colin": true, "tom@example.com": true}}
+	g := newTestEngine(NewCertStrategy(u.lookup, false))
+
+	w := serve(g, &x509.Certificate{Subject: pkix.Name{CommonName: "colin"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "colin", w.Body.String())
+
+	// CommonName 没有对应的用户时继续使用 SAN
+	w = serve(g, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}, EmailAddresses: []string{"tom@example.com"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "tom@example.com", w.Body.String())
+
+	w = serve(g, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, code.ErrUnknownClientCert, errorCode(t, w))
+
+	w = serve(g, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, code.ErrMissingClientCert, errorCode(t, w))
+
+	// 数据库不可用时不能当作证书没有对应的用户
+	u.down = true
+	w = serve(g, &x509.Certificate{Subject: pkix.Name{CommonName: "colin"}})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, code.ErrDatabase, errorCode(t, w))
+}
+
+func TestCertStrategy_AuthFunc_Optional(t *testing.T) {
+	u := &users{names: map[string]bool{"colin": true}}
+	g := newTestEngine(NewCertStrategy(u.lookup, true))
+
+	// 没有证书的请求不经认证放行
+	w := serve(g, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serve(g, &x509.Certificate{Subject: pkix.Name{CommonName: "colin"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "colin", w.Body.String())
+
+	// 带有证书时仍然需要映射为用户
+	w = serve(g, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// Context 是一个中间件，它将 request id 等公共字段同时注入到 gin.Context 和
+// http.Request 的 context.Context 中。
+// 这样无论下游拿到的是 gin.Context 还是 c.Request.Context()，log.L(ctx) 都能取到 requestID。
+func Context() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rid := GetRequestID(c)
+
+		c.Set(log.KeyRequestID, rid)
+
+		//nolint:staticcheck // log.L 使用字符串类型的 key 读取 requestID
+		ctx := context.WithValue(c.Request.Context(), log.KeyRequestID, rid)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
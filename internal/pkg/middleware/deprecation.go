@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+const (
+	// HeaderDeprecation defines Deprecation header key string (RFC 9745).
+	HeaderDeprecation = "Deprecation"
+	// HeaderSunset defines Sunset header key string (RFC 8594).
+	HeaderSunset = "Sunset"
+	// HeaderLink defines Link header key string.
+	HeaderLink = "Link"
+)
+
+// Deprecation 为已废弃的 API 版本设置 Deprecation 和 Sunset 响应头，
+// 有替代版本时通过 Link rel="successor-version" 指向替代版本中相同的路径。未废弃的版本不做任何处理。
+func Deprecation(v scheme.Version) gin.HandlerFunc {
+	if !v.Deprecated {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	deprecation := "true"
+	if !v.DeprecatedAt.IsZero() {
+		deprecation = "@" + strconv.FormatInt(v.DeprecatedAt.Unix(), 10)
+	}
+
+	prefix := "/" + v.Name
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set(HeaderDeprecation, deprecation)
+
+		if !v.Sunset.IsZero() {
+			h.Set(HeaderSunset, v.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		path := c.Request.URL.Path
+		if v.Successor != "" && (path == prefix || strings.HasPrefix(path, prefix+"/")) {
+			h.Add(HeaderLink, fmt.Sprintf(`</%s%s>; rel="successor-version"`, v.Successor, path[len(prefix):]))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+func newDeprecationEngine(v scheme.Version) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	g := gin.New()
+	g.Group("/"+v.Name, Deprecation(v)).GET("/users/:name", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return g
+}
+
+func TestDeprecation(t *testing.T) {
+	g := newDeprecationEngine(scheme.Version{
+		Name:         "v1",
+		Deprecated:   true,
+		DeprecatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC),
+		Successor:    "v2",
+	})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/colin", nil))
+
+	assert.Equal(t, "@1790812800", w.Header().Get(HeaderDeprecation))
+	assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", w.Header().Get(HeaderSunset))
+	assert.Equal(t, `</v2/users/colin>; rel="successor-version"`, w.Header().Get(HeaderLink))
+}
+
+func TestDeprecation_NotDeprecated(t *testing.T) {
+	g := newDeprecationEngine(scheme.Version{Name: "v2"})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/users/colin", nil))
+
+	assert.Empty(t, w.Header().Get(HeaderDeprecation))
+	assert.Empty(t, w.Header().Get(HeaderSunset))
+	assert.Empty(t, w.Header().Get(HeaderLink))
+}
@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/idempotency"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// 幂等相关的请求头和响应头。
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength 是 Idempotency-Key 的最大长度。
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyConfig 是幂等中间件的配置。
+type IdempotencyConfig struct {
+	// Store 保存请求指纹和响应。
+	Store idempotency.Store
+	// TTL 指定记录的保存时间，超过后相同的 key 会被当作新的请求处理。
+	TTL time.Duration
+}
+
+// Idempotency 返回一个处理 Idempotency-Key 请求头的中间件，只对 POST 请求生效。
+// 第一次请求正常处理，成功后（状态码小于 500）保存响应的状态码、Content-Type、handler 设置的响应头
+// （例如 ETag 和 Location）和响应体；相同 key 的重试请求直接重放保存的响应，并设置 Idempotent-Replayed: true。
+// 相同 key 但请求方法、路径或请求体不同时返回 409 和 code.ErrIdempotencyKeyMismatch，
+// 第一次请求仍在处理中时返回 409 和 code.ErrIdempotencyKeyInUse。
+// key 按调用方隔离，避免重放其他调用方的响应：有认证用户（log.KeyUsername）时按用户隔离，
+// 因此该中间件需要安装在认证中间件之后；没有认证用户时按客户端 IP 隔离。
+func Idempotency(cfg *IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+
+			return
+		}
+
+		if !validIdempotencyKey(key) {
+			core.WriteResponse(c, errors.WithCode(code.ErrIdempotencyKeyInvalid,
+				"%s must be 1-%d printable ASCII characters", HeaderIdempotencyKey, maxIdempotencyKeyLength), nil)
+			c.Abort()
+
+			return
+		}
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+			c.Abort()
+
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		rec := &idempotency.Record{
+			Key:         scopedIdempotencyKey(idempotencyScope(c), key),
+			Fingerprint: requestFingerprint(c.Request, body),
+			ExpiresAt:   time.Now().Add(cfg.TTL),
+		}
+
+		existing, err := cfg.Store.Reserve(c, rec)
+		if err != nil {
+			core.WriteResponse(c, errors.WrapC(err, code.ErrDatabase, "reserve idempotency key"), nil)
+			c.Abort()
+
+			return
+		}
+
+		if existing != nil {
+			replayIdempotentResponse(c, existing, rec.Fingerprint)
+
+			return
+		}
+
+		handleIdempotentRequest(c, cfg.Store, rec)
+	}
+}
+
+// replayIdempotentResponse 处理 key 已存在的请求。
+func replayIdempotentResponse(c *gin.Context, existing *idempotency.Record, fingerprint string) {
+	defer c.Abort()
+
+	switch {
+	case existing.Fingerprint != fingerprint:
+		core.WriteResponse(c, errors.WithCode(code.ErrIdempotencyKeyMismatch,
+			"%s was used by a request with a different method, path or body", HeaderIdempotencyKey), nil)
+	case !existing.Completed:
+		core.WriteResponse(c, errors.WithCode(code.ErrIdempotencyKeyInUse,
+			"the original request is still in progress"), nil)
+	default:
+		for name, values := range existing.Header {
+			c.Writer.Header()[name] = values
+		}
+		c.Header(HeaderIdempotentReplayed, "true")
+		c.Data(existing.StatusCode, existing.ContentType, existing.Body)
+	}
+}
+
+// handleIdempotentRequest 执行请求并保存响应。请求失败（5xx 或 panic）时删除记录，允许客户端重试。
+// 保存和删除使用新的 context，避免客户端断开连接后记录一直处于处理中的状态。
+func handleIdempotentRequest(c *gin.Context, store idempotency.Store, rec *idempotency.Record) {
+	w := &bodyRecorder{ResponseWriter: c.Writer}
+	c.Writer = w
+
+	// 之前的中间件设置的响应头（例如 X-Request-ID）属于本次请求，不保存
+	before := w.Header().Clone()
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := store.Delete(context.Background(), rec.Key); err != nil {
+			log.L(c).Warnf("Failed to delete idempotency record: %s", err.Error())
+		}
+	}()
+
+	c.Next()
+
+	if w.Status() >= http.StatusInternalServerError {
+		return
+	}
+
+	rec.StatusCode = w.Status()
+	rec.ContentType = w.Header().Get("Content-Type")
+	rec.Header = handlerHeaders(before, w.Header())
+	rec.Body = w.body.Bytes()
+
+	if err := store.Complete(context.Background(), rec); err != nil {
+		log.L(c).Warnf("Failed to save idempotent response: %s", err.Error())
+
+		return
+	}
+
+	completed = true
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// idempotencyScope 返回隔离 Idempotency-Key 的调用方，优先使用认证用户，没有认证用户时使用客户端 IP。
+func idempotencyScope(c *gin.Context) string {
+	if username := c.GetString(log.KeyUsername); username != "" {
+		return "user:" + username
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// scopedIdempotencyKey 返回按调用方隔离的 key 的摘要，摘要长度固定，便于存储。
+func scopedIdempotencyKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// handlerHeaders 返回 handler 新设置或修改的响应头，Content-Type 单独保存，Content-Length 由响应体决定。
+func handlerHeaders(before, after http.Header) http.Header {
+	var headers http.Header
+	for name, values := range after {
+		if name == "Content-Type" || name == "Content-Length" || equalValues(before[name], values) {
+			continue
+		}
+
+		if headers == nil {
+			headers = http.Header{}
+		}
+		headers[name] = append([]string(nil), values...)
+	}
+
+	return headers
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestFingerprint 计算请求方法、URI、Content-Type 和请求体的摘要。
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, s := range []string{r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder 在写入响应的同时保存响应体。
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
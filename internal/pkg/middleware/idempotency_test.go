@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/idempotency"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// authenticate 模拟认证中间件，使用 X-User 请求头作为认证用户。
+func authenticate(c *gin.Context) {
+	if username := c.GetHeader("X-User"); username != "" {
+		c.Set(log.KeyUsername, username)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	g := gin.New()
+	g.Use(authenticate, Idempotency(&IdempotencyConfig{Store: idempotency.NewMemoryStore(), TTL: time.Hour}))
+	g.POST("/v1/users", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"calls": calls})
+	})
+	g.POST("/v1/fail", func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusInternalServerError)
+	})
+
+	doAs := func(user, path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("X-User", user)
+		if key != "" {
+			r.Header.Set(HeaderIdempotencyKey, key)
+		}
+		g.ServeHTTP(w, r)
+
+		return w
+	}
+	do := func(path, key, body string) *httptest.ResponseRecorder {
+		return doAs("colin", path, key, body)
+	}
+
+	w := do("/v1/users", "key-1", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderIdempotentReplayed))
+
+	// 重试时重放第一次的响应，不再执行 handler
+	w = do("/v1/users", "key-1", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get(HeaderIdempotentReplayed))
+	assert.JSONEq(t, `{"calls":1}`, w.Body.String())
+	assert.Equal(t, 1, calls)
+
+	w = do("/v1/users", "key-1", `{"name":"b"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assertErrCode(t, w, code.ErrIdempotencyKeyMismatch)
+
+	// 没有 Idempotency-Key 的请求不受影响
+	do("/v1/users", "", `{"name":"a"}`)
+	assert.Equal(t, 2, calls)
+
+	// 5xx 响应不保存，相同的 key 可以重试
+	do("/v1/fail", "key-2", "")
+	do("/v1/fail", "key-2", "")
+	assert.Equal(t, 4, calls)
+
+	w = do("/v1/users", strings.Repeat("k", maxIdempotencyKeyLength+1), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assertErrCode(t, w, code.ErrIdempotencyKeyInvalid)
+
+	// key 按用户隔离，其他用户使用相同的 key 和请求体不会重放 colin 的响应
+	w = doAs("tom", "/v1/users", "key-1", `{"name":"a"}`)
+	assert.Empty(t, w.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, 5, calls)
+
+	// 没有认证用户的请求按客户端 IP 隔离
+	doAs("", "/v1/users", "key-3", `{"name":"a"}`)
+	w = doAs("", "/v1/users", "key-3", `{"name":"a"}`)
+	assert.Equal(t, "true", w.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, 6, calls)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(`{"name":"a"}`))
+	r.RemoteAddr = "192.0.2.2:1234"
+	r.Header.Set(HeaderIdempotencyKey, "key-3")
+	g.ServeHTTP(w, r)
+	assert.Empty(t, w.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, 7, calls)
+}
+
+func TestIdempotency_ReplayHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	g := gin.New()
+	g.Use(func(c *gin.Context) {
+		// 之前的中间件设置的响应头属于每次请求，不会被重放
+		c.Header("X-Request-ID", c.GetHeader("X-Request-ID"))
+	}, Idempotency(&IdempotencyConfig{Store: idempotency.NewMemoryStore(), TTL: time.Hour}))
+	g.POST("/v1/users", func(c *gin.Context) {
+		calls++
+		c.Header("ETag", `"1"`)
+		c.Header("Location", "/v1/users/colin")
+		c.JSON(http.StatusCreated, gin.H{"name": "colin"})
+	})
+
+	do := func(requestID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(`{"name":"colin"}`))
+		r.Header.Set(HeaderIdempotencyKey, "key")
+		r.Header.Set("X-Request-ID", requestID)
+		g.ServeHTTP(w, r)
+
+		return w
+	}
+
+	do("1")
+	w := do("2")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, "/v1/users/colin", w.Header().Get("Location"))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "2", w.Header().Get("X-Request-ID"))
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/users", nil)
+		r.Header.Set("X-User", "colin")
+		r.Header.Set(HeaderIdempotencyKey, "key")
+
+		return r
+	}
+
+	retry := httptest.NewRecorder()
+	g := gin.New()
+	g.Use(authenticate, Idempotency(&IdempotencyConfig{Store: idempotency.NewMemoryStore(), TTL: time.Hour}))
+	g.POST("/v1/users", func(c *gin.Context) {
+		// 第一次请求仍在处理中时收到重试请求
+		g.ServeHTTP(retry, newRequest())
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, newRequest())
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assertErrCode(t, retry, code.ErrIdempotencyKeyInUse)
+}
+
+func assertErrCode(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	var resp core.ErrResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, want, resp.Code)
+}
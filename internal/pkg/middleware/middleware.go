@@ -0,0 +1,13 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Middlewares 保存所有可选的中间件，通过 --server.middlewares 按名称启用。
+// RequestID、Context 等必要的中间件由 GenericAPIServer 默认安装，不需要在这里注册。
+var Middlewares = defaultMiddlewares()
+
+func defaultMiddlewares() map[string]gin.HandlerFunc {
+	return map[string]gin.HandlerFunc{}
+}
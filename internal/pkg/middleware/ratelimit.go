@@ -0,0 +1,313 @@
+package middleware
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// 限流相关的响应头，参考 IETF draft-ietf-httpapi-ratelimit-headers。
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// bucketIdleTimeout 指定 per-ip/per-user 令牌桶在多久未被访问后被清理。
+const bucketIdleTimeout = 10 * time.Minute
+
+// Limit 定义一个令牌桶的速率和容量。QPS 为 0 表示不限流。
+type Limit struct {
+	// QPS 是每秒向桶中补充的令牌数。
+	QPS float64
+	// Burst 是桶的容量，即允许的最大突发请求数。
+	Burst int
+}
+
+// Enabled 判断该限流规则是否生效。
+func (l Limit) Enabled() bool {
+	return l.QPS > 0 && l.Burst > 0
+}
+
+// RateLimitRule 定义一组路由需要遵守的全局、按 IP、按用户的限流规则。
+type RateLimitRule struct {
+	Global  Limit
+	PerIP   Limit
+	PerUser Limit
+}
+
+// RateLimitGroup 为匹配 Route 的请求覆盖默认的限流规则。
+// Route 的格式为 "[METHOD ]/path/prefix"，例如 "POST /v1/users" 或 "/v1"。
+type RateLimitGroup struct {
+	Route string
+	RateLimitRule
+}
+
+// RateLimitConfig 是限流中间件的配置。
+type RateLimitConfig struct {
+	// Default 应用于没有匹配到任何 Groups 的请求。
+	Default RateLimitRule
+	// Groups 按路由覆盖默认规则，匹配时选择路径前缀最长的分组。
+	Groups []RateLimitGroup
+}
+
+// RateLimit 返回一个基于令牌桶的限流中间件。
+// 每个路由分组拥有独立的全局桶、按客户端 IP 的桶和按认证用户（log.KeyUsername）的桶，
+// 请求需要同时从所有生效的桶中取得令牌，否则返回 429 和 code.ErrTooManyRequests。
+// 按用户限流依赖于在该中间件之前执行的认证中间件设置的用户名。
+func RateLimit(cfg *RateLimitConfig) gin.HandlerFunc {
+	l := newRateLimiter(cfg)
+
+	return func(c *gin.Context) {
+		group := l.match(c.Request.Method, c.Request.URL.Path)
+
+		keys := []string{"global"}
+		if group.rule.PerIP.Enabled() {
+			keys = append(keys, "ip:"+c.ClientIP())
+		}
+		if username := c.GetString(log.KeyUsername); username != "" && group.rule.PerUser.Enabled() {
+			keys = append(keys, "user:"+username)
+		}
+
+		res, ok := group.take(keys, time.Now())
+		if res.limit > 0 {
+			c.Header(HeaderRateLimitLimit, strconv.Itoa(res.limit))
+			c.Header(HeaderRateLimitRemaining, strconv.Itoa(res.remaining))
+			c.Header(HeaderRateLimitReset, strconv.Itoa(seconds(res.reset)))
+		}
+
+		if !ok {
+			c.Header(HeaderRetryAfter, strconv.Itoa(seconds(res.retryAfter)))
+			core.WriteResponse(c, errors.WithCode(code.ErrTooManyRequests, "rate limit exceeded for %s", res.key), nil)
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// seconds 将时间向上取整为整秒，用于 RateLimit-Reset 和 Retry-After 头。
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ==========================================================================
+
+type rateLimiter struct {
+	defaultGroup *limitGroup
+	groups       []*limitGroup
+}
+
+func newRateLimiter(cfg *RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{
+		defaultGroup: newLimitGroup("", "", cfg.Default),
+	}
+
+	for _, g := range cfg.Groups {
+		method, prefix := parseRoute(g.Route)
+		l.groups = append(l.groups, newLimitGroup(method, prefix, g.RateLimitRule))
+	}
+
+	// 路径前缀越长越优先，相同前缀时指定了 method 的分组优先
+	sort.SliceStable(l.groups, func(i, j int) bool {
+		if len(l.groups[i].prefix) != len(l.groups[j].prefix) {
+			return len(l.groups[i].prefix) > len(l.groups[j].prefix)
+		}
+
+		return l.groups[i].method != "" && l.groups[j].method == ""
+	})
+
+	return l
+}
+
+// parseRoute 将 "POST /v1/users" 解析为 method 和路径前缀。
+func parseRoute(route string) (method, prefix string) {
+	fields := strings.Fields(route)
+	switch len(fields) {
+	case 0:
+		return "", "/"
+	case 1:
+		return "", fields[0]
+	default:
+		return strings.ToUpper(fields[0]), fields[1]
+	}
+}
+
+func (l *rateLimiter) match(method, path string) *limitGroup {
+	for _, g := range l.groups {
+		if g.method != "" && g.method != method {
+			continue
+		}
+
+		if path == g.prefix || strings.HasPrefix(path, strings.TrimSuffix(g.prefix, "/")+"/") {
+			return g
+		}
+	}
+
+	return l.defaultGroup
+}
+
+// limitGroup 持有一个路由分组的所有令牌桶。
+type limitGroup struct {
+	method string
+	prefix string
+	rule   RateLimitRule
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newLimitGroup(method, prefix string, rule RateLimitRule) *limitGroup {
+	return &limitGroup{
+		method:    method,
+		prefix:    prefix,
+		rule:      rule,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// takeResult 描述一次取令牌的结果，多个桶时取剩余令牌最少的那个。
+type takeResult struct {
+	key        string
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// take 从 keys 对应的所有桶中各取一个令牌。只要有一个桶没有令牌，则整个请求被拒绝，
+// 并且不会消耗其他桶中的令牌。
+func (g *limitGroup) take(keys []string, now time.Time) (takeResult, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.sweep(now)
+
+	var (
+		res    = takeResult{remaining: math.MaxInt32}
+		taken  []*bucket
+		denied bool
+	)
+
+	for _, key := range keys {
+		b := g.bucket(key, now)
+		if b == nil {
+			continue
+		}
+
+		b.refill(now)
+		if b.tokens < 1 {
+			denied = true
+			if wait := b.wait(); wait > res.retryAfter {
+				res.retryAfter = wait
+				res.key = key
+			}
+		}
+
+		taken = append(taken, b)
+	}
+
+	if len(taken) == 0 {
+		return takeResult{}, true
+	}
+
+	for _, b := range taken {
+		if !denied {
+			b.tokens--
+		}
+
+		if remaining := int(b.tokens); remaining < res.remaining {
+			res.remaining = remaining
+			res.limit = b.limit.Burst
+			res.reset = b.untilFull()
+		}
+	}
+
+	if res.remaining < 0 {
+		res.remaining = 0
+	}
+
+	return res, !denied
+}
+
+// bucket 返回 key 对应的令牌桶，对应的规则未开启时返回 nil。调用方需持有 g.mu。
+func (g *limitGroup) bucket(key string, now time.Time) *bucket {
+	var limit Limit
+	switch {
+	case key == "global":
+		limit = g.rule.Global
+	case strings.HasPrefix(key, "ip:"):
+		limit = g.rule.PerIP
+	case strings.HasPrefix(key, "user:"):
+		limit = g.rule.PerUser
+	}
+
+	if !limit.Enabled() {
+		return nil
+	}
+
+	b, ok := g.buckets[key]
+	if !ok {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		g.buckets[key] = b
+	}
+
+	return b
+}
+
+// sweep 清理长时间未被访问的令牌桶，避免按 IP 的桶无限增长。调用方需持有 g.mu。
+func (g *limitGroup) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < bucketIdleTimeout {
+		return
+	}
+
+	for key, b := range g.buckets {
+		if now.Sub(b.last) >= bucketIdleTimeout {
+			delete(g.buckets, key)
+		}
+	}
+
+	g.lastSweep = now
+}
+
+// bucket 是一个令牌桶，令牌以 limit.QPS 的速率补充，最多 limit.Burst 个。
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed.Seconds()*b.limit.QPS)
+		b.last = now
+	}
+}
+
+// wait 返回桶中至少有一个令牌还需要等待的时间。
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.limit.QPS * float64(time.Second))
+}
+
+// untilFull 返回桶被填满还需要等待的时间。
+func (b *bucket) untilFull() time.Duration {
+	return time.Duration((float64(b.limit.Burst) - b.tokens) / b.limit.QPS * float64(time.Second))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	g := gin.New()
+	g.Use(RateLimit(&RateLimitConfig{
+		Default: RateLimitRule{PerIP: Limit{QPS: 100, Burst: 100}},
+		Groups: []RateLimitGroup{
+			{Route: "POST /v1/users", RateLimitRule: RateLimitRule{PerIP: Limit{QPS: 1, Burst: 2}}},
+		},
+	}))
+	g.POST("/v1/users", func(c *gin.Context) {})
+	g.GET("/v1/users", func(c *gin.Context) {})
+
+	do := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(method, "/v1/users", nil))
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost).Code)
+
+	w := do(http.MethodPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+
+	w = do(http.MethodPost)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get(HeaderRetryAfter))
+
+	// GET /v1/users 不匹配 POST 分组，使用默认规则
+	w = do(http.MethodGet)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get(HeaderRateLimitLimit))
+}
+
+func TestLimitGroup_TakeIsAtomic(t *testing.T) {
+	now := time.Now()
+	g := newLimitGroup("", "/", RateLimitRule{
+		Global: Limit{QPS: 1, Burst: 10},
+		PerIP:  Limit{QPS: 1, Burst: 1},
+	})
+
+	_, ok := g.take([]string{"global", "ip:1.1.1.1"}, now)
+	assert.True(t, ok)
+
+	// 被 per-ip 桶拒绝时不应消耗全局桶中的令牌
+	_, ok = g.take([]string{"global", "ip:1.1.1.1"}, now)
+	assert.False(t, ok)
+	assert.Equal(t, float64(9), g.buckets["global"].tokens)
+
+	_, ok = g.take([]string{"global", "ip:2.2.2.2"}, now)
+	assert.True(t, ok)
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// Recovery 是一个中间件，它从 panic 中恢复，记录带有请求上下文的堆栈信息，
+// 并通过 core.WriteResponse 返回 ErrUnknown。
+// 客户端断开连接导致的 panic（broken pipe）只记录日志，不再写入响应。
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			if isBrokenPipe(r) {
+				log.L(c).Warnf("Client connection closed while serving %s %s: %v", c.Request.Method, c.Request.URL.Path, r)
+				c.Abort()
+
+				return
+			}
+
+			log.L(c).Errorf("Panic recovered while serving %s %s: %v\n%s",
+				c.Request.Method, c.Request.URL.Path, r, debug.Stack())
+
+			// 响应头已经写出时无法再返回错误信息
+			if c.Writer.Written() {
+				c.Abort()
+
+				return
+			}
+
+			core.WriteResponse(c, errors.WithCode(code.ErrUnknown, "panic: %v", r), nil)
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// isBrokenPipe 判断 panic 是否是因为客户端断开连接导致的。
+func isBrokenPipe(r interface{}) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+
+	if errors.Is(err, http.ErrAbortHandler) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+
+	msg := strings.ToLower(syscallErr.Error())
+
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// XRequestIDKey defines X-Request-ID key string.
+	XRequestIDKey = "X-Request-ID"
+
+	// maxRequestIDLength 限制客户端传入的 X-Request-ID 长度，避免超长的值被写入日志。
+	maxRequestIDLength = 128
+)
+
+// RequestID 是一个中间件，它为每个请求的 context、请求头和响应头注入 'X-Request-ID'。
+// 如果客户端传入了合法的 X-Request-ID，则沿用该值，否则生成一个新的 UUID。
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Check for incoming header, use it if exists
+		rid := c.GetHeader(XRequestIDKey)
+
+		if !isValidRequestID(rid) {
+			rid = uuid.New().String()
+			c.Request.Header.Set(XRequestIDKey, rid)
+		}
+
+		c.Set(XRequestIDKey, rid)
+
+		// Set XRequestIDKey header
+		c.Writer.Header().Set(XRequestIDKey, rid)
+		c.Next()
+	}
+}
+
+// GetRequestID 返回当前请求的 X-Request-ID。
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(XRequestIDKey)
+}
+
+// isValidRequestID 只接受长度合法、由可打印 ASCII 字符组成的 request id，
+// 防止客户端借此向日志中注入换行等控制字符。
+func isValidRequestID(rid string) bool {
+	if rid == "" || len(rid) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(rid); i++ {
+		if rid[i] < 0x21 || rid[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
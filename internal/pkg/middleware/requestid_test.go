@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+func newRequestIDEngine(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	g := gin.New()
+	g.Use(RequestID(), Context())
+	g.GET("/", handler)
+
+	return g
+}
+
+func TestRequestID_Generate(t *testing.T) {
+	var fromGin, fromCtx interface{}
+	g := newRequestIDEngine(func(c *gin.Context) {
+		fromGin = c.Value(log.KeyRequestID)
+		fromCtx = c.Request.Context().Value(log.KeyRequestID)
+	})
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	rid := w.Header().Get(XRequestIDKey)
+	assert.Len(t, rid, 36)
+	assert.Equal(t, rid, fromGin)
+	assert.Equal(t, rid, fromCtx)
+}
+
+func TestRequestID_Propagate(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "valid", incoming: "abc-123", keep: true},
+		{name: "control characters", incoming: "abc\n123", keep: false},
+		{name: "too long", incoming: string(make([]byte, maxRequestIDLength+1)), keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newRequestIDEngine(func(c *gin.Context) {})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(XRequestIDKey, tt.incoming)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			if tt.keep {
+				assert.Equal(t, tt.incoming, w.Header().Get(XRequestIDKey))
+			} else {
+				assert.NotEqual(t, tt.incoming, w.Header().Get(XRequestIDKey))
+				assert.Len(t, w.Header().Get(XRequestIDKey), 36)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/tiandh987/SharkAgent/pkg/scheme"
+)
+
+// apiVersionDateLayouts 是废弃和下线时间支持的格式。
+var apiVersionDateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// APIVersionOptions 包含 REST API 版本废弃相关的配置项，例如：
+//
+//	api:
+//	  deprecated: { v1: "2026-10-01" }
+//	  sunset: { v1: "2027-04-01" }
+type APIVersionOptions struct {
+	// Deprecated 的 key 为版本名，value 为废弃时间，为空时只返回 Deprecation: true。
+	Deprecated map[string]string `json:"deprecated" mapstructure:"deprecated"`
+	// Sunset 的 key 为版本名，value 为计划下线的时间，版本必须同时在 Deprecated 中。
+	Sunset map[string]string `json:"sunset"     mapstructure:"sunset"`
+}
+
+// NewAPIVersionOptions creates a APIVersionOptions object with default parameters.
+func NewAPIVersionOptions() *APIVersionOptions {
+	return &APIVersionOptions{
+		Deprecated: map[string]string{},
+		Sunset:     map[string]string{},
+	}
+}
+
+// ApplyTo 将废弃的版本标记到 scheme 中。
+func (o *APIVersionOptions) ApplyTo(s *scheme.Scheme) error {
+	for name, deprecatedAt := range o.Deprecated {
+		var deprecated, sunset time.Time
+		if deprecatedAt != "" {
+			deprecated, _ = parseAPIVersionDate(deprecatedAt)
+		}
+
+		if date, ok := o.Sunset[name]; ok {
+			sunset, _ = parseAPIVersionDate(date)
+		}
+
+		if err := s.Deprecate(name, deprecated, sunset); err != nil {
+			return fmt.Errorf("--api.deprecated: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *APIVersionOptions) Validate() []error {
+	var errs []error
+
+	for name, date := range o.Deprecated {
+		if date == "" {
+			continue
+		}
+
+		if _, err := parseAPIVersionDate(date); err != nil {
+			errs = append(errs, fmt.Errorf("--api.deprecated %s: %w", name, err))
+		}
+	}
+
+	for name, date := range o.Sunset {
+		if _, ok := o.Deprecated[name]; !ok {
+			errs = append(errs, fmt.Errorf("--api.sunset %s: version must be deprecated by --api.deprecated", name))
+		}
+
+		if _, err := parseAPIVersionDate(date); err != nil {
+			errs = append(errs, fmt.Errorf("--api.sunset %s: %w", name, err))
+		}
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to API versions for a specific APIServer to the
+// specified FlagSet.
+func (o *APIVersionOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringToStringVar(&o.Deprecated, "api.deprecated", o.Deprecated, ""+
+		"Mark API versions as deprecated, e.g. v1=2026-10-01. The date is when the version was deprecated "+
+		"and may be empty. Responses of deprecated versions carry Deprecation and Link successor-version headers.")
+
+	fs.StringToStringVar(&o.Sunset, "api.sunset", o.Sunset, ""+
+		"The date when deprecated API versions will be removed, e.g. v1=2027-04-01. "+
+		"Sent in the Sunset response header.")
+}
+
+func parseAPIVersionDate(s string) (time.Time, error) {
+	for _, layout := range apiVersionDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q, must be YYYY-MM-DD or RFC3339", s)
+}
@@ -0,0 +1,75 @@
+package options
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// GRPCOptions 包含 gRPC 服务相关的配置项。
+// TLS 为 true 时复用 --secure.* 的服务端证书、客户端 CA 和 TLS 参数。
+// 与不安全的 HTTP 服务一样，默认只监听 127.0.0.1。
+type GRPCOptions struct {
+	BindAddress string `json:"bind-address" mapstructure:"bind-address"`
+	BindPort    int    `json:"bind-port"    mapstructure:"bind-port"`
+	MaxMsgSize  int    `json:"max-msg-size" mapstructure:"max-msg-size"`
+	TLS         bool   `json:"tls"          mapstructure:"tls"`
+}
+
+// NewGRPCOptions creates a GRPCOptions object with default parameters.
+func NewGRPCOptions() *GRPCOptions {
+	return &GRPCOptions{
+		BindAddress: "127.0.0.1",
+		BindPort:    8081,
+		MaxMsgSize:  4 * 1024 * 1024,
+		TLS:         false,
+	}
+}
+
+// Address join host IP address and host port number into a address string, like: 127.0.0.1:8081.
+func (s *GRPCOptions) Address() string {
+	return net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort))
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (s *GRPCOptions) Validate() []error {
+	var errors []error
+
+	if s.BindPort < 0 || s.BindPort > 65535 {
+		errors = append(
+			errors,
+			fmt.Errorf(
+				"--grpc.bind-port %v must be between 0 and 65535, inclusive. 0 for turning off gRPC port",
+				s.BindPort,
+			),
+		)
+	}
+
+	if s.MaxMsgSize <= 0 {
+		errors = append(errors, fmt.Errorf("--grpc.max-msg-size %v must be greater than 0", s.MaxMsgSize))
+	}
+
+	return errors
+}
+
+// AddFlags adds flags related to gRPC serving for a specific APIServer to the
+// specified FlagSet.
+func (s *GRPCOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&s.BindAddress, "grpc.bind-address", s.BindAddress, ""+
+		"The IP address on which to serve the --grpc.bind-port "+
+		"(set to 0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces).")
+
+	fs.IntVar(&s.BindPort, "grpc.bind-port", s.BindPort, ""+
+		"The port on which to serve gRPC. Without --grpc.tls the port is not encrypted, "+
+		"make sure it is only reachable from trusted networks. Set to zero to disable.")
+
+	fs.IntVar(&s.MaxMsgSize, "grpc.max-msg-size", s.MaxMsgSize, "gRPC max message size.")
+
+	fs.BoolVar(&s.TLS, "grpc.tls", s.TLS, ""+
+		"Serve gRPC over TLS with the certificate, client CA and TLS settings of --secure.*. "+
+		"Client certificate authentication is enforced the same way as the REST API and requires this flag. "+
+		"CredentialService is only served with this flag and --secure.client-auth=require-and-verify.")
+}
@@ -0,0 +1,100 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/tiandh987/SharkAgent/internal/pkg/idempotency"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
+	"github.com/tiandh987/SharkAgent/internal/pkg/server"
+)
+
+// 幂等记录支持的存储类型。
+const (
+	IdempotencyStoreMemory = "memory"
+	IdempotencyStoreMySQL  = "mysql"
+)
+
+// IdempotencyOptions 包含 POST 请求 Idempotency-Key 处理相关的配置项。
+type IdempotencyOptions struct {
+	Enabled bool          `json:"enabled" mapstructure:"enabled"`
+	TTL     time.Duration `json:"ttl"     mapstructure:"ttl"`
+	Store   string        `json:"store"   mapstructure:"store"`
+}
+
+// NewIdempotencyOptions creates a IdempotencyOptions object with default parameters.
+func NewIdempotencyOptions() *IdempotencyOptions {
+	return &IdempotencyOptions{
+		Enabled: false,
+		TTL:     24 * time.Hour,
+		Store:   IdempotencyStoreMemory,
+	}
+}
+
+// ApplyTo applies the run options to the method receiver and returns self.
+// 使用 mysql 存储时，通过 mysqlOptions 创建数据库连接。
+func (o *IdempotencyOptions) ApplyTo(c *server.Config, mysqlOptions *MySQLOptions) error {
+	if !o.Enabled {
+		c.Idempotency = nil
+
+		return nil
+	}
+
+	var store idempotency.Store
+
+	switch o.Store {
+	case IdempotencyStoreMySQL:
+		db, err := mysqlOptions.NewClient()
+		if err != nil {
+			return err
+		}
+
+		store = idempotency.NewMySQLStore(db)
+	default:
+		store = idempotency.NewMemoryStore()
+	}
+
+	c.Idempotency = &middleware.IdempotencyConfig{
+		Store: store,
+		TTL:   o.TTL,
+	}
+
+	return nil
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *IdempotencyOptions) Validate() []error {
+	errs := []error{}
+
+	if !o.Enabled {
+		return errs
+	}
+
+	if o.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("--idempotency.ttl must be greater than 0"))
+	}
+
+	if o.Store != IdempotencyStoreMemory && o.Store != IdempotencyStoreMySQL {
+		errs = append(errs, fmt.Errorf("--idempotency.store must be one of %q or %q, got %q",
+			IdempotencyStoreMemory, IdempotencyStoreMySQL, o.Store))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to Idempotency-Key handling for a specific APIServer to the
+// specified FlagSet.
+func (o *IdempotencyOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "idempotency.enabled", o.Enabled, ""+
+		"Replay the saved response for POST requests retried with the same Idempotency-Key header. "+
+		"Keys are scoped to the authenticated user, or to the client IP when the request is not authenticated.")
+
+	fs.DurationVar(&o.TTL, "idempotency.ttl", o.TTL, ""+
+		"How long the request fingerprint and response of an Idempotency-Key are kept.")
+
+	fs.StringVar(&o.Store, "idempotency.store", o.Store, ""+
+		"Where to keep idempotency records, one of memory or mysql. "+
+		"Use mysql when running more than one apiserver instance.")
+}
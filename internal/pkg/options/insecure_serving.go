@@ -10,9 +10,12 @@ import (
 
 // InsecureServingOptions 用于创建未经身份验证的、未经授权的、不安全的端口。
 // 没有人应该再使用这些了。
+// BindAddress 也可以是 unix:///path.sock，此时忽略 BindPort。
 type InsecureServingOptions struct {
 	BindAddress string `json:"bind-address" mapstructure:"bind-address"`
 	BindPort    int    `json:"bind-port"    mapstructure:"bind-port"`
+
+	UnixSocketOptions `json:",inline" mapstructure:",squash"`
 }
 
 // NewInsecureServingOptions is for creating an unauthenticated, unauthorized, insecure port.
@@ -26,8 +29,20 @@ func NewInsecureServingOptions() *InsecureServingOptions {
 
 // ApplyTo applies the run options to the method receiver and returns self.
 func (s *InsecureServingOptions) ApplyTo(c *server.Config) error {
-	c.InsecureServing = &server.InsecureServingInfo{
-		Address: net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort)),
+	c.InsecureServing = &server.InsecureServingInfo{}
+
+	switch {
+	case server.IsUnixSocket(s.BindAddress):
+		socket, err := s.UnixSocketOptions.unixSocketInfo()
+		if err != nil {
+			return err
+		}
+
+		c.InsecureServing.Address = s.BindAddress
+		c.InsecureServing.UnixSocket = socket
+	case s.BindPort != 0:
+		// BindPort 为 0 时不开启 HTTP 服务
+		c.InsecureServing.Address = net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort))
 	}
 
 	return nil
@@ -38,6 +53,10 @@ func (s *InsecureServingOptions) ApplyTo(c *server.Config) error {
 func (s *InsecureServingOptions) Validate() []error {
 	var errors []error
 
+	if server.IsUnixSocket(s.BindAddress) {
+		return s.UnixSocketOptions.Validate("insecure")
+	}
+
 	if s.BindPort < 0 || s.BindPort > 65535 {
 		errors = append(
 			errors,
@@ -56,10 +75,15 @@ func (s *InsecureServingOptions) Validate() []error {
 func (s *InsecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.BindAddress, "insecure.bind-address", s.BindAddress, ""+
 		"The IP address on which to serve the --insecure.bind-port "+
-		"(set to 0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces).")
+		"(set to 0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces). "+
+		"Set to unix:///path.sock to serve on a unix domain socket instead of a TCP port. "+
+		"Listeners passed by systemd socket activation (LISTEN_FDS) named 'insecure' or "+
+		"bound to the same address are reused.")
 	fs.IntVar(&s.BindPort, "insecure.bind-port", s.BindPort, ""+
 		"The port on which to serve unsecured, unauthenticated access. It is assumed "+
 		"that firewall rules are set up such that this port is not reachable from outside of "+
 		"the deployed machine and that port 443 on the iam public address is proxied to this "+
 		"port. This is performed by nginx in the default setup. Set to zero to disable.")
-}
\ No newline at end of file
+
+	s.UnixSocketOptions.AddFlags(fs, "insecure")
+}
@@ -2,6 +2,7 @@ package options
 
 import (
 	"errors"
+	"fmt"
 	"github.com/spf13/pflag"
 	"github.com/tiandh987/SharkAgent/pkg/db"
 	"gorm.io/gorm"
@@ -12,6 +13,9 @@ import (
 // 被项目内多个组件使用，抽象出来放到 internal/pkg/options 目录下
 
 // MySQLOptions 定义 iam 各个组件命令行、配置文件中关于 mysql 的 options
+// ConnectTimeout 是启动时等待数据库可以连接的时间，期间按指数退避重试，0 表示只尝试一次。
+// QueryTimeout 是一次存储操作的默认超时时间，请求的 context 有更早的截止时间时以请求为准，0 表示不限制。
+// Replicas 是只读从库的地址，与主库使用相同的用户名、密码和数据库，ReplicaHealthCheck 是检查从库是否可用的间隔。
 type MySQLOptions struct {
 	Host                  string        `json:"host,omitempty"                     mapstructure:"host"`
 	Username              string        `json:"username,omitempty"                 mapstructure:"username"`
@@ -21,6 +25,10 @@ type MySQLOptions struct {
 	MaxOpenConnections    int           `json:"max-open-connections,omitempty"     mapstructure:"max-open-connections"`
 	MaxConnectionLifeTime time.Duration `json:"max-connection-life-time,omitempty" mapstructure:"max-connection-life-time"`
 	LogLevel              int           `json:"log-level"                          mapstructure:"log-level"`
+	ConnectTimeout        time.Duration `json:"connect-timeout,omitempty"          mapstructure:"connect-timeout"`
+	QueryTimeout          time.Duration `json:"query-timeout,omitempty"            mapstructure:"query-timeout"`
+	Replicas              []string      `json:"replicas,omitempty"                 mapstructure:"replicas"`
+	ReplicaHealthCheck    time.Duration `json:"replica-health-check,omitempty"     mapstructure:"replica-health-check"`
 }
 
 // NewMySQLOptions create a `zero` value instance.
@@ -34,6 +42,10 @@ func NewMySQLOptions() *MySQLOptions {
 		MaxOpenConnections:    100,
 		MaxConnectionLifeTime: time.Duration(10) * time.Second,
 		LogLevel:              1, // Silent
+		ConnectTimeout:        30 * time.Second,
+		QueryTimeout:          10 * time.Second,
+		Replicas:              []string{},
+		ReplicaHealthCheck:    5 * time.Second,
 	}
 }
 
@@ -45,6 +57,27 @@ func (o *MySQLOptions) Validate() []error {
 		errs = append(errs, errors.New("[mysql] Username or Password or Database is empty"))
 	}
 
+	if o.ConnectTimeout < 0 {
+		errs = append(errs, errors.New("[mysql] --mysql.connect-timeout can not be negative"))
+	}
+
+	if o.QueryTimeout < 0 {
+		errs = append(errs, errors.New("[mysql] --mysql.query-timeout can not be negative"))
+	}
+
+	if len(o.Replicas) != 0 && o.ReplicaHealthCheck <= 0 {
+		errs = append(errs, errors.New("[mysql] --mysql.replica-health-check must be greater than 0"))
+	}
+
+	seen := map[string]bool{o.Host: true}
+	for _, replica := range o.Replicas {
+		if seen[replica] {
+			errs = append(errs, fmt.Errorf("[mysql] replica %q is duplicated or the same as --mysql.host", replica))
+		}
+
+		seen[replica] = true
+	}
+
 	return errs
 }
 
@@ -73,6 +106,20 @@ func (o *MySQLOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.IntVar(&o.LogLevel, "mysql.log-mode", o.LogLevel, ""+
 		"Specify gorm log level.")
+
+	fs.DurationVar(&o.ConnectTimeout, "mysql.connect-timeout", o.ConnectTimeout, ""+
+		"Time to keep retrying with exponential backoff when mysql can not be connected at startup. "+
+		"0 means only try once.")
+
+	fs.DurationVar(&o.QueryTimeout, "mysql.query-timeout", o.QueryTimeout, ""+
+		"Default timeout of a store operation, the earlier deadline of the request wins. 0 means no timeout.")
+
+	fs.StringSliceVar(&o.Replicas, "mysql.replicas", o.Replicas, ""+
+		"Comma separated host addresses of read-only MySQL replicas. Reads are sent to healthy replicas, "+
+		"writes and reads after a write in the same request are sent to --mysql.host.")
+
+	fs.DurationVar(&o.ReplicaHealthCheck, "mysql.replica-health-check", o.ReplicaHealthCheck, ""+
+		"Interval of checking whether the replicas are available.")
 }
 
 
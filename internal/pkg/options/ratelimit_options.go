@@ -0,0 +1,159 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
+	"github.com/tiandh987/SharkAgent/internal/pkg/server"
+)
+
+// RateLimitOptions 包含 API 服务器限流相关的配置项。
+// 路由分组（Groups）只能通过配置文件设置，例如：
+//
+//	ratelimit:
+//	  enabled: true
+//	  per-ip: { qps: 10, burst: 20 }
+//	  groups:
+//	    - route: "POST /v1/users"
+//	      global: { qps: 5, burst: 10 }
+//	      per-user: { qps: 1, burst: 2 }
+type RateLimitOptions struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+
+	RateLimitRuleOptions `json:",inline" mapstructure:",squash"`
+
+	Groups []RateLimitGroupOptions `json:"groups" mapstructure:"groups"`
+}
+
+// RateLimitRuleOptions 定义全局、按 IP、按认证用户三种令牌桶的速率。
+type RateLimitRuleOptions struct {
+	Global  LimitOptions `json:"global"   mapstructure:"global"`
+	PerIP   LimitOptions `json:"per-ip"   mapstructure:"per-ip"`
+	PerUser LimitOptions `json:"per-user" mapstructure:"per-user"`
+}
+
+// RateLimitGroupOptions 为匹配 Route（"[METHOD ]/path/prefix"）的请求覆盖默认限流规则。
+type RateLimitGroupOptions struct {
+	Route string `json:"route" mapstructure:"route"`
+
+	RateLimitRuleOptions `json:",inline" mapstructure:",squash"`
+}
+
+// LimitOptions 定义一个令牌桶的速率（qps）和容量（burst），qps 为 0 表示不限流。
+type LimitOptions struct {
+	QPS   float64 `json:"qps"   mapstructure:"qps"`
+	Burst int     `json:"burst" mapstructure:"burst"`
+}
+
+// NewRateLimitOptions creates a RateLimitOptions object with default parameters.
+func NewRateLimitOptions() *RateLimitOptions {
+	return &RateLimitOptions{
+		Enabled: false,
+		RateLimitRuleOptions: RateLimitRuleOptions{
+			Global:  LimitOptions{QPS: 1000, Burst: 2000},
+			PerIP:   LimitOptions{QPS: 50, Burst: 100},
+			PerUser: LimitOptions{QPS: 50, Burst: 100},
+		},
+	}
+}
+
+// ApplyTo applies the run options to the method receiver and returns self.
+func (o *RateLimitOptions) ApplyTo(c *server.Config) error {
+	if !o.Enabled {
+		c.RateLimit = nil
+
+		return nil
+	}
+
+	cfg := &middleware.RateLimitConfig{
+		Default: o.RateLimitRuleOptions.rule(),
+	}
+	for _, g := range o.Groups {
+		cfg.Groups = append(cfg.Groups, middleware.RateLimitGroup{
+			Route:         g.Route,
+			RateLimitRule: g.RateLimitRuleOptions.rule(),
+		})
+	}
+
+	c.RateLimit = cfg
+
+	return nil
+}
+
+func (o RateLimitRuleOptions) rule() middleware.RateLimitRule {
+	return middleware.RateLimitRule{
+		Global:  middleware.Limit{QPS: o.Global.QPS, Burst: o.Global.Burst},
+		PerIP:   middleware.Limit{QPS: o.PerIP.QPS, Burst: o.PerIP.Burst},
+		PerUser: middleware.Limit{QPS: o.PerUser.QPS, Burst: o.PerUser.Burst},
+	}
+}
+
+// Validate is used to parse and validate the parameters entered by the user at
+// the command line when the program starts.
+func (o *RateLimitOptions) Validate() []error {
+	errs := []error{}
+
+	if !o.Enabled {
+		return errs
+	}
+
+	errs = append(errs, o.RateLimitRuleOptions.validate("ratelimit")...)
+	for i, g := range o.Groups {
+		if strings.TrimSpace(g.Route) == "" {
+			errs = append(errs, fmt.Errorf("ratelimit.groups[%d].route must not be empty", i))
+		}
+
+		errs = append(errs, g.RateLimitRuleOptions.validate(fmt.Sprintf("ratelimit.groups[%d]", i))...)
+	}
+
+	return errs
+}
+
+func (o RateLimitRuleOptions) validate(prefix string) []error {
+	var errs []error
+
+	limits := []struct {
+		name  string
+		limit LimitOptions
+	}{
+		{"global", o.Global},
+		{"per-ip", o.PerIP},
+		{"per-user", o.PerUser},
+	}
+
+	for _, l := range limits {
+		if l.limit.QPS < 0 || l.limit.Burst < 0 {
+			errs = append(errs, fmt.Errorf("%s.%s qps and burst must not be negative", prefix, l.name))
+		}
+
+		if l.limit.QPS > 0 && l.limit.Burst == 0 {
+			errs = append(errs, fmt.Errorf("%s.%s.burst must be greater than 0 when qps is set", prefix, l.name))
+		}
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to rate limiting for a specific APIServer to the
+// specified FlagSet.
+func (o *RateLimitOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "ratelimit.enabled", o.Enabled, ""+
+		"Enable token bucket rate limiting. Requests over the limit are rejected with 429 Too Many Requests.")
+
+	fs.Float64Var(&o.Global.QPS, "ratelimit.global.qps", o.Global.QPS, ""+
+		"Requests per second allowed for all clients together. Set to 0 to disable.")
+	fs.IntVar(&o.Global.Burst, "ratelimit.global.burst", o.Global.Burst, ""+
+		"Maximum burst of requests allowed for all clients together.")
+
+	fs.Float64Var(&o.PerIP.QPS, "ratelimit.per-ip.qps", o.PerIP.QPS, ""+
+		"Requests per second allowed for a single client IP. Set to 0 to disable.")
+	fs.IntVar(&o.PerIP.Burst, "ratelimit.per-ip.burst", o.PerIP.Burst, ""+
+		"Maximum burst of requests allowed for a single client IP.")
+
+	fs.Float64Var(&o.PerUser.QPS, "ratelimit.per-user.qps", o.PerUser.QPS, ""+
+		"Requests per second allowed for a single authenticated user. Set to 0 to disable.")
+	fs.IntVar(&o.PerUser.Burst, "ratelimit.per-user.burst", o.PerUser.Burst, ""+
+		"Maximum burst of requests allowed for a single authenticated user.")
+}
@@ -0,0 +1,80 @@
+package options
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/pflag"
+)
+
+// RedisOptions 定义 iam 各个组件命令行、配置文件中关于 redis 的 options。
+type RedisOptions struct {
+	Host     string `json:"host"     mapstructure:"host"`
+	Port     int    `json:"port"     mapstructure:"port"`
+	Password string `json:"-"        mapstructure:"password"`
+	Database int    `json:"database" mapstructure:"database"`
+}
+
+// NewRedisOptions create a `zero` value instance.
+func NewRedisOptions() *RedisOptions {
+	return &RedisOptions{
+		Host:     "127.0.0.1",
+		Port:     6379,
+		Password: "",
+		Database: 0,
+	}
+}
+
+// Validate verifies flags passed to RedisOptions.
+func (o *RedisOptions) Validate() []error {
+	errs := []error{}
+
+	if o.Host == "" {
+		errs = append(errs, errors.New("[redis] --redis.host can not be empty"))
+	}
+
+	if o.Port <= 0 || o.Port > 65535 {
+		errs = append(errs, fmt.Errorf("[redis] --redis.port %d must be between 1 and 65535", o.Port))
+	}
+
+	if o.Database < 0 {
+		errs = append(errs, errors.New("[redis] --redis.database can not be negative"))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to redis storage for a specific APIServer to the specified FlagSet.
+func (o *RedisOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Host, "redis.host", o.Host, "Hostname of your Redis server.")
+
+	fs.IntVar(&o.Port, "redis.port", o.Port, "The port the Redis server is listening on.")
+
+	fs.StringVar(&o.Password, "redis.password", o.Password, "Optional auth password for Redis db.")
+
+	fs.IntVar(&o.Database, "redis.database", o.Database, ""+
+		"By default, the database is 0. Setting the database is not supported with redis cluster.")
+}
+
+// NewClient 使用 redis 配置创建客户端，并检查 redis 是否可以连接。
+func (o *RedisOptions) NewClient() (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", o.Host, o.Port),
+		Password: o.Password,
+		DB:       o.Database,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+
+		return nil, fmt.Errorf("connect to redis %s failed: %w", client.Options().Addr, err)
+	}
+
+	return client, nil
+}
@@ -1,9 +1,17 @@
 package options
 
 import (
+	"crypto/tls"
 	"fmt"
 	"github.com/spf13/pflag"
 	"github.com/tiandh987/SharkAgent/internal/pkg/server"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"github.com/tiandh987/SharkAgent/pkg/util/certutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 // SecureServingOptions 包含与 HTTPS 服务器启动相关的配置项。
@@ -16,6 +24,34 @@ type SecureServingOptions struct {
 	// ServerCert is the TLS cert info for serving secure traffic
 	ServerCert GeneratableKeyCert `json:"tls"          mapstructure:"tls"`
 	// AdvertiseAddress net.IP
+
+	// MinTLSVersion is the minimum TLS version supported, e.g. VersionTLS12.
+	MinTLSVersion string `json:"min-tls-version"   mapstructure:"min-tls-version"`
+	// CipherSuites is the allowed cipher suites for the server, using IANA names.
+	// If empty, Go's default cipher suites are used.
+	CipherSuites []string `json:"cipher-suites"     mapstructure:"cipher-suites"`
+	// CurvePreferences is the elliptic curves used in ECDHE handshakes, in preference order.
+	CurvePreferences []string `json:"curve-preferences" mapstructure:"curve-preferences"`
+	// ClientCAFile is a file containing PEM-encoded CA certificates used to verify client certificates.
+	ClientCAFile string `json:"client-ca-file"    mapstructure:"client-ca-file"`
+	// ClientAuth is the client certificate policy: none, request or require-and-verify.
+	ClientAuth string `json:"client-auth"       mapstructure:"client-auth"`
+
+	// UnixSocketOptions is used when BindAddress is unix:///path.sock.
+	UnixSocketOptions `json:",inline" mapstructure:",squash"`
+}
+
+// Client certificate authentication modes.
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	ClientAuthNone:             tls.NoClientCert,
+	ClientAuthRequest:          tls.VerifyClientCertIfGiven,
+	ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
 }
 
 // GeneratableKeyCert contains configuration items related to certificate.
@@ -30,6 +66,12 @@ type GeneratableKeyCert struct {
 	// PairName is the name which will be used with CertDirectory to make a cert and key filenames.
 	// It becomes CertDirectory/PairName.crt and CertDirectory/PairName.key
 	PairName string `json:"pair-name" mapstructure:"pair-name"`
+	// AlternateNames are extra hostnames or IP addresses added to the generated self-signed certificate.
+	// localhost, 127.0.0.1, ::1, the machine hostname and the bind address are always included.
+	AlternateNames []string `json:"alternate-names" mapstructure:"alternate-names"`
+	// ReloadInterval is how often the certificate files are polled for changes, in addition to
+	// file system notifications. Set to 0 to rely on file system notifications only.
+	ReloadInterval time.Duration `json:"reload-interval" mapstructure:"reload-interval"`
 }
 
 // CertKey contains configuration items related to certificate.
@@ -47,27 +89,181 @@ func NewSecureServingOptions() *SecureServingOptions {
 		BindPort:    8443,
 		Required:    true,
 		ServerCert: GeneratableKeyCert{
-			PairName:      "iam",
-			CertDirectory: "/var/run/iam",
+			PairName:       "iam",
+			CertDirectory:  "/var/run/iam",
+			ReloadInterval: time.Minute,
 		},
+		MinTLSVersion: "VersionTLS12",
+		ClientAuth:    ClientAuthNone,
 	}
 }
 
 // ApplyTo applies the run options to the method receiver and returns self.
 func (s *SecureServingOptions) ApplyTo(c *server.Config) error {
 	// SecureServing is required to serve https
-	c.SecureServing = &server.SecureServingInfo{
+	info := &server.SecureServingInfo{
 		BindAddress: s.BindAddress,
 		BindPort:    s.BindPort,
 		CertKey: server.CertKey{
 			CertFile: s.ServerCert.CertKey.CertFile,
 			KeyFile:  s.ServerCert.CertKey.KeyFile,
 		},
+		ReloadInterval: s.ServerCert.ReloadInterval,
+	}
+
+	if server.IsUnixSocket(s.BindAddress) {
+		socket, err := s.UnixSocketOptions.unixSocketInfo()
+		if err != nil {
+			return err
+		}
+
+		info.UnixSocket = socket
+	}
+
+	if info.Enabled() {
+		if err := s.ServerCert.complete(s.BindAddress, info); err != nil {
+			return err
+		}
+	}
+
+	if err := s.applyTLSTo(info); err != nil {
+		return err
+	}
+
+	c.SecureServing = info
+
+	return nil
+}
+
+// applyTLSTo 将 TLS 协议版本、密码套件、曲线和客户端证书校验相关的配置转换为 server.SecureServingInfo。
+func (s *SecureServingOptions) applyTLSTo(info *server.SecureServingInfo) error {
+	var err error
+
+	if info.MinTLSVersion, err = certutil.TLSVersion(s.MinTLSVersion); err != nil {
+		return err
+	}
+
+	if info.CipherSuites, err = certutil.CipherSuites(s.CipherSuites); err != nil {
+		return err
+	}
+
+	if info.CurvePreferences, err = certutil.CurvePreferences(s.CurvePreferences); err != nil {
+		return err
+	}
+
+	info.ClientAuth = clientAuthTypes[s.ClientAuth]
+	if len(s.ClientCAFile) != 0 {
+		if info.ClientCAs, err = certutil.NewPoolFromFile(s.ClientCAFile); err != nil {
+			return fmt.Errorf("unable to load client CA file %s: %w", s.ClientCAFile, err)
+		}
+	}
+
+	return nil
+}
+
+// complete 在没有显式指定 CertFile/KeyFile 时为 HTTPS 服务准备证书：
+// 1. 优先使用 <cert-dir>/<pair-name>.crt 和 <cert-dir>/<pair-name>.key；
+// 2. 文件不存在时，生成自签名的 CA 和服务端证书并写入 CertDirectory；
+// 3. 没有设置 CertDirectory/PairName 或写入失败时，使用仅存在于内存中的证书。
+func (g *GeneratableKeyCert) complete(bindAddress string, info *server.SecureServingInfo) error {
+	if len(g.CertKey.CertFile) != 0 || len(g.CertKey.KeyFile) != 0 {
+		return nil
+	}
+
+	var certPath, keyPath, caPath string
+	if len(g.CertDirectory) != 0 && len(g.PairName) != 0 {
+		certPath = filepath.Join(g.CertDirectory, g.PairName+".crt")
+		keyPath = filepath.Join(g.CertDirectory, g.PairName+".key")
+		caPath = filepath.Join(g.CertDirectory, g.PairName+"-ca.crt")
+
+		canRead, err := certutil.CanReadCertAndKey(certPath, keyPath)
+		if err != nil {
+			return err
+		}
+
+		if canRead {
+			log.Infof("Using TLS certificate %s and key %s", certPath, keyPath)
+			info.CertKey = server.CertKey{CertFile: certPath, KeyFile: keyPath}
+
+			return nil
+		}
+	}
+
+	alternateIPs, alternateDNS := g.alternateNames(bindAddress)
+
+	certPEM, keyPEM, caPEM, err := certutil.GenerateSelfSignedCertKey("localhost", alternateIPs, alternateDNS)
+	if err != nil {
+		return fmt.Errorf("unable to generate self signed cert: %w", err)
+	}
+
+	if len(certPath) != 0 {
+		err = writeCertKey(certPath, keyPath, caPath, certPEM, keyPEM, caPEM)
+		if err == nil {
+			log.Infof("Generated self-signed cert %s (CA %s)", certPath, caPath)
+			info.CertKey = server.CertKey{CertFile: certPath, KeyFile: keyPath}
+
+			return nil
+		}
+
+		log.Warnf("Failed to persist self-signed cert to %s, using in-memory cert instead: %s", g.CertDirectory, err.Error())
 	}
 
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("unable to load generated self signed cert: %w", err)
+	}
+
+	log.Infof("Generated in-memory self-signed cert for %v %v", alternateDNS, alternateIPs)
+	info.Certificate = &cert
+
 	return nil
 }
 
+// alternateNames 返回自签名证书需要包含的 IP 和 DNS SAN。
+func (g *GeneratableKeyCert) alternateNames(bindAddress string) ([]net.IP, []string) {
+	names := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil && len(hostname) != 0 {
+		names = append(names, hostname)
+	}
+	if ip := net.ParseIP(bindAddress); ip != nil && !ip.IsUnspecified() {
+		names = append(names, bindAddress)
+	}
+	names = append(names, g.AlternateNames...)
+
+	var (
+		ips  []net.IP
+		dns  []string
+		seen = map[string]bool{}
+	)
+
+	for _, name := range names {
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if ip := net.ParseIP(name); ip != nil {
+			ips = append(ips, ip)
+		} else {
+			dns = append(dns, name)
+		}
+	}
+
+	return ips, dns
+}
+
+func writeCertKey(certPath, keyPath, caPath string, certPEM, keyPEM, caPEM []byte) error {
+	if err := certutil.WriteCert(certPath, certPEM); err != nil {
+		return err
+	}
+
+	if err := certutil.WriteKey(keyPath, keyPEM); err != nil {
+		return err
+	}
+
+	return certutil.WriteCert(caPath, caPEM)
+}
+
 // Validate is used to parse and validate the parameters entered by the user at
 // the command line when the program starts.
 func (s *SecureServingOptions) Validate() []error {
@@ -77,7 +273,9 @@ func (s *SecureServingOptions) Validate() []error {
 
 	errors := []error{}
 
-	if s.Required && s.BindPort < 1 || s.BindPort > 65535 {
+	if server.IsUnixSocket(s.BindAddress) {
+		errors = append(errors, s.UnixSocketOptions.Validate("secure")...)
+	} else if s.Required && s.BindPort < 1 || s.BindPort > 65535 {
 		errors = append(
 			errors,
 			fmt.Errorf(
@@ -89,6 +287,34 @@ func (s *SecureServingOptions) Validate() []error {
 		errors = append(errors, fmt.Errorf("--secure.bind-port %v must be between 0 and 65535, inclusive. 0 for turning off secure port", s.BindPort))
 	}
 
+	if s.ServerCert.ReloadInterval < 0 {
+		errors = append(errors, fmt.Errorf("--secure.tls.reload-interval %v must not be negative", s.ServerCert.ReloadInterval))
+	}
+
+	if _, err := certutil.TLSVersion(s.MinTLSVersion); err != nil {
+		errors = append(errors, fmt.Errorf("--secure.min-tls-version: %w", err))
+	}
+
+	if _, err := certutil.CipherSuites(s.CipherSuites); err != nil {
+		errors = append(errors, fmt.Errorf("--secure.cipher-suites: %w", err))
+	}
+
+	if _, err := certutil.CurvePreferences(s.CurvePreferences); err != nil {
+		errors = append(errors, fmt.Errorf("--secure.curve-preferences: %w", err))
+	}
+
+	if _, ok := clientAuthTypes[s.ClientAuth]; !ok {
+		errors = append(errors, fmt.Errorf("--secure.client-auth %q must be one of %s, %s, %s",
+			s.ClientAuth, ClientAuthNone, ClientAuthRequest, ClientAuthRequireAndVerify))
+	} else if s.ClientAuth != ClientAuthNone && len(s.ClientCAFile) == 0 {
+		errors = append(errors, fmt.Errorf("--secure.client-ca-file is required when --secure.client-auth is %s", s.ClientAuth))
+	}
+
+	if (len(s.ServerCert.CertKey.CertFile) == 0) != (len(s.ServerCert.CertKey.KeyFile) == 0) {
+		errors = append(errors, fmt.Errorf("--secure.tls.cert-key.cert-file and "+
+			"--secure.tls.cert-key.private-key-file must be specified together"))
+	}
+
 	return errors
 }
 
@@ -98,7 +324,10 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.BindAddress, "secure.bind-address", s.BindAddress, ""+
 		"The IP address on which to listen for the --secure.bind-port port. The "+
 		"associated interface(s) must be reachable by the rest of the engine, and by CLI/web "+
-		"clients. If blank, all interfaces will be used (0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces).")
+		"clients. If blank, all interfaces will be used (0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces). "+
+		"Set to unix:///path.sock to serve HTTPS on a unix domain socket instead of a TCP port. "+
+		"Listeners passed by systemd socket activation (LISTEN_FDS) named 'secure' or "+
+		"bound to the same address are reused.")
 	desc := "The port on which to serve HTTPS with authentication and authorization."
 	if s.Required {
 		desc += " It cannot be switched off with 0."
@@ -107,6 +336,8 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 	}
 	fs.IntVar(&s.BindPort, "secure.bind-port", s.BindPort, desc)
 
+	s.UnixSocketOptions.AddFlags(fs, "secure")
+
 	fs.StringVar(&s.ServerCert.CertDirectory, "secure.tls.cert-dir", s.ServerCert.CertDirectory, ""+
 		"The directory where the TLS certs are located. "+
 		"If --secure.tls.cert-key.cert-file and --secure.tls.cert-key.private-key-file are provided, "+
@@ -116,6 +347,10 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		"The name which will be used with --secure.tls.cert-dir to make a cert and key filenames. "+
 		"It becomes <cert-dir>/<pair-name>.crt and <cert-dir>/<pair-name>.key")
 
+	fs.StringSliceVar(&s.ServerCert.AlternateNames, "secure.tls.alternate-names", s.ServerCert.AlternateNames, ""+
+		"Extra hostnames or IP addresses to include in the generated self-signed certificate, comma separated. "+
+		"Only used when no certificate is found in --secure.tls.cert-dir.")
+
 	fs.StringVar(&s.ServerCert.CertKey.CertFile, "secure.tls.cert-key.cert-file", s.ServerCert.CertKey.CertFile, ""+
 		"File containing the default x509 Certificate for HTTPS. (CA cert, if any, concatenated "+
 		"after server cert).")
@@ -123,4 +358,28 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServerCert.CertKey.KeyFile, "secure.tls.cert-key.private-key-file",
 		s.ServerCert.CertKey.KeyFile, ""+
 			"File containing the default x509 private key matching --secure.tls.cert-key.cert-file.")
+
+	fs.DurationVar(&s.ServerCert.ReloadInterval, "secure.tls.reload-interval", s.ServerCert.ReloadInterval, ""+
+		"How often the certificate files are checked for changes, in addition to file system notifications. "+
+		"A changed certificate is validated and swapped in without restarting the server. Set to 0 to disable polling.")
+
+	fs.StringVar(&s.MinTLSVersion, "secure.min-tls-version", s.MinTLSVersion, ""+
+		"Minimum TLS version supported. Possible values: "+strings.Join(certutil.TLSVersionNames(), ", ")+".")
+
+	fs.StringSliceVar(&s.CipherSuites, "secure.cipher-suites", s.CipherSuites, ""+
+		"Comma-separated list of cipher suites for the server, using IANA names "+
+		"(e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256). If omitted, the default Go cipher suites will be used.")
+
+	fs.StringSliceVar(&s.CurvePreferences, "secure.curve-preferences", s.CurvePreferences, ""+
+		"Comma-separated list of elliptic curves used in ECDHE handshakes, in preference order. "+
+		"Possible values: X25519, P256, P384, P521.")
+
+	fs.StringVar(&s.ClientCAFile, "secure.client-ca-file", s.ClientCAFile, ""+
+		"If set, any request presenting a client certificate signed by one of the authorities in the client-ca-file "+
+		"is authenticated with an identity corresponding to the CommonName or SAN of the client certificate.")
+
+	fs.StringVar(&s.ClientAuth, "secure.client-auth", s.ClientAuth, ""+
+		"Client certificate policy: none, request (verify the certificate if the client sends one, "+
+		"requests without a certificate are not authenticated) "+
+		"or require-and-verify (reject TLS handshakes without a valid client certificate).")
 }
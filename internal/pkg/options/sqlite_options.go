@@ -0,0 +1,90 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/tiandh987/SharkAgent/internal/pkg/logger"
+	"github.com/tiandh987/SharkAgent/pkg/db"
+	"gorm.io/gorm"
+)
+
+// sqliteJournalModes 是 sqlite 支持的 journal_mode。
+var sqliteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+// SQLiteOptions 定义命令行、配置文件中关于 sqlite 的 options，用于无法部署 MySQL 的小型环境和 CI。
+type SQLiteOptions struct {
+	Path        string        `json:"path"         mapstructure:"path"`
+	JournalMode string        `json:"journal-mode" mapstructure:"journal-mode"`
+	BusyTimeout time.Duration `json:"busy-timeout" mapstructure:"busy-timeout"`
+	LogLevel    int           `json:"log-level"    mapstructure:"log-level"`
+}
+
+// NewSQLiteOptions create a `zero` value instance.
+func NewSQLiteOptions() *SQLiteOptions {
+	return &SQLiteOptions{
+		Path:        "iam.db",
+		JournalMode: "WAL",
+		BusyTimeout: 5 * time.Second,
+		LogLevel:    1, // Silent
+	}
+}
+
+// Validate verifies flags passed to SQLiteOptions.
+func (o *SQLiteOptions) Validate() []error {
+	errs := []error{}
+
+	if o.Path == "" {
+		errs = append(errs, fmt.Errorf("--sqlite.path can not be empty"))
+	}
+
+	valid := false
+	for _, mode := range sqliteJournalModes {
+		if strings.EqualFold(o.JournalMode, mode) {
+			valid = true
+		}
+	}
+
+	if !valid {
+		errs = append(errs, fmt.Errorf("--sqlite.journal-mode %q must be one of %s",
+			o.JournalMode, strings.Join(sqliteJournalModes, ", ")))
+	}
+
+	if o.BusyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--sqlite.busy-timeout can not be negative"))
+	}
+
+	return errs
+}
+
+// AddFlags adds flags related to sqlite storage for a specific APIServer to the specified FlagSet.
+func (o *SQLiteOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Path, "sqlite.path", o.Path, ""+
+		"Path of the sqlite database file, created if it does not exist. Use :memory: for a database "+
+		"that is lost on exit.")
+
+	fs.StringVar(&o.JournalMode, "sqlite.journal-mode", o.JournalMode, ""+
+		"SQLite journal mode, one of "+strings.Join(sqliteJournalModes, ", ")+". "+
+		"WAL allows reads while a write is in progress.")
+
+	fs.DurationVar(&o.BusyTimeout, "sqlite.busy-timeout", o.BusyTimeout, ""+
+		"How long a write waits for the database lock held by another write before failing.")
+
+	fs.IntVar(&o.LogLevel, "sqlite.log-mode", o.LogLevel, ""+
+		"Specify gorm log level.")
+}
+
+// NewClient create sqlite store with the given config.
+func (o *SQLiteOptions) NewClient() (*gorm.DB, error) {
+	opts := &db.SQLiteOptions{
+		Path:        o.Path,
+		JournalMode: strings.ToUpper(o.JournalMode),
+		BusyTimeout: o.BusyTimeout,
+		LogLevel:    o.LogLevel,
+		Logger:      logger.New(o.LogLevel),
+	}
+
+	return db.NewSQLite(opts)
+}
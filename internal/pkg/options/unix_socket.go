@@ -0,0 +1,105 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/tiandh987/SharkAgent/internal/pkg/server"
+)
+
+// UnixSocketOptions 定义监听地址为 unix:///path.sock 时 socket 文件的权限和属主。
+type UnixSocketOptions struct {
+	// Mode is the octal file mode of the socket file, e.g. 0660. Empty keeps the umask default.
+	Mode string `json:"socket-mode"  mapstructure:"socket-mode"`
+	// Owner is the owner of the socket file in the form user[:group], names or numeric ids.
+	Owner string `json:"socket-owner" mapstructure:"socket-owner"`
+}
+
+// unixSocketInfo 将 UnixSocketOptions 转换为 server.UnixSocketInfo。
+func (o *UnixSocketOptions) unixSocketInfo() (*server.UnixSocketInfo, error) {
+	info := &server.UnixSocketInfo{UID: -1, GID: -1}
+
+	if len(o.Mode) != 0 {
+		mode, err := strconv.ParseUint(o.Mode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return nil, fmt.Errorf("invalid socket mode %q, must be an octal permission like 0660", o.Mode)
+		}
+
+		info.Mode = os.FileMode(mode)
+	}
+
+	if len(o.Owner) != 0 {
+		parts := strings.SplitN(o.Owner, ":", 2)
+
+		if len(parts[0]) != 0 {
+			uid, err := lookupID(parts[0], func(name string) (string, error) {
+				u, err := user.Lookup(name)
+				if err != nil {
+					return "", err
+				}
+
+				return u.Uid, nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("invalid socket owner %q: %w", o.Owner, err)
+			}
+
+			info.UID = uid
+		}
+
+		if len(parts) == 2 && len(parts[1]) != 0 {
+			gid, err := lookupID(parts[1], func(name string) (string, error) {
+				g, err := user.LookupGroup(name)
+				if err != nil {
+					return "", err
+				}
+
+				return g.Gid, nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("invalid socket group %q: %w", o.Owner, err)
+			}
+
+			info.GID = gid
+		}
+	}
+
+	return info, nil
+}
+
+// lookupID 解析数字 id，或者通过 lookup 将用户名/组名转换为 id。
+func lookupID(nameOrID string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+
+	id, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(id)
+}
+
+// Validate checks the socket file mode and owner.
+func (o *UnixSocketOptions) Validate(prefix string) []error {
+	if _, err := o.unixSocketInfo(); err != nil {
+		return []error{fmt.Errorf("--%s.socket-mode/--%s.socket-owner: %w", prefix, prefix, err)}
+	}
+
+	return nil
+}
+
+// AddFlags adds flags related to unix domain sockets with the given prefix to the specified FlagSet.
+func (o *UnixSocketOptions) AddFlags(fs *pflag.FlagSet, prefix string) {
+	fs.StringVar(&o.Mode, prefix+".socket-mode", o.Mode, ""+
+		"Octal file mode of the unix domain socket, e.g. 0660. Only used when --"+prefix+".bind-address is unix:///path.sock.")
+
+	fs.StringVar(&o.Owner, prefix+".socket-owner", o.Owner, ""+
+		"Owner of the unix domain socket in the form user[:group]. Only used when --"+prefix+
+		".bind-address is unix:///path.sock.")
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// 证书热加载的指标，通过 expvar 暴露在 /debug/vars。
+var (
+	certReloadTotal    = expvar.NewInt("tls_cert_reload_total")
+	certReloadFailures = expvar.NewInt("tls_cert_reload_failures_total")
+)
+
+// debounceDelay 用于合并证书轮换时连续产生的文件事件（例如先写证书、再写私钥）。
+const debounceDelay = 200 * time.Millisecond
+
+// certReloader 通过 tls.Config.GetCertificate 提供服务端证书，
+// 并在证书文件变化时（inotify 事件或定期轮询）重新加载，新证书校验失败时继续使用旧证书。
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	pollInterval time.Duration
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certPEM  []byte
+	keyPEM   []byte
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// newCertReloader 加载证书并返回 certReloader，初次加载失败时返回错误。
+func newCertReloader(certFile, keyFile string, pollInterval time.Duration) (*certReloader, error) {
+	r := &certReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		pollInterval: pollInterval,
+		stopCh:       make(chan struct{}),
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate。
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// reload 读取证书文件，内容发生变化且校验通过时替换当前证书。
+// 返回值表示证书是否被替换。
+func (r *certReloader) reload() (bool, error) {
+	certPEM, err := ioutil.ReadFile(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("read cert file %s: %w", r.certFile, err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("read key file %s: %w", r.keyFile, err)
+	}
+
+	r.mu.RLock()
+	unchanged := bytes.Equal(certPEM, r.certPEM) && bytes.Equal(keyPEM, r.keyPEM)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("invalid key pair %s/%s: %w", r.certFile, r.keyFile, err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false, fmt.Errorf("parse cert %s: %w", r.certFile, err)
+	}
+
+	if now := time.Now(); now.After(leaf.NotAfter) || now.Before(leaf.NotBefore) {
+		return false, fmt.Errorf("cert %s is only valid from %s to %s", r.certFile, leaf.NotBefore, leaf.NotAfter)
+	}
+
+	cert.Leaf = leaf
+
+	r.mu.Lock()
+	r.cert, r.certPEM, r.keyPEM = &cert, certPEM, keyPEM
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// tryReload 重新加载证书并记录日志和指标，失败时保留旧证书。
+func (r *certReloader) tryReload(reason string) {
+	changed, err := r.reload()
+	if err != nil {
+		certReloadFailures.Add(1)
+		log.Errorf("Failed to reload TLS certificate (%s), keep serving the previous one: %s", reason, err.Error())
+
+		return
+	}
+
+	if changed {
+		certReloadTotal.Add(1)
+		log.Infof("Reloaded TLS certificate %s (%s), expires at %s", r.certFile, reason, r.expiry())
+	}
+}
+
+func (r *certReloader) expiry() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert.Leaf.NotAfter
+}
+
+// Run 监听证书文件所在目录的变化，并定期轮询作为兜底，直到 Stop 被调用。
+// 监听目录而不是文件，是为了覆盖通过 rename/symlink 原子替换证书的场景。
+func (r *certReloader) Run() {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warnf("Failed to create TLS certificate watcher, fall back to polling every %s: %s", r.pollInterval, err.Error())
+	} else {
+		defer watcher.Close()
+
+		for _, dir := range uniqueDirs(r.certFile, r.keyFile) {
+			if err := watcher.Add(dir); err != nil {
+				log.Warnf("Failed to watch %s, fall back to polling every %s: %s", dir, r.pollInterval, err.Error())
+			}
+		}
+
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	var pollCh <-chan time.Time
+	if r.pollInterval > 0 {
+		ticker := time.NewTicker(r.pollInterval)
+		defer ticker.Stop()
+		pollCh = ticker.C
+	}
+
+	debounce := time.NewTimer(debounceDelay)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+
+				continue
+			}
+
+			log.Debugf("TLS certificate watcher event: %s", event.String())
+			debounce.Reset(debounceDelay)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+
+				continue
+			}
+
+			log.Warnf("TLS certificate watcher error: %s", err.Error())
+		case <-debounce.C:
+			r.tryReload("file changed")
+		case <-pollCh:
+			r.tryReload("periodic poll")
+		}
+	}
+}
+
+// Stop 停止监听证书文件。
+func (r *certReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+}
+
+func uniqueDirs(files ...string) []string {
+	var dirs []string
+	seen := map[string]bool{}
+
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
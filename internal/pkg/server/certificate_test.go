@@ -0,0 +1,51 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/pkg/util/certutil"
+)
+
+func writeSelfSignedCert(t *testing.T, certFile, keyFile string) {
+	certPEM, keyPEM, _, err := certutil.GenerateSelfSignedCertKey("localhost", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, certutil.WriteCert(certFile, certPEM))
+	require.NoError(t, certutil.WriteKey(keyFile, keyPEM))
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "iam.crt"), filepath.Join(dir, "iam.key")
+	writeSelfSignedCert(t, certFile, keyFile)
+
+	r, err := newCertReloader(certFile, keyFile, 0)
+	require.NoError(t, err)
+
+	first, _ := r.GetCertificate(nil)
+	require.NotNil(t, first)
+
+	// 证书和私钥不匹配时继续使用旧证书
+	certPEM, _, _, err := certutil.GenerateSelfSignedCertKey("localhost", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, certutil.WriteCert(certFile, certPEM))
+
+	r.tryReload("test")
+	current, _ := r.GetCertificate(nil)
+	assert.Same(t, first, current)
+
+	// 证书轮换后通过文件事件或轮询自动加载
+	r.pollInterval = 100 * time.Millisecond
+	go r.Run()
+	defer r.Stop()
+
+	writeSelfSignedCert(t, certFile, keyFile)
+	assert.Eventually(t, func() bool {
+		current, _ := r.GetCertificate(nil)
+
+		return current != first
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -1,7 +1,14 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
 )
 
 // Config 是用于配置 GenericAPIServer 的结构体。
@@ -11,6 +18,12 @@ type Config struct {
 	InsecureServing *InsecureServingInfo
 	SecureServing   *SecureServingInfo
 
+	// RateLimit 为 nil 时不开启限流。
+	RateLimit *middleware.RateLimitConfig
+
+	// Idempotency 为 nil 时不处理 Idempotency-Key 请求头。
+	Idempotency *middleware.IdempotencyConfig
+
 	//JWT *JwtInfo
 
 	Healthz         bool
@@ -44,7 +57,10 @@ func (c *Config) Complete() CompletedConfig {
 
 // InsecureServingInfo holds configuration of the insecure http server.
 type InsecureServingInfo struct {
+	// Address 为 host:port 或 unix:///path.sock，为空时不开启 HTTP 服务。
 	Address string
+	// UnixSocket 定义 Address 为 Unix domain socket 时 socket 文件的权限和属主。
+	UnixSocket *UnixSocketInfo
 }
 
 // ================================================================
@@ -59,9 +75,82 @@ type CertKey struct {
 
 // SecureServingInfo holds configuration of the TLS server.
 type SecureServingInfo struct {
+	// BindAddress 为 unix:///path.sock 时忽略 BindPort。
 	BindAddress string
 	BindPort    int
-	CertKey     CertKey
+	// UnixSocket 定义 BindAddress 为 Unix domain socket 时 socket 文件的权限和属主。
+	UnixSocket *UnixSocketInfo
+	CertKey    CertKey
+	// Certificate 是在内存中生成的证书，设置后优先于 CertKey 使用。
+	Certificate *tls.Certificate
+	// ReloadInterval 是轮询 CertKey 证书文件变化的间隔，为 0 时只依赖文件系统事件。
+	ReloadInterval time.Duration
+
+	// MinTLSVersion 为 0 时使用 Go 的默认值。
+	MinTLSVersion    uint16
+	CipherSuites     []uint16
+	CurvePreferences []tls.CurveID
+	// ClientCAs 用于校验客户端证书，ClientAuth 决定是否要求客户端提供证书。
+	ClientCAs  *x509.CertPool
+	ClientAuth tls.ClientAuthType
+}
+
+// TLSConfig 基于 SecureServingInfo 创建 HTTPS 服务使用的 tls.Config，不包含服务端证书。
+func (s *SecureServingInfo) TLSConfig() *tls.Config {
+	minVersion := s.MinTLSVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	return &tls.Config{
+		MinVersion:       minVersion,
+		CipherSuites:     s.CipherSuites,
+		CurvePreferences: s.CurvePreferences,
+		ClientCAs:        s.ClientCAs,
+		ClientAuth:       s.ClientAuth,
+	}
+}
+
+// HasCertificate 判断是否配置了服务端证书。
+func (s *SecureServingInfo) HasCertificate() bool {
+	return s.Certificate != nil || (s.CertKey.CertFile != "" && s.CertKey.KeyFile != "")
+}
+
+// ServingTLSConfig 返回包含服务端证书的 tls.Config，HTTPS 和 gRPC 服务使用相同的证书和客户端证书校验配置。
+// 证书来自文件时会在文件变化后自动重新加载，调用方需要在不再使用时调用 stop 停止重新加载。
+func (s *SecureServingInfo) ServingTLSConfig() (cfg *tls.Config, stop func(), err error) {
+	cfg = s.TLSConfig()
+
+	if s.Certificate != nil {
+		cfg.Certificates = []tls.Certificate{*s.Certificate}
+
+		return cfg, func() {}, nil
+	}
+
+	reloader, err := newCertReloader(s.CertKey.CertFile, s.CertKey.KeyFile, s.ReloadInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg.GetCertificate = reloader.GetCertificate
+	go reloader.Run()
+
+	return cfg, reloader.Stop, nil
+}
+
+// Address join host IP address and host port number into a address string, like: 0.0.0.0:8443.
+// Unix domain socket 地址原样返回，例如 unix:///var/run/iam/apiserver.sock。
+func (s *SecureServingInfo) Address() string {
+	if IsUnixSocket(s.BindAddress) {
+		return s.BindAddress
+	}
+
+	return net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort))
+}
+
+// Enabled 判断是否需要开启 HTTPS 服务。
+func (s *SecureServingInfo) Enabled() bool {
+	return s.BindPort != 0 || IsUnixSocket(s.BindAddress)
 }
 
 // ================================================================
@@ -81,10 +170,12 @@ func (c CompletedConfig) New() (*GenericAPIServer, error) {
 		enableMetrics:       c.EnableMetrics,
 		enableProfiling:     c.EnableProfiling,
 		middlewares:         c.Middlewares,
+		rateLimit:           c.RateLimit,
+		idempotency:         c.Idempotency,
 		Engine:              gin.New(),
 	}
 
-	//initGenericAPIServer(s)
+	initGenericAPIServer(s)
 
 	return s, nil
 }
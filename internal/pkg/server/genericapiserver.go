@@ -1,7 +1,15 @@
 package server
 
 import (
+	"context"
+	"expvar"
 	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/internal/pkg/middleware"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"golang.org/x/sync/errgroup"
 	"net/http"
 	"time"
 )
@@ -12,6 +20,14 @@ type GenericAPIServer struct {
 	mode string
 	// gin 中间件
 	middlewares []string
+	// 限流配置，为 nil 时不开启限流
+	rateLimit *middleware.RateLimitConfig
+	// 根据 rateLimit 创建的限流中间件，所有路由共用同一组令牌桶
+	rateLimiter gin.HandlerFunc
+	// 幂等配置，为 nil 时不处理 Idempotency-Key 请求头
+	idempotency *middleware.IdempotencyConfig
+	// 根据 idempotency 创建的幂等中间件
+	idempotent gin.HandlerFunc
 	// 是用于服务器关闭的超时时间。 这指定服务器正常关闭返回之前的超时。
 	ShutdownTimeout time.Duration
 
@@ -22,6 +38,8 @@ type GenericAPIServer struct {
 	secureServer *http.Server
 	// TLS 服务配置
 	SecureServingInfo *SecureServingInfo
+	// 停止证书文件热加载
+	stopCertReload func()
 
 	// 是否开启健康检查 API 接口
 	healthz bool
@@ -30,3 +48,181 @@ type GenericAPIServer struct {
 	//
 	enableProfiling bool
 }
+
+func initGenericAPIServer(s *GenericAPIServer) {
+	s.Setup()
+	s.InstallMiddlewares()
+	s.InstallAPIs()
+}
+
+// InstallAPIs 安装通用的 API。
+func (s *GenericAPIServer) InstallAPIs() {
+	// install metrics handler
+	if s.enableMetrics {
+		s.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
+	// 未匹配的路由和方法也返回标准的错误响应
+	s.HandleMethodNotAllowed = true
+	s.NoRoute(func(c *gin.Context) {
+		core.WriteResponse(c, errors.WithCode(code.ErrPageNotFound, "page not found: %s %s",
+			c.Request.Method, c.Request.URL.Path), nil)
+	})
+	s.NoMethod(func(c *gin.Context) {
+		core.WriteResponse(c, errors.WithCode(code.ErrMethodNotAllowed, "method %s is not allowed for %s",
+			c.Request.Method, c.Request.URL.Path), nil)
+	})
+}
+
+// Setup 设置 gin 的运行模式。
+func (s *GenericAPIServer) Setup() {
+	gin.SetMode(s.mode)
+}
+
+// InstallMiddlewares 安装通用的中间件。
+func (s *GenericAPIServer) InstallMiddlewares() {
+	// necessary middlewares
+	s.Use(middleware.RequestID())
+	s.Use(middleware.Context())
+	s.Use(middleware.Recovery())
+
+	// 按用户限流和按用户隔离的幂等 key 依赖认证中间件设置的用户名，因此不作为全局中间件安装，
+	// 由路由在认证之后通过 RateLimiter 和 Idempotency 安装
+	if s.rateLimit != nil {
+		s.rateLimiter = middleware.RateLimit(s.rateLimit)
+	}
+
+	if s.idempotency != nil {
+		s.idempotent = middleware.Idempotency(s.idempotency)
+	}
+
+	// install custom middlewares
+	for _, m := range s.middlewares {
+		mw, ok := middleware.Middlewares[m]
+		if !ok {
+			log.Warnf("can not find middleware: %s", m)
+
+			continue
+		}
+
+		log.Infof("install middleware: %s", m)
+		s.Use(mw)
+	}
+}
+
+// RateLimiter 返回限流中间件，没有开启限流时返回 nil。
+// 需要认证的路由应在认证中间件之后安装它，否则按用户的限流规则不会生效。
+func (s *GenericAPIServer) RateLimiter() gin.HandlerFunc {
+	return s.rateLimiter
+}
+
+// Idempotency 返回处理 Idempotency-Key 请求头的中间件，没有开启时返回 nil。
+// 它需要安装在认证中间件之后，有认证用户时 key 按用户隔离，否则按客户端 IP 隔离。
+func (s *GenericAPIServer) Idempotency() gin.HandlerFunc {
+	return s.idempotent
+}
+
+// Run 启动 HTTP 和 HTTPS 服务，直到服务被关闭。
+func (s *GenericAPIServer) Run() error {
+	var eg errgroup.Group
+
+	if s.InsecureServingInfo != nil && len(s.InsecureServingInfo.Address) != 0 {
+		ln, err := listen("insecure", s.InsecureServingInfo.Address, s.InsecureServingInfo.UnixSocket)
+		if err != nil {
+			return err
+		}
+
+		s.insecureServer = &http.Server{
+			Addr:    s.InsecureServingInfo.Address,
+			Handler: s,
+		}
+
+		eg.Go(func() error {
+			log.Infof("Start to listening the incoming requests on http address: %s", s.InsecureServingInfo.Address)
+
+			if err := s.insecureServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			log.Infof("Server on %s stopped", s.InsecureServingInfo.Address)
+
+			return nil
+		})
+	}
+
+	if s.SecureServingInfo != nil && s.SecureServingInfo.Enabled() {
+		if !s.SecureServingInfo.HasCertificate() {
+			log.Warnf("No TLS certificate configured, skip serving on https address: %s", s.SecureServingInfo.Address())
+		} else {
+			tlsConfig, stop, err := s.SecureServingInfo.ServingTLSConfig()
+			if err != nil {
+				return s.stopStarted(&eg, err)
+			}
+
+			s.stopCertReload = stop
+			s.secureServer = &http.Server{
+				Addr:      s.SecureServingInfo.Address(),
+				Handler:   s,
+				TLSConfig: tlsConfig,
+			}
+
+			ln, err := listen("secure", s.SecureServingInfo.Address(), s.SecureServingInfo.UnixSocket)
+			if err != nil {
+				return s.stopStarted(&eg, err)
+			}
+
+			eg.Go(func() error {
+				log.Infof("Start to listening the incoming requests on https address: %s", s.SecureServingInfo.Address())
+
+				if err := s.secureServer.ServeTLS(ln, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+
+				log.Infof("Server on %s stopped", s.SecureServingInfo.Address())
+
+				return nil
+			})
+		}
+	}
+
+	return eg.Wait()
+}
+
+// stopStarted 在启动 HTTPS 服务失败时关闭已经启动的 HTTP 服务和证书热加载，等待它们退出后返回 err。
+func (s *GenericAPIServer) stopStarted(eg *errgroup.Group, err error) error {
+	if s.stopCertReload != nil {
+		s.stopCertReload()
+	}
+
+	if s.insecureServer != nil {
+		_ = s.insecureServer.Close()
+	}
+
+	_ = eg.Wait()
+
+	return err
+}
+
+// Close graceful shutdown the api server.
+func (s *GenericAPIServer) Close() {
+	// The context is used to inform the server it has 10 seconds to finish
+	// the request it is currently handling
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if s.stopCertReload != nil {
+		s.stopCertReload()
+	}
+
+	if s.secureServer != nil {
+		if err := s.secureServer.Shutdown(ctx); err != nil {
+			log.Warnf("Shutdown secure server failed: %s", err.Error())
+		}
+	}
+
+	if s.insecureServer != nil {
+		if err := s.insecureServer.Shutdown(ctx); err != nil {
+			log.Warnf("Shutdown insecure server failed: %s", err.Error())
+		}
+	}
+}
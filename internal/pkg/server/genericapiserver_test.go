@@ -0,0 +1,82 @@
+package server
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+)
+
+func TestNoRouteAndNoMethod(t *testing.T) {
+	s := &GenericAPIServer{Engine: gin.New(), mode: gin.TestMode}
+	initGenericAPIServer(s)
+	s.GET("/v1/users", func(c *gin.Context) {})
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		code   int
+	}{
+		{http.MethodGet, "/not-found", http.StatusNotFound, code.ErrPageNotFound},
+		{http.MethodDelete, "/v1/users", http.StatusMethodNotAllowed, code.ErrMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		assert.Equal(t, tt.status, w.Code, tt.path)
+
+		var resp core.ErrResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, tt.code, resp.Code)
+		assert.NotEmpty(t, resp.RequestID)
+	}
+}
+
+func TestRun_SecureListenFailure(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	insecureAddress := free.Addr().String()
+	require.NoError(t, free.Close())
+
+	// 占用 HTTPS 端口，使 HTTPS 服务在 HTTP 服务启动之后监听失败
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	s := &GenericAPIServer{
+		Engine:              gin.New(),
+		InsecureServingInfo: &InsecureServingInfo{Address: insecureAddress},
+		SecureServingInfo: &SecureServingInfo{
+			BindAddress: "127.0.0.1",
+			BindPort:    occupied.Addr().(*net.TCPAddr).Port,
+			Certificate: &tls.Certificate{},
+		},
+	}
+	defer s.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the secure listener failed")
+	}
+
+	// HTTP 服务已经关闭
+	_, err = net.DialTimeout("tcp", insecureAddress, time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// UnixSocketPrefix 是 Unix domain socket 地址的前缀，例如 unix:///var/run/iam/apiserver.sock。
+const UnixSocketPrefix = "unix://"
+
+// listenFdsStart 是 systemd socket activation 协议中第一个被继承的文件描述符。
+const listenFdsStart = 3
+
+// UnixSocketInfo 定义 Unix domain socket 文件的权限和属主。
+type UnixSocketInfo struct {
+	// Mode 为 0 时不修改 socket 文件权限。
+	Mode os.FileMode
+	// UID/GID 为 -1 时不修改 socket 文件属主。
+	UID int
+	GID int
+}
+
+// IsUnixSocket 判断地址是否为 unix://path 格式。
+func IsUnixSocket(address string) bool {
+	return strings.HasPrefix(address, UnixSocketPrefix)
+}
+
+// inheritedListeners 保存通过 systemd socket activation（LISTEN_FDS）继承的 listener。
+type inheritedListeners struct {
+	once      sync.Once
+	mu        sync.Mutex
+	listeners []namedListener
+}
+
+type namedListener struct {
+	name     string
+	listener net.Listener
+}
+
+var inherited inheritedListeners
+
+// load 按照 sd_listen_fds(3) 协议解析 LISTEN_PID、LISTEN_FDS、LISTEN_FDNAMES 环境变量，
+// 并清除它们，避免被子进程再次继承。
+func (l *inheritedListeners) load() {
+	l.once.Do(func() {
+		defer func() {
+			_ = os.Unsetenv("LISTEN_PID")
+			_ = os.Unsetenv("LISTEN_FDS")
+			_ = os.Unsetenv("LISTEN_FDNAMES")
+		}()
+
+		pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+		if err != nil || pid != os.Getpid() {
+			return
+		}
+
+		nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil || nfds <= 0 {
+			return
+		}
+
+		names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+		for i := 0; i < nfds; i++ {
+			fd := listenFdsStart + i
+
+			name := ""
+			if i < len(names) {
+				name = names[i]
+			}
+
+			// net.FileListener 会复制文件描述符，原始描述符可以直接关闭
+			file := os.NewFile(uintptr(fd), name)
+			ln, err := net.FileListener(file)
+			_ = file.Close()
+
+			if err != nil {
+				log.Warnf("Ignore inherited file descriptor %d (%s): %s", fd, name, err.Error())
+
+				continue
+			}
+
+			log.Infof("Inherited listener %s (name: %q) from file descriptor %d", ln.Addr().String(), name, fd)
+			l.listeners = append(l.listeners, namedListener{name: name, listener: ln})
+		}
+	})
+}
+
+// take 返回名称为 name 或监听地址与 address 相同的继承 listener，每个 listener 只会被取出一次。
+func (l *inheritedListeners) take(name, address string) net.Listener {
+	l.load()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, nl := range l.listeners {
+		if (name != "" && nl.name == name) || sameAddress(nl.listener.Addr(), address) {
+			l.listeners = append(l.listeners[:i], l.listeners[i+1:]...)
+
+			return nl.listener
+		}
+	}
+
+	return nil
+}
+
+func sameAddress(addr net.Addr, address string) bool {
+	if IsUnixSocket(address) {
+		return addr.Network() == "unix" && addr.String() == strings.TrimPrefix(address, UnixSocketPrefix)
+	}
+
+	want, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return false
+	}
+
+	got, ok := addr.(*net.TCPAddr)
+	if !ok || got.Port != want.Port {
+		return false
+	}
+
+	if want.IP == nil || want.IP.IsUnspecified() {
+		return got.IP.IsUnspecified()
+	}
+
+	return got.IP.Equal(want.IP)
+}
+
+// listen 返回 address 上的 listener：优先使用 systemd 继承的 listener（按 name 或地址匹配），
+// 否则新建 TCP 或 Unix domain socket listener。
+func listen(name, address string, socket *UnixSocketInfo) (net.Listener, error) {
+	if ln := inherited.take(name, address); ln != nil {
+		return ln, nil
+	}
+
+	if !IsUnixSocket(address) {
+		return net.Listen("tcp", address)
+	}
+
+	path := strings.TrimPrefix(address, UnixSocketPrefix)
+
+	// 删除上次运行遗留的 socket 文件，但不删除其他类型的文件或仍在使用中的 socket
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s already exists and is not a socket", path)
+		}
+
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if socket != nil {
+		if socket.Mode != 0 {
+			if err := os.Chmod(path, socket.Mode); err != nil {
+				_ = ln.Close()
+
+				return nil, fmt.Errorf("chmod %s: %w", path, err)
+			}
+		}
+
+		if socket.UID != -1 || socket.GID != -1 {
+			if err := os.Chown(path, socket.UID, socket.GID); err != nil {
+				_ = ln.Close()
+
+				return nil, fmt.Errorf("chown %s: %w", path, err)
+			}
+		}
+	}
+
+	return ln, nil
+}
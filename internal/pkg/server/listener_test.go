@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apiserver.sock")
+	address := UnixSocketPrefix + path
+
+	ln, err := listen("insecure", address, &UnixSocketInfo{Mode: 0o600, UID: -1, GID: -1})
+	require.NoError(t, err)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	// socket 仍在使用中时不能被删除
+	_, err = listen("insecure", address, nil)
+	assert.Error(t, err)
+
+	// 遗留的 socket 文件会被删除后重新监听
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, ln.Close())
+
+	ln, err = listen("insecure", address, nil)
+	require.NoError(t, err)
+	assert.NoError(t, ln.Close())
+}
+
+func TestSameAddress(t *testing.T) {
+	tcp := &net.TCPAddr{IP: net.IPv4zero, Port: 8080}
+	assert.True(t, sameAddress(tcp, "0.0.0.0:8080"))
+	assert.True(t, sameAddress(tcp, ":8080"))
+	assert.False(t, sameAddress(tcp, "127.0.0.1:8080"))
+	assert.False(t, sameAddress(tcp, "0.0.0.0:8443"))
+
+	unix := &net.UnixAddr{Name: "/run/iam.sock", Net: "unix"}
+	assert.True(t, sameAddress(unix, "unix:///run/iam.sock"))
+	assert.False(t, sameAddress(unix, "unix:///run/other.sock"))
+}
@@ -0,0 +1,302 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+const (
+	// pageSize 是每次从事件日志中读取的最大事件数。
+	pageSize = 100
+	// incomingQueueLength 是每个 watcher 等待发送的事件队列长度，队列满时 watcher 会被关闭。
+	incomingQueueLength = 1000
+	// pumpTimeout 是每次读取事件日志的超时时间。
+	pumpTimeout = 10 * time.Second
+)
+
+// ErrShutdown 表示 Broadcaster 已经关闭。
+var ErrShutdown = errors.New("watch broadcaster is shut down")
+
+// Broadcaster 从事件日志中读取新的事件并分发给所有 watcher。
+// 写入事件日志后调用 Notify 可以立即分发，同时每隔 interval 轮询一次，
+// 用于发现其他 apiserver 实例写入的事件。读取事件日志的后台协程在第一次 Watch 或 Notify 时启动。
+type Broadcaster struct {
+	source   Source
+	interval time.Duration
+
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+	// last 是已经分发的最新 ResourceVersion
+	last        uint64
+	initialized bool
+	started     bool
+	stopped     bool
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewBroadcaster 创建一个从 source 读取事件的 Broadcaster。
+func NewBroadcaster(source Source, interval time.Duration) *Broadcaster {
+	return &Broadcaster{
+		source:   source,
+		interval: interval,
+		watchers: make(map[*watcher]struct{}),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Notify 通知 Broadcaster 事件日志中有新的事件。
+func (b *Broadcaster) Notify() {
+	b.start()
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Watch 返回 ResourceVersion 大于 since 的事件，since 为 0 时只返回之后发生的事件。
+// 调用方需要在不再使用时调用 Stop。
+func (b *Broadcaster) Watch(ctx context.Context, since uint64) (Interface, error) {
+	latest, err := b.source.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if since == 0 {
+		since = latest
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watcher{
+		b:        b,
+		incoming: make(chan Event, incomingQueueLength),
+		result:   make(chan Event),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		cancel()
+
+		return nil, ErrShutdown
+	}
+	// 先注册再补齐历史事件，保证两者之间不会遗漏事件。
+	// 后台协程还没有读取过事件日志时，从 latest 开始分发，latest 之后的事件由补齐或分发覆盖
+	if !b.initialized {
+		b.last, b.initialized = latest, true
+	}
+	b.watchers[w] = struct{}{}
+	b.mu.Unlock()
+
+	b.start()
+	go w.run(since)
+
+	return w, nil
+}
+
+// Shutdown 停止读取事件日志并关闭所有 watcher。
+func (b *Broadcaster) Shutdown() {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+
+		return
+	}
+
+	b.stopped = true
+	started := b.started
+	for w := range b.watchers {
+		b.removeLocked(w)
+	}
+	b.mu.Unlock()
+
+	close(b.stop)
+	if started {
+		<-b.done
+	}
+}
+
+func (b *Broadcaster) start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started || b.stopped {
+		return
+	}
+
+	b.started = true
+	go b.loop()
+}
+
+func (b *Broadcaster) loop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.pump()
+
+		select {
+		case <-b.notify:
+		case <-ticker.C:
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// pump 读取 last 之后的事件并分发，读取失败时等待下一次轮询。
+func (b *Broadcaster) pump() {
+	ctx, cancel := context.WithTimeout(context.Background(), pumpTimeout)
+	defer cancel()
+
+	b.mu.Lock()
+	initialized, last := b.initialized, b.last
+	b.mu.Unlock()
+
+	if !initialized {
+		latest, err := b.source.Latest(ctx)
+		if err != nil {
+			log.Warnf("Failed to read the latest watch event: %s", err.Error())
+
+			return
+		}
+
+		b.mu.Lock()
+		if !b.initialized {
+			b.last, b.initialized = latest, true
+		}
+		last = b.last
+		b.mu.Unlock()
+	}
+
+	for {
+		events, err := b.source.List(ctx, last, pageSize)
+		if err != nil {
+			log.Warnf("Failed to read watch events after %d: %s", last, err.Error())
+
+			return
+		}
+
+		b.mu.Lock()
+		for _, e := range events {
+			for w := range b.watchers {
+				select {
+				case w.incoming <- e:
+				default:
+					// watcher 消费过慢，关闭后由客户端从最后收到的版本恢复
+					b.removeLocked(w)
+				}
+			}
+
+			b.last, last = e.ResourceVersion, e.ResourceVersion
+		}
+		b.mu.Unlock()
+
+		if len(events) < pageSize {
+			return
+		}
+	}
+}
+
+// removeLocked 删除 watcher 并关闭其事件队列，调用方需要持有锁。
+func (b *Broadcaster) removeLocked(w *watcher) {
+	if _, ok := b.watchers[w]; !ok {
+		return
+	}
+
+	delete(b.watchers, w)
+	close(w.incoming)
+}
+
+// ==========================================================================
+
+type watcher struct {
+	b        *Broadcaster
+	incoming chan Event
+	result   chan Event
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *watcher) Stop() {
+	w.cancel()
+
+	w.b.mu.Lock()
+	w.b.removeLocked(w)
+	w.b.mu.Unlock()
+}
+
+func (w *watcher) ResultChan() <-chan Event {
+	return w.result
+}
+
+// run 先从事件日志中补齐 since 之后的事件，再发送 Broadcaster 分发的事件，跳过已经发送过的版本。
+func (w *watcher) run(since uint64) {
+	defer close(w.result)
+
+	last := since
+	for {
+		events, err := w.b.source.List(w.ctx, last, pageSize)
+		if err != nil {
+			log.Warnf("Failed to read watch events after %d: %s", last, err.Error())
+
+			return
+		}
+
+		for _, e := range events {
+			if !w.send(e) {
+				return
+			}
+
+			last = e.ResourceVersion
+		}
+
+		if len(events) < pageSize {
+			break
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-w.incoming:
+			if !ok {
+				return
+			}
+
+			if e.ResourceVersion <= last {
+				continue
+			}
+
+			if !w.send(e) {
+				return
+			}
+
+			last = e.ResourceVersion
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *watcher) send(e Event) bool {
+	select {
+	case w.result <- e:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
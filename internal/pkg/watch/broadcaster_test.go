@@ -0,0 +1,122 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+type fakeSource struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *fakeSource) append(t metav1.EventType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, Event{Type: t, ResourceVersion: uint64(len(s.events) + 1)})
+}
+
+func (s *fakeSource) List(ctx context.Context, since uint64, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []Event
+	for _, e := range s.events {
+		if e.ResourceVersion > since && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
+
+func (s *fakeSource) Latest(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return uint64(len(s.events)), nil
+}
+
+func receive(t *testing.T, w Interface) Event {
+	t.Helper()
+
+	select {
+	case e, ok := <-w.ResultChan():
+		require.True(t, ok, "result channel closed")
+
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+
+		return Event{}
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	source := &fakeSource{}
+	source.append(metav1.Added)
+	source.append(metav1.Modified)
+
+	b := NewBroadcaster(source, time.Hour)
+	defer b.Shutdown()
+
+	// since 为 0 时只接收之后的事件
+	live, err := b.Watch(context.Background(), 0)
+	require.NoError(t, err)
+	defer live.Stop()
+
+	// 从 resourceVersion 1 恢复时先补齐历史事件
+	resumed, err := b.Watch(context.Background(), 1)
+	require.NoError(t, err)
+	defer resumed.Stop()
+
+	assert.Equal(t, Event{Type: metav1.Modified, ResourceVersion: 2}, receive(t, resumed))
+
+	source.append(metav1.Deleted)
+	b.Notify()
+
+	assert.Equal(t, Event{Type: metav1.Deleted, ResourceVersion: 3}, receive(t, live))
+	assert.Equal(t, Event{Type: metav1.Deleted, ResourceVersion: 3}, receive(t, resumed))
+}
+
+func TestBroadcaster_Poll(t *testing.T) {
+	source := &fakeSource{}
+	b := NewBroadcaster(source, 10*time.Millisecond)
+	defer b.Shutdown()
+
+	w, err := b.Watch(context.Background(), 0)
+	require.NoError(t, err)
+	defer w.Stop()
+
+	// 其他实例写入的事件通过轮询发现
+	source.append(metav1.Added)
+	assert.Equal(t, uint64(1), receive(t, w).ResourceVersion)
+}
+
+func TestBroadcaster_Shutdown(t *testing.T) {
+	b := NewBroadcaster(&fakeSource{}, time.Hour)
+
+	w, err := b.Watch(context.Background(), 0)
+	require.NoError(t, err)
+
+	b.Shutdown()
+
+	select {
+	case _, ok := <-w.ResultChan():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("result channel not closed after shutdown")
+	}
+
+	w.Stop()
+
+	_, err = b.Watch(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrShutdown)
+}
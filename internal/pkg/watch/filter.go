@@ -0,0 +1,57 @@
+package watch
+
+import "sync"
+
+// FilterFunc 修改事件，返回 false 时丢弃该事件。
+type FilterFunc func(in Event) (out Event, keep bool)
+
+// Filter 返回一个使用 f 处理 w 中每个事件的 watcher，Stop 时同时停止 w。
+func Filter(w Interface, f FilterFunc) Interface {
+	fw := &filteredWatch{
+		incoming: w,
+		result:   make(chan Event),
+		stop:     make(chan struct{}),
+		f:        f,
+	}
+	go fw.loop()
+
+	return fw
+}
+
+type filteredWatch struct {
+	incoming Interface
+	result   chan Event
+	stop     chan struct{}
+	stopOnce sync.Once
+	f        FilterFunc
+}
+
+// ResultChan returns a channel which will receive filtered events.
+func (fw *filteredWatch) ResultChan() <-chan Event {
+	return fw.result
+}
+
+// Stop stops the upstream watch, which will eventually stop this watch.
+func (fw *filteredWatch) Stop() {
+	fw.stopOnce.Do(func() {
+		close(fw.stop)
+		fw.incoming.Stop()
+	})
+}
+
+func (fw *filteredWatch) loop() {
+	defer close(fw.result)
+
+	for event := range fw.incoming.ResultChan() {
+		event, keep := fw.f(event)
+		if !keep {
+			continue
+		}
+
+		select {
+		case fw.result <- event:
+		case <-fw.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+func TestFilter(t *testing.T) {
+	source := &fakeSource{}
+	b := NewBroadcaster(source, time.Hour)
+	defer b.Shutdown()
+
+	upstream, err := b.Watch(context.Background(), 0)
+	require.NoError(t, err)
+
+	w := Filter(upstream, func(in Event) (Event, bool) {
+		in.Object = []byte(`{}`)
+
+		return in, in.Type != metav1.Modified
+	})
+
+	source.append(metav1.Added)
+	source.append(metav1.Modified)
+	source.append(metav1.Deleted)
+	b.Notify()
+
+	e := receive(t, w)
+	assert.Equal(t, metav1.Added, e.Type)
+	assert.JSONEq(t, `{}`, string(e.Object))
+	assert.Equal(t, metav1.Deleted, receive(t, w).Type)
+
+	w.Stop()
+	w.Stop()
+	for range w.ResultChan() {
+	}
+}
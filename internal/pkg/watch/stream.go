@@ -0,0 +1,133 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/pkg/core"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// watch 事件流支持的媒体类型。
+const (
+	MIMEEventStream = "text/event-stream"
+	MIMENDJSON      = "application/x-ndjson"
+)
+
+// HeaderLastEventID 是 SSE 客户端断线重连时携带的最后一个事件的 id，即 resourceVersion。
+const HeaderLastEventID = "Last-Event-ID"
+
+// heartbeatInterval 指定 SSE 连接空闲多久后发送一次注释行，避免被代理断开。
+const heartbeatInterval = 15 * time.Second
+
+// ResumeToken 返回 watch 请求的恢复令牌，优先使用 resourceVersion 参数，其次使用 Last-Event-ID 请求头。
+func ResumeToken(c *gin.Context, resourceVersion string) string {
+	if resourceVersion != "" {
+		return resourceVersion
+	}
+
+	return c.GetHeader(HeaderLastEventID)
+}
+
+// StreamEvents 将 w 中的事件写入响应，直到客户端断开连接、超时（timeout 为 0 时不超时）或 w 被关闭。
+// Accept 优先接受 text/event-stream 时使用 Server-Sent Events，否则每行写入一个 JSON 编码的 metav1.WatchEvent。
+// 每个事件的 resourceVersion 都可以作为恢复令牌，w 被关闭后客户端需要使用最后收到的 resourceVersion 重新 watch。
+func StreamEvents(c *gin.Context, w Interface, timeout time.Duration) {
+	defer w.Stop()
+
+	sse := prefersEventStream(c.GetHeader("Accept"))
+
+	contentType := MIMENDJSON
+	if sse {
+		contentType = MIMEEventStream
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+
+			if err := writeEvent(c, e, sse); err != nil {
+				log.L(c).Warnf("Failed to write watch event %d: %s", e.ResourceVersion, err.Error())
+
+				return
+			}
+		case <-heartbeat.C:
+			if sse {
+				if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+					return
+				}
+
+				c.Writer.Flush()
+			}
+		case <-timeoutCh:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, e Event, sse bool) error {
+	rv := strconv.FormatUint(e.ResourceVersion, 10)
+
+	data, err := json.Marshal(&metav1.WatchEvent{
+		Type:            e.Type,
+		ResourceVersion: rv,
+		Object:          e.Object,
+	})
+	if err != nil {
+		return err
+	}
+
+	if sse {
+		_, err = fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", rv, e.Type, data)
+	} else {
+		_, err = c.Writer.Write(append(data, '\n'))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.Writer.Flush()
+
+	return nil
+}
+
+// prefersEventStream 判断客户端是否优先接受 text/event-stream。
+func prefersEventStream(accept string) bool {
+	for _, candidate := range core.ParseAccept(accept) {
+		switch candidate {
+		case MIMEEventStream:
+			return true
+		case MIMENDJSON, core.MIMEJSON, "*/*", "application/*":
+			return false
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+type fakeWatcher struct {
+	result chan Event
+}
+
+func newFakeWatcher(events ...Event) *fakeWatcher {
+	w := &fakeWatcher{result: make(chan Event, len(events))}
+	for _, e := range events {
+		w.result <- e
+	}
+	close(w.result)
+
+	return w
+}
+
+func (w *fakeWatcher) Stop()                    {}
+func (w *fakeWatcher) ResultChan() <-chan Event { return w.result }
+
+func newTestContext(accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+
+	return c, w
+}
+
+func TestStreamEvents(t *testing.T) {
+	events := []Event{
+		{Type: metav1.Added, ResourceVersion: 1, Object: json.RawMessage(`{"name":"a"}`)},
+		{Type: metav1.Deleted, ResourceVersion: 2, Object: json.RawMessage(`{"name":"a"}`)},
+	}
+
+	t.Run("json lines", func(t *testing.T) {
+		c, w := newTestContext("")
+		StreamEvents(c, newFakeWatcher(events...), 0)
+
+		assert.Equal(t, MIMENDJSON, w.Header().Get("Content-Type"))
+
+		scanner := bufio.NewScanner(w.Body)
+		var got []metav1.WatchEvent
+		for scanner.Scan() {
+			var e metav1.WatchEvent
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+			got = append(got, e)
+		}
+
+		require.Len(t, got, 2)
+		assert.Equal(t, metav1.Added, got[0].Type)
+		assert.Equal(t, "2", got[1].ResourceVersion)
+		assert.Equal(t, map[string]interface{}{"name": "a"}, got[1].Object)
+	})
+
+	t.Run("server-sent events", func(t *testing.T) {
+		c, w := newTestContext(MIMEEventStream)
+		StreamEvents(c, newFakeWatcher(events...), 0)
+
+		assert.Equal(t, MIMEEventStream, w.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(w.Body.String(), "id: 1\nevent: ADDED\ndata: {"))
+		assert.Contains(t, w.Body.String(), "id: 2\nevent: DELETED\n")
+	})
+
+	t.Run("client disconnected", func(t *testing.T) {
+		c, w := newTestContext("")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		StreamEvents(c, &fakeWatcher{result: make(chan Event)}, 0)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestResumeToken(t *testing.T) {
+	c, _ := newTestContext("")
+	c.Request.Header.Set(HeaderLastEventID, "7")
+
+	assert.Equal(t, "7", ResumeToken(c, ""))
+	assert.Equal(t, "9", ResumeToken(c, "9"))
+}
@@ -0,0 +1,37 @@
+// Package watch 实现了资源变更事件的广播，事件从持久化的事件日志（outbox 表）中读取，
+// watcher 可以通过 resourceVersion 从任意位置恢复。
+package watch
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "github.com/tiandh987/SharkAgent/pkg/meta/v1"
+)
+
+// Event 是一个资源变更事件。
+type Event struct {
+	Type metav1.EventType
+	// ResourceVersion 是事件在事件日志中的序号，单调递增。
+	ResourceVersion uint64
+	// Object 是 JSON 编码的资源。
+	Object json.RawMessage
+}
+
+// Interface 可以被任何知道如何 watch 并报告变更的对象实现。
+type Interface interface {
+	// Stop 停止 watch，关闭 ResultChan 返回的 channel，可以重复调用。
+	Stop()
+
+	// ResultChan 返回接收事件的 channel。watcher 消费过慢、事件日志读取失败
+	// 或 Broadcaster 关闭时 channel 会被关闭，客户端需要使用最后收到的 resourceVersion 重新 watch。
+	ResultChan() <-chan Event
+}
+
+// Source 按 ResourceVersion 顺序读取事件日志。
+type Source interface {
+	// List 返回 ResourceVersion 大于 since 的最多 limit 个事件，按 ResourceVersion 升序排列。
+	List(ctx context.Context, since uint64, limit int) ([]Event, error)
+	// Latest 返回事件日志中最新的 ResourceVersion，没有事件时返回 0。
+	Latest(ctx context.Context) (uint64, error)
+}
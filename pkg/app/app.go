@@ -97,6 +97,7 @@ type Option func(*App)
 // 6. func WithValidArgs(args cobra.PositionalArgs) Option
 // 7. func WithDefaultValidArgs() Option
 // 8. func WithRunFunc(run RunFunc) Option
+// 9. func WithCommands(cmds ...*Command) Option
 
 // WithDescription 用于设置应用程序的描述信息
 func WithDescription(desc string) Option {
@@ -164,6 +165,13 @@ func WithRunFunc(run RunFunc) Option {
 	}
 }
 
+// WithCommands 用于添加应用程序的子命令
+func WithCommands(cmds ...*Command) Option {
+	return func(a *App) {
+		a.commands = append(a.commands, cmds...)
+	}
+}
+
 // ===============================
 
 // NewApp 基于给定的 应用名称、二进制名称、其他选项 创建一个应用程序实例
@@ -217,6 +225,11 @@ func (a *App) buildCommand() {
 	//
 	cliflag.InitFlags(cmd.Flags())
 
+	// 重命名过的 flag 仍然可以使用旧名称
+	if aliasedOptions, ok := a.options.(AliasedOptions); ok {
+		cmd.Flags().SetNormalizeFunc(cliflag.AliasNormalizeFunc(aliasedOptions.FlagAliases()))
+	}
+
 	// 附加命令
 	if len(a.commands) > 0 {
 		for _, command := range a.commands {
@@ -366,17 +379,30 @@ func addCmdTemplate(cmd *cobra.Command, namedFlagSets cliflag.NamedFlagSets) {
 	// 获取终端宽度、高度
 	cols, _, _ := term.TerminalSize(cmd.OutOrStdout())
 
+	// 子命令会继承 Usage、Help，没有自定义的子命令使用 cobra 默认的实现
+	defaultUsage, defaultHelp := (&cobra.Command{}).UsageFunc(), (&cobra.Command{}).HelpFunc()
+
 	// 自定义 Usage
-	cmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Fprintf(cmd.OutOrStderr(), usageFmt, cmd.UseLine())
-		cliflag.PrintSections(cmd.OutOrStderr(), namedFlagSets, cols)
+	cmd.SetUsageFunc(func(c *cobra.Command) error {
+		if c != cmd {
+			return defaultUsage(c)
+		}
+
+		fmt.Fprintf(c.OutOrStderr(), usageFmt, c.UseLine())
+		cliflag.PrintSections(c.OutOrStderr(), namedFlagSets, cols)
 
 		return nil
 	})
 
 	// 自定义 Help
-	cmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
-		fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n"+usageFmt, cmd.Long, cmd.UseLine())
-		cliflag.PrintSections(cmd.OutOrStdout(), namedFlagSets, cols)
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		if c != cmd {
+			defaultHelp(c, args)
+
+			return
+		}
+
+		fmt.Fprintf(c.OutOrStdout(), "%s\n\n"+usageFmt, c.Long, c.UseLine())
+		cliflag.PrintSections(c.OutOrStdout(), namedFlagSets, cols)
 	})
 }
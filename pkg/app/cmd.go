@@ -3,7 +3,10 @@ package app
 import (
 	"fmt"
 	"github.com/fatih/color"
+	"github.com/marmotedu/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 	"os"
 	"runtime"
 	"strings"
@@ -19,12 +22,55 @@ type Command struct {
 	runFunc  RunCommandFunc
 }
 
-// 1. func (c *Command) cobraCommand() *cobra.Command
-// 2.
+// 1. func NewCommand(usage string, desc string, opts ...CommandOption) *Command
+// 2. func (c *Command) AddCommand(cmd *Command)
+// 3. func (c *Command) AddCommands(cmds ...*Command)
+// 4. func (c *Command) cobraCommand() *cobra.Command
 
 // RunCommandFunc 定义应用程序的命令的启动回调函数
 type RunCommandFunc func(args []string) error
 
+// CommandOption 定义用于初始化命令结构的可选参数。
+type CommandOption func(*Command)
+
+// WithCommandOptions 设置命令的选项，命令运行前从配置文件和命令行读取并检查。
+func WithCommandOptions(opt CliOptions) CommandOption {
+	return func(c *Command) {
+		c.options = opt
+	}
+}
+
+// WithCommandRunFunc 用于设置命令的启动回调函数
+func WithCommandRunFunc(run RunCommandFunc) CommandOption {
+	return func(c *Command) {
+		c.runFunc = run
+	}
+}
+
+// NewCommand 基于给定的 用法、描述、其他选项 创建一个命令实例
+func NewCommand(usage string, desc string, opts ...CommandOption) *Command {
+	c := &Command{
+		usage: usage,
+		desc:  desc,
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+// AddCommand 添加子命令
+func (c *Command) AddCommand(cmd *Command) {
+	c.commands = append(c.commands, cmd)
+}
+
+// AddCommands 添加多个子命令
+func (c *Command) AddCommands(cmds ...*Command) {
+	c.commands = append(c.commands, cmds...)
+}
+
 //
 func (c *Command) cobraCommand() *cobra.Command {
 	cmd := &cobra.Command {
@@ -46,17 +92,37 @@ func (c *Command) cobraCommand() *cobra.Command {
 	}
 
 	if c.options != nil {
-		for _, f := range c.options.Flags().FlagSets {
+		namedFlagSets := c.options.Flags()
+
+		// 与应用程序使用同一个配置文件
+		if f := pflag.Lookup(configFlagName); f != nil {
+			namedFlagSets.FlagSet("global").AddFlag(f)
+		}
+
+		addHelpCommandFlag(c.usage, namedFlagSets.FlagSet("global"))
+
+		for _, f := range namedFlagSets.FlagSets {
 			cmd.Flags().AddFlagSet(f)
 		}
-	}
 
-	addHelpCommandFlag(c.usage, cmd.Flags())
+		// 按 FlagSet 分组显示命令的选项
+		addCmdTemplate(cmd, namedFlagSets)
+	} else {
+		addHelpCommandFlag(c.usage, cmd.Flags())
+		cmd.SetUsageTemplate(usageTemplate)
+	}
 
 	return cmd
 }
 
 func (c *Command) runCommand(cmd *cobra.Command, args []string) {
+	if c.options != nil {
+		if err := c.applyOptions(cmd); err != nil {
+			fmt.Printf("%v %v\n", color.RedString("Error:"), err)
+			os.Exit(1)
+		}
+	}
+
 	if c.runFunc != nil {
 		if err := c.runFunc(args); err != nil {
 			fmt.Printf("%v %v\n", color.RedString("Error:"), err)
@@ -65,6 +131,29 @@ func (c *Command) runCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// applyOptions 将配置文件中的配置项和命令行参数绑定到命令的选项，并检查选项。
+func (c *Command) applyOptions(cmd *cobra.Command) error {
+	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+		return err
+	}
+
+	if err := viper.Unmarshal(c.options); err != nil {
+		return err
+	}
+
+	if completeableOptions, ok := c.options.(CompleteableOptions); ok {
+		if err := completeableOptions.Complete(); err != nil {
+			return err
+		}
+	}
+
+	if errs := c.options.Validate(); len(errs) != 0 {
+		return errors.NewAggregate(errs)
+	}
+
+	return nil
+}
+
 // FormatBaseName 基于给定的 basenema，在不同的操作系统下格式化为不同的可执行文件名
 func FormatBaseName(basename string) string {
 	if runtime.GOOS == "windows" {
@@ -26,4 +26,12 @@ type CompleteableOptions interface {
 // PrintableOptions abstracts options which can be printed.
 type PrintableOptions interface {
 	String() string
-}
\ No newline at end of file
+}
+
+// AliasedOptions abstracts options which renamed some flags but still accept
+// the old names.
+type AliasedOptions interface {
+	// FlagAliases returns the deprecated flag names and the flags they are
+	// translated to.
+	FlagAliases() map[string]string
+}
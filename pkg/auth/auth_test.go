@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptCompare(t *testing.T) {
+	hashed, err := Encrypt("Admin@2021")
+	require.NoError(t, err)
+	assert.NotEqual(t, "Admin@2021", hashed)
+
+	assert.NoError(t, Compare(hashed, "Admin@2021"))
+	assert.Error(t, Compare(hashed, "admin@2021"))
+	assert.Error(t, Compare("not a hash", "Admin@2021"))
+}
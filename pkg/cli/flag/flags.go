@@ -2,8 +2,10 @@ package flag
 
 import (
 	goflag "flag"
+	"fmt"
 	"github.com/spf13/pflag"
 	"github.com/tiandh987/SharkAgent/pkg/log"
+	"os"
 	"strings"
 )
 
@@ -15,6 +17,22 @@ func WordSepNormalizeFunc(f *pflag.FlagSet, name string) pflag.NormalizedName {
 	return pflag.NormalizedName(name)
 }
 
+// AliasNormalizeFunc 在 WordSepNormalizeFunc 的基础上，将 aliases 中已废弃的 flag 名称翻译为新的名称，
+// 用于重命名 flag 后保持旧名称可用。使用旧名称时在标准错误中输出提示。
+// 旧名称不会注册为 flag，因此不会与新名称一起被绑定为配置项。
+func AliasNormalizeFunc(aliases map[string]string) func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	return func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		name = string(WordSepNormalizeFunc(f, name))
+		if to, ok := aliases[name]; ok {
+			fmt.Fprintf(os.Stderr, "Flag --%s has been deprecated, use --%s instead\n", name, to)
+
+			return pflag.NormalizedName(to)
+		}
+
+		return pflag.NormalizedName(name)
+	}
+}
+
 // InitFlags 规范化，解析，然后记录命令行 flags。
 // 1. SetNormalizeFunc 允许您添加一个可以 "翻译Flag名称" 的函数。
 // 添加到 FlagSet 的 flag 将被翻译，然后当尝试查找 flag 时也将被翻译的。
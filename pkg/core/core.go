@@ -1,10 +1,16 @@
 package core
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/marmotedu/errors"
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
 	"github.com/tiandh987/SharkAgent/pkg/log"
-	"net/http"
+	"google.golang.org/protobuf/proto"
 )
 
 // ErrResponse 定义发生错误时的返回消息。
@@ -20,23 +26,105 @@ type ErrResponse struct {
 
 	// Reference returns the reference document which maybe useful to solve this error.
 	Reference string `json:"reference,omitempty"`
+
+	// RequestID is the X-Request-ID of the request, used to correlate the response with server logs.
+	RequestID string `json:"requestID,omitempty"`
 }
 
 // WriteResponse 将 错误 或 响应数据 写入 http 响应正文。
 // 它使用 errors.ParseCoder 将任何错误解析为 errors.Coder
 // errors.Coder 包含 错误代码、用户安全错误消息 和 http 状态代码。
+// 响应格式由 Accept 请求头决定，支持 JSON、YAML 和 protobuf，默认使用 JSON；
+// 客户端接受 application/problem+json 时，错误以 RFC 7807 格式返回。
 func WriteResponse(c *gin.Context, err error, data interface{}) {
 	if err != nil {
-		log.Errorf("%#+v", err)
+		log.L(c).Errorf("%#+v", err)
 		coder := errors.ParseCoder(err)
-		c.JSON(coder.HTTPStatus(), ErrResponse{
-			Code:      coder.Code(),
-			Message:   coder.String(),
-			Reference: coder.Reference(),
-		})
+
+		if acceptsProblem(c.GetHeader("Accept")) {
+			writeProblem(c, coder, err)
+
+			return
+		}
+
+		render(c, coder.HTTPStatus(), newErrResponse(c, coder))
 
 		return
 	}
 
-	c.JSON(http.StatusOK, data)
-}
\ No newline at end of file
+	render(c, http.StatusOK, data)
+}
+
+func newErrResponse(c *gin.Context, coder errors.Coder) *ErrResponse {
+	return &ErrResponse{
+		Code:      coder.Code(),
+		Message:   coder.String(),
+		Reference: coder.Reference(),
+		RequestID: c.GetString(log.KeyRequestID),
+	}
+}
+
+// render 按照 Accept 请求头协商的格式写入响应。无法满足 Accept 时返回 406，编码失败时返回 500。
+func render(c *gin.Context, status int, obj interface{}) {
+	mediaType, ok := negotiate(c.GetHeader("Accept"), obj)
+	if !ok {
+		renderError(c, errors.WithCode(code.ErrNotAcceptable, "unsupported Accept %q, supported: %s",
+			c.GetHeader("Accept"), strings.Join(supportedMediaTypes(obj), ", ")))
+
+		return
+	}
+
+	if mediaType == MIMEJSON {
+		c.JSON(status, obj)
+
+		return
+	}
+
+	body, err := encode(mediaType, obj)
+	if err != nil {
+		renderError(c, err)
+
+		return
+	}
+
+	c.Data(status, mediaType, body)
+}
+
+// renderError 在无法按照协商的格式写入响应时，使用 JSON 或 problem+json 格式写入错误。
+func renderError(c *gin.Context, err error) {
+	log.L(c).Errorf("%#+v", err)
+	coder := errors.ParseCoder(err)
+
+	if acceptsProblem(c.GetHeader("Accept")) {
+		writeProblem(c, coder, err)
+
+		return
+	}
+
+	c.JSON(coder.HTTPStatus(), newErrResponse(c, coder))
+}
+
+// ToProto converts the error response to its protobuf representation.
+func (e *ErrResponse) ToProto() proto.Message {
+	return &pbv1.ErrResponse{
+		Code:      int64(e.Code),
+		Message:   e.Message,
+		Reference: e.Reference,
+		RequestId: e.RequestID,
+	}
+}
+
+// FromProto fills the error response with the protobuf message.
+func (e *ErrResponse) FromProto(msg proto.Message) error {
+	pb, ok := msg.(*pbv1.ErrResponse)
+	if !ok {
+		return fmt.Errorf("unexpected protobuf message %T, want *v1.ErrResponse", msg)
+	}
+
+	e.Code = int(pb.GetCode())
+	e.Message = pb.GetMessage()
+	e.Reference = pb.GetReference()
+	e.RequestID = pb.GetRequestId()
+
+	return nil
+}
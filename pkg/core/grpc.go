@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/marmotedu/errors"
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCError 将 errors.Coder 错误转换为 gRPC status 错误。
+// gRPC 状态码由错误码的 HTTP 状态码映射得到，status details 中包含与 REST API 相同的 ErrResponse。
+func GRPCError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	log.L(ctx).Errorf("%#+v", err)
+	coder := errors.ParseCoder(err)
+
+	st := status.New(GRPCCode(coder.HTTPStatus()), coder.String())
+	if detailed, derr := st.WithDetails(&pbv1.ErrResponse{
+		Code:      int64(coder.Code()),
+		Message:   coder.String(),
+		Reference: coder.Reference(),
+	}); derr == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// GRPCCode 将 HTTP 状态码映射为 gRPC 状态码。
+func GRPCCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusMethodNotAllowed, http.StatusNotAcceptable, http.StatusUnsupportedMediaType:
+		return codes.Unimplemented
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pbv1 "github.com/tiandh987/SharkAgent/api/proto/apiserver/v1"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCError(t *testing.T) {
+	assert.NoError(t, GRPCError(context.Background(), nil))
+
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"not found", errors.WithCode(code.ErrUserNotFound, "user foo"), codes.NotFound},
+		{"precondition", errors.WithCode(code.ErrPreconditionFailed, "modified"), codes.FailedPrecondition},
+		{"database", errors.WithCode(code.ErrDatabase, "connection refused"), codes.Internal},
+		{"unavailable", errors.WithCode(code.ErrDatabaseUnavailable, "connection refused"), codes.Unavailable},
+		{"timeout", errors.WithCode(code.ErrDatabaseTimeout, "context deadline exceeded"), codes.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(GRPCError(context.Background(), tt.err))
+			require.True(t, ok)
+			assert.Equal(t, tt.want, st.Code())
+
+			coder := errors.ParseCoder(tt.err)
+			assert.Equal(t, coder.String(), st.Message())
+			require.Len(t, st.Details(), 1)
+			detail, ok := st.Details()[0].(*pbv1.ErrResponse)
+			require.True(t, ok)
+			assert.Equal(t, int64(coder.Code()), detail.GetCode())
+		})
+	}
+}
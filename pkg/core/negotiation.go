@@ -0,0 +1,313 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// 支持的媒体类型。
+const (
+	MIMEJSON     = "application/json"
+	MIMEYAML     = "application/yaml"
+	MIMEProtobuf = "application/x-protobuf"
+)
+
+// mediaTypeAliases 将媒体类型的别名映射到支持的媒体类型。
+var mediaTypeAliases = map[string]string{
+	MIMEJSON:                          MIMEJSON,
+	MIMEYAML:                          MIMEYAML,
+	"application/x-yaml":              MIMEYAML,
+	"text/yaml":                       MIMEYAML,
+	"text/x-yaml":                     MIMEYAML,
+	MIMEProtobuf:                      MIMEProtobuf,
+	"application/protobuf":            MIMEProtobuf,
+	"application/vnd.google.protobuf": MIMEProtobuf,
+}
+
+// ProtoConverter 由可以与 protobuf 消息相互转换的 API 类型实现，
+// 只有实现了该接口的类型才支持 application/x-protobuf 编解码。
+type ProtoConverter interface {
+	// ToProto 返回对应的 protobuf 消息。
+	ToProto() proto.Message
+	// FromProto 从 protobuf 消息中读取字段。
+	FromProto(msg proto.Message) error
+}
+
+// Bind 根据请求的 Content-Type 将请求体解码到 obj 中，支持 JSON、YAML 和 protobuf。
+// 没有 Content-Type 时按 JSON 解码。返回的错误都带有错误码，可以直接传给 WriteResponse。
+func Bind(c *gin.Context, obj interface{}) error {
+	contentType := c.ContentType()
+
+	mediaType := mediaTypeAliases[contentType]
+	if contentType == "" {
+		mediaType = MIMEJSON
+	}
+
+	switch mediaType {
+	case MIMEJSON:
+		if err := c.ShouldBindJSON(obj); err != nil {
+			return errors.WithCode(code.ErrBind, err.Error())
+		}
+
+		return nil
+	case MIMEYAML:
+		return bindYAML(c, obj)
+	case MIMEProtobuf:
+		if pc, ok := obj.(ProtoConverter); ok {
+			return bindProtobuf(c, pc)
+		}
+	}
+
+	return errors.WithCode(code.ErrUnsupportedMediaType, "unsupported Content-Type %q, supported: %s",
+		contentType, strings.Join(supportedMediaTypes(obj), ", "))
+}
+
+func bindYAML(c *gin.Context, obj interface{}) error {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	// 先转换为 JSON，这样 YAML 和 JSON 使用相同的字段名（json 标签）
+	data, err := yamlToJSON(body)
+	if err != nil {
+		return errors.WithCode(code.ErrInvalidYaml, err.Error())
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return errors.WithCode(code.ErrDecodingYaml, err.Error())
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	return nil
+}
+
+func bindProtobuf(c *gin.Context, obj ProtoConverter) error {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	msg := obj.ToProto().ProtoReflect().New().Interface()
+	if err := proto.Unmarshal(body, msg); err != nil {
+		return errors.WithCode(code.ErrBind, "invalid protobuf: %s", err.Error())
+	}
+
+	if err := obj.FromProto(msg); err != nil {
+		return errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	return nil
+}
+
+// negotiate 根据 Accept 请求头选择响应的媒体类型，返回响应的 Content-Type。
+// 没有 Accept 或者接受任意类型时使用 JSON；protobuf 只用于实现了 ProtoConverter 的响应。
+func negotiate(accept string, obj interface{}) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MIMEJSON, true
+	}
+
+	for _, candidate := range ParseAccept(accept) {
+		switch candidate {
+		case "*/*", "application/*":
+			return MIMEJSON, true
+		}
+
+		switch mediaTypeAliases[candidate] {
+		case MIMEJSON, MIMEYAML:
+			return candidate, true
+		case MIMEProtobuf:
+			if obj == nil || protoConverter(obj) != nil {
+				return candidate, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// ParseAccept 解析 Accept 请求头，按 q 值从高到低返回媒体类型，忽略 q=0 的媒体类型。
+func ParseAccept(accept string) []string {
+	type weighted struct {
+		mediaType string
+		q         float64
+	}
+
+	var candidates []weighted
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q > 0 {
+			candidates = append(candidates, weighted{mediaType: mediaType, q: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	mediaTypes := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		mediaTypes = append(mediaTypes, c.mediaType)
+	}
+
+	return mediaTypes
+}
+
+// protoConverter 返回 obj 对应的 ProtoConverter，obj 为结构体值时尝试使用其指针。
+func protoConverter(obj interface{}) ProtoConverter {
+	if pc, ok := obj.(ProtoConverter); ok {
+		return pc
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	pc, _ := ptr.Interface().(ProtoConverter)
+
+	return pc
+}
+
+// encode 将 obj 编码为 mediaType 对应的格式。
+func encode(mediaType string, obj interface{}) ([]byte, error) {
+	switch mediaTypeAliases[mediaType] {
+	case MIMEYAML:
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, errors.WithCode(code.ErrEncodingJSON, err.Error())
+		}
+
+		out, err := jsonToYAML(data)
+		if err != nil {
+			return nil, errors.WithCode(code.ErrEncodingYaml, err.Error())
+		}
+
+		return out, nil
+	case MIMEProtobuf:
+		if obj == nil {
+			return nil, nil
+		}
+
+		out, err := proto.Marshal(protoConverter(obj).ToProto())
+		if err != nil {
+			return nil, errors.WithCode(code.ErrEncodingProtobuf, err.Error())
+		}
+
+		return out, nil
+	default:
+		out, err := json.Marshal(obj)
+		if err != nil {
+			return nil, errors.WithCode(code.ErrEncodingJSON, err.Error())
+		}
+
+		return out, nil
+	}
+}
+
+func supportedMediaTypes(obj interface{}) []string {
+	if obj == nil || protoConverter(obj) != nil {
+		return []string{MIMEJSON, MIMEYAML, MIMEProtobuf}
+	}
+
+	return []string{MIMEJSON, MIMEYAML}
+}
+
+// yamlToJSON 将 YAML 转换为 JSON。
+func yamlToJSON(data []byte) ([]byte, error) {
+	var obj interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	obj, err := convertYAMLMaps(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(obj)
+}
+
+// jsonToYAML 将 JSON 转换为 YAML，保证 YAML 的字段名与 JSON 一致。
+func jsonToYAML(data []byte) ([]byte, error) {
+	var obj interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(obj)
+}
+
+// convertYAMLMaps 将 yaml.v2 解码得到的 map[interface{}]interface{} 转换为 JSON 支持的 map[string]interface{}。
+func convertYAMLMaps(obj interface{}) (interface{}, error) {
+	switch v := obj.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+
+		for key, value := range v {
+			var k string
+			switch typed := key.(type) {
+			case string:
+				k = typed
+			case int, int64, float64, bool:
+				k = fmt.Sprint(typed)
+			default:
+				return nil, fmt.Errorf("unsupported map key %v of type %T", key, key)
+			}
+
+			converted, err := convertYAMLMaps(value)
+			if err != nil {
+				return nil, err
+			}
+
+			m[k] = converted
+		}
+
+		return m, nil
+	case []interface{}:
+		for i := range v {
+			converted, err := convertYAMLMaps(v[i])
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = converted
+		}
+
+		return v, nil
+	default:
+		return obj, nil
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+func newTestContext(method, contentType, accept string, body []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/", bytes.NewReader(body))
+
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+
+	return c, w
+}
+
+func TestBind(t *testing.T) {
+	want := ErrResponse{Code: 100001, Message: "OK", RequestID: "abc"}
+
+	jsonBody, _ := json.Marshal(want)
+	protoBody, _ := proto.Marshal(want.ToProto())
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"default", "", jsonBody},
+		{"json", "application/json; charset=utf-8", jsonBody},
+		{"yaml", "application/yaml", []byte("code: 100001\nmessage: OK\nrequestID: abc\n")},
+		{"x-yaml", "application/x-yaml", []byte("code: 100001\nmessage: OK\nrequestID: abc\n")},
+		{"protobuf", MIMEProtobuf, protoBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(http.MethodPost, tt.contentType, "", tt.body)
+
+			var got ErrResponse
+			require.NoError(t, Bind(c, &got))
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	c, _ := newTestContext(http.MethodPost, "text/plain", "", []byte("hello"))
+	err := Bind(c, &ErrResponse{})
+	assert.True(t, errors.IsCode(err, code.ErrUnsupportedMediaType))
+
+	// 没有实现 ProtoConverter 的类型不支持 protobuf
+	c, _ = newTestContext(http.MethodPost, MIMEProtobuf, "", nil)
+	err = Bind(c, &struct{}{})
+	assert.True(t, errors.IsCode(err, code.ErrUnsupportedMediaType))
+
+	c, _ = newTestContext(http.MethodPost, MIMEYAML, "", []byte("code: [1"))
+	err = Bind(c, &ErrResponse{})
+	assert.True(t, errors.IsCode(err, code.ErrInvalidYaml))
+
+	c, _ = newTestContext(http.MethodPost, MIMEYAML, "", []byte("code: not a number"))
+	err = Bind(c, &ErrResponse{})
+	assert.True(t, errors.IsCode(err, code.ErrDecodingYaml))
+}
+
+func TestWriteResponse(t *testing.T) {
+	data := &ErrResponse{Code: 100001, Message: "OK"}
+
+	t.Run("json by default", func(t *testing.T) {
+		c, w := newTestContext(http.MethodGet, "", "", nil)
+		WriteResponse(c, nil, data)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), MIMEJSON)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		c, w := newTestContext(http.MethodGet, "", "application/json;q=0.5, application/yaml", nil)
+		WriteResponse(c, nil, data)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, MIMEYAML, w.Header().Get("Content-Type"))
+
+		var got map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "OK", got["message"])
+	})
+
+	t.Run("protobuf error", func(t *testing.T) {
+		c, w := newTestContext(http.MethodGet, "", MIMEProtobuf, nil)
+		WriteResponse(c, errors.WithCode(code.ErrValidation, "invalid"), nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, MIMEProtobuf, w.Header().Get("Content-Type"))
+
+		var got ErrResponse
+		msg := got.ToProto()
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), msg))
+		require.NoError(t, got.FromProto(msg))
+		assert.Equal(t, code.ErrValidation, got.Code)
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		c, w := newTestContext(http.MethodGet, "", "text/html, application/x-protobuf", nil)
+		WriteResponse(c, nil, map[string]string{"foo": "bar"})
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+		var got ErrResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, code.ErrNotAcceptable, got.Code)
+	})
+}
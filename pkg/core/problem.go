@@ -0,0 +1,119 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"github.com/tiandh987/SharkAgent/pkg/validation/field"
+)
+
+// MIMEProblemJSON 是 RFC 7807 定义的错误响应媒体类型，客户端通过 Accept 请求头启用。
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem 是 RFC 7807 定义的 problem details 错误响应。
+type Problem struct {
+	// Type is a URI reference that identifies the problem type, taken from the error code's reference.
+	Type string `json:"type"`
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance identifies this occurrence of the problem, it is the X-Request-ID of the request.
+	Instance string `json:"instance,omitempty"`
+
+	// Code defines the business error code.
+	Code int `json:"code"`
+
+	// Errors contains the field-level validation errors.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError 描述一个字段级的校验错误。
+type FieldError struct {
+	// Field is the path of the invalid field, e.g. metadata.name.
+	Field string `json:"field"`
+
+	// Type is a machine readable reason, e.g. FieldValueRequired.
+	Type string `json:"type"`
+
+	// BadValue is the rejected value.
+	BadValue interface{} `json:"badValue,omitempty"`
+
+	// Detail is a human-readable description of the error.
+	Detail string `json:"detail,omitempty"`
+}
+
+// fieldErrors 保存字段级的校验错误，作为带错误码 error 的 cause。
+type fieldErrors struct {
+	list field.ErrorList
+}
+
+func (e *fieldErrors) Error() string {
+	return e.list.ToAggregate().Error()
+}
+
+// NewValidationError 将 field.ErrorList 转换为错误码为 code.ErrValidation 的 error。
+// 客户端使用 application/problem+json 时，每个字段的错误会通过 errors 数组返回。
+func NewValidationError(list field.ErrorList) error {
+	return errors.WrapC(&fieldErrors{list: list}, code.ErrValidation, list.ToAggregate().Error())
+}
+
+// acceptsProblem 判断客户端是否优先接受 application/problem+json 格式的错误响应。
+func acceptsProblem(accept string) bool {
+	for _, candidate := range ParseAccept(accept) {
+		if candidate == MIMEProblemJSON {
+			return true
+		}
+
+		if _, ok := mediaTypeAliases[candidate]; ok || candidate == "*/*" || candidate == "application/*" {
+			return false
+		}
+	}
+
+	return false
+}
+
+// writeProblem 以 application/problem+json 格式写入错误响应。
+// 只有 4xx 错误会在 detail 中返回具体的错误信息，5xx 错误可能包含内部信息，只返回 title。
+func writeProblem(c *gin.Context, coder errors.Coder, err error) {
+	problem := &Problem{
+		Type:     coder.Reference(),
+		Title:    coder.String(),
+		Status:   coder.HTTPStatus(),
+		Instance: c.GetString(log.KeyRequestID),
+		Code:     coder.Code(),
+	}
+
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+
+	if problem.Status < http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+
+	var fe *fieldErrors
+	if errors.As(err, &fe) {
+		for _, e := range fe.list {
+			fieldErr := FieldError{Field: e.Field, Type: string(e.Type), Detail: e.Detail}
+			// 必填字段没有值，不返回 badValue
+			if e.Type != field.ErrorTypeRequired {
+				fieldErr.BadValue = e.BadValue
+			}
+
+			problem.Errors = append(problem.Errors, fieldErr)
+		}
+	}
+
+	c.Header("Content-Type", MIMEProblemJSON)
+	c.JSON(problem.Status, problem)
+}
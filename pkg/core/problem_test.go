@@ -0,0 +1,64 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/internal/pkg/code"
+	"github.com/tiandh987/SharkAgent/pkg/log"
+	"github.com/tiandh987/SharkAgent/pkg/validation/field"
+)
+
+func TestWriteProblem(t *testing.T) {
+	c, w := newTestContext(http.MethodPost, "", "application/problem+json, application/json;q=0.9", nil)
+	c.Set(log.KeyRequestID, "req-1")
+
+	WriteResponse(c, NewValidationError(field.ErrorList{
+		field.Required(field.NewPath("nickname"), "nickname is a required field"),
+		field.Invalid(field.NewPath("metadata", "name"), "a b", "name must not contain spaces"),
+	}), nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Validation failed", problem.Title)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "req-1", problem.Instance)
+	assert.Equal(t, code.ErrValidation, problem.Code)
+	assert.Equal(t, []FieldError{
+		{Field: "nickname", Type: string(field.ErrorTypeRequired), Detail: "nickname is a required field"},
+		{Field: "metadata.name", Type: string(field.ErrorTypeInvalid), BadValue: "a b", Detail: "name must not contain spaces"},
+	}, problem.Errors)
+}
+
+func TestWriteProblemHidesServerErrorDetail(t *testing.T) {
+	c, w := newTestContext(http.MethodGet, "", MIMEProblemJSON, nil)
+	WriteResponse(c, errors.WithCode(code.ErrDatabase, "dial tcp 10.0.0.1:3306: connection refused"), nil)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "Database error", problem.Title)
+	assert.Empty(t, problem.Detail)
+	assert.Empty(t, problem.Errors)
+}
+
+func TestErrResponseWithoutProblemOptIn(t *testing.T) {
+	c, w := newTestContext(http.MethodGet, "", "application/json, application/problem+json;q=0.5", nil)
+	WriteResponse(c, NewValidationError(field.ErrorList{field.Required(field.NewPath("email"), "")}), nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), MIMEJSON)
+
+	var resp ErrResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, code.ErrValidation, resp.Code)
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/tiandh987/SharkAgent/pkg/log"
+)
+
+// Cluster 是一个主库和多个只读从库。读操作按轮询选择健康的从库，没有健康的从库时使用主库。
+// 后台定期 ping 所有从库，失败的从库在下一次 ping 成功前不会被选择。
+type Cluster struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     uint32
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+type replica struct {
+	host string
+	db   *gorm.DB
+	// healthy 为 1 表示最近一次检查成功
+	healthy int32
+}
+
+// NewCluster 使用 opts 连接主库，使用 opts 中除 Host 外的配置连接 hosts 中的每个从库，
+// 每隔 healthCheckInterval 检查一次从库是否可用。
+// 主库无法连接时返回错误；从库无法连接时只标记为不可用，恢复后由健康检查重新启用。
+func NewCluster(opts *Options, hosts []string, healthCheckInterval time.Duration) (*Cluster, error) {
+	primary, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make(map[string]*gorm.DB, len(hosts))
+	for _, host := range hosts {
+		replicaOpts := *opts
+		replicaOpts.Host = host
+		replicaOpts.DisableAutomaticPing = true
+
+		dbIns, err := New(&replicaOpts)
+		if err != nil {
+			_ = closeDB(primary)
+			for _, d := range replicas {
+				_ = closeDB(d)
+			}
+
+			return nil, err
+		}
+
+		replicas[host] = dbIns
+	}
+
+	c := newCluster(primary, hosts, replicas, healthCheckInterval)
+	c.check(healthCheckInterval)
+
+	return c, nil
+}
+
+// newCluster 创建 Cluster，replicas 的 key 为 hosts 中的从库地址。
+func newCluster(primary *gorm.DB, hosts []string, replicas map[string]*gorm.DB,
+	healthCheckInterval time.Duration) *Cluster {
+	c := &Cluster{primary: primary, stopCh: make(chan struct{})}
+	for _, host := range hosts {
+		c.replicas = append(c.replicas, &replica{host: host, db: replicas[host], healthy: 1})
+	}
+
+	if len(c.replicas) != 0 && healthCheckInterval > 0 {
+		c.wg.Add(1)
+
+		go c.healthCheck(healthCheckInterval)
+	}
+
+	return c
+}
+
+// Primary 返回主库。
+func (c *Cluster) Primary() *gorm.DB {
+	return c.primary
+}
+
+// Replica 按轮询返回一个健康的从库，没有从库或所有从库都不可用时返回主库。
+func (c *Cluster) Replica() *gorm.DB {
+	n := len(c.replicas)
+	if n == 0 {
+		return c.primary
+	}
+
+	start := atomic.AddUint32(&c.next, 1)
+	for i := 0; i < n; i++ {
+		r := c.replicas[(int(start)+i)%n]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r.db
+		}
+	}
+
+	return c.primary
+}
+
+// MarkDown 将 Replica 返回的从库标记为不可用，在下一次健康检查成功前不再使用。
+// 查询从库时发现连接错误可以立即调用，不需要等待下一次健康检查。
+func (c *Cluster) MarkDown(db *gorm.DB) {
+	for _, r := range c.replicas {
+		if r.db == db && atomic.SwapInt32(&r.healthy, 0) == 1 {
+			log.Warnf("mysql replica %s is marked down", r.host)
+		}
+	}
+}
+
+// Close 停止健康检查并关闭主库和所有从库的连接。
+func (c *Cluster) Close() error {
+	close(c.stopCh)
+	c.wg.Wait()
+
+	var firstErr error
+	for _, d := range append([]*gorm.DB{c.primary}, c.dbs()...) {
+		if err := closeDB(d); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (c *Cluster) dbs() []*gorm.DB {
+	ret := make([]*gorm.DB, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		ret = append(ret, r.db)
+	}
+
+	return ret
+}
+
+func (c *Cluster) healthCheck(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.check(interval)
+		}
+	}
+}
+
+// check ping 所有从库并更新健康状态，每个从库最多等待 timeout。
+func (c *Cluster) check(timeout time.Duration) {
+	for _, r := range c.replicas {
+		err := ping(r.db, timeout)
+
+		switch {
+		case err != nil && atomic.SwapInt32(&r.healthy, 0) == 1:
+			log.Warnf("mysql replica %s is down: %s", r.host, err.Error())
+		case err == nil && atomic.SwapInt32(&r.healthy, 1) == 0:
+			log.Infof("mysql replica %s is up", r.host)
+		}
+	}
+}
+
+func ping(db *gorm.DB, timeout time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return sqlDB.PingContext(ctx)
+}
+
+func closeDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
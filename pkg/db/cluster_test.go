@@ -0,0 +1,55 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dbIns, err := NewSQLite(&SQLiteOptions{Path: ":memory:", JournalMode: "MEMORY", BusyTimeout: time.Second})
+	require.NoError(t, err)
+
+	return dbIns
+}
+
+func TestCluster(t *testing.T) {
+	primary, a, b := newTestDB(t), newTestDB(t), newTestDB(t)
+	c := newCluster(primary, []string{"a", "b"}, map[string]*gorm.DB{"a": a, "b": b}, time.Hour)
+
+	assert.Equal(t, primary, c.Primary())
+
+	// 轮询所有健康的从库
+	got := map[*gorm.DB]bool{c.Replica(): true, c.Replica(): true}
+	assert.Equal(t, map[*gorm.DB]bool{a: true, b: true}, got)
+
+	c.MarkDown(a)
+	assert.Equal(t, b, c.Replica())
+	assert.Equal(t, b, c.Replica())
+
+	// 健康检查成功后恢复
+	c.check(time.Second)
+	got = map[*gorm.DB]bool{c.Replica(): true, c.Replica(): true}
+	assert.Equal(t, map[*gorm.DB]bool{a: true, b: true}, got)
+
+	// 所有从库都不可用时使用主库
+	require.NoError(t, closeDB(a))
+	require.NoError(t, closeDB(b))
+	c.check(time.Second)
+	assert.Equal(t, primary, c.Replica())
+
+	assert.NoError(t, closeDB(primary))
+}
+
+func TestCluster_NoReplicas(t *testing.T) {
+	primary := newTestDB(t)
+	c := newCluster(primary, nil, nil, time.Second)
+
+	assert.Equal(t, primary, c.Replica())
+	assert.NoError(t, c.Close())
+}
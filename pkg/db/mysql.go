@@ -19,6 +19,8 @@ type Options struct {
 	MaxConnectionLifeTime time.Duration
 	LogLevel              int
 	Logger                logger.Interface
+	// DisableAutomaticPing 为 true 时创建实例不检查数据库是否可用
+	DisableAutomaticPing bool
 }
 
 // New 使用给定的配置，创建一个 gorm db 实例
@@ -32,7 +34,8 @@ func New(opts *Options) (*gorm.DB, error) {
 		"Local")
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: opts.Logger,
+		Logger:               opts.Logger,
+		DisableAutomaticPing: opts.DisableAutomaticPing,
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SQLiteOptions 定义打开 sqlite 数据库要使用的配置信息.
+type SQLiteOptions struct {
+	// Path 是数据库文件路径，":memory:" 表示内存数据库
+	Path        string
+	JournalMode string
+	BusyTimeout time.Duration
+	LogLevel    int
+	Logger      logger.Interface
+}
+
+// NewSQLite 使用给定的配置，创建一个 gorm db 实例
+func NewSQLite(opts *SQLiteOptions) (*gorm.DB, error) {
+	params := url.Values{}
+	params.Set("_journal_mode", opts.JournalMode)
+	params.Set("_busy_timeout", fmt.Sprint(opts.BusyTimeout.Milliseconds()))
+	params.Set("_foreign_keys", "on")
+	// 事务开始时即获取写锁，避免两个事务都从读锁升级为写锁时其中一个直接返回 SQLITE_BUSY
+	params.Set("_txlock", "immediate")
+
+	dsn := fmt.Sprintf("file:%s?%s", opts.Path, params.Encode())
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: opts.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite 同一时间只允许一个写入者，内存数据库的每个连接都是独立的数据库，因此只使用一个连接
+	if opts.Path == ":memory:" {
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
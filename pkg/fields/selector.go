@@ -0,0 +1,38 @@
+// Package fields 实现了简单的字段选择器，用于 List 请求的 fieldSelector 参数。
+package fields
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selector 是解析后的字段选择器，所有条件之间是“与”的关系。
+type Selector map[string]string
+
+// ParseSelector 解析逗号分隔的 "key=value" 或 "key==value" 形式的字段选择器，空字符串匹配所有对象。
+func ParseSelector(selector string) (Selector, error) {
+	s := Selector{}
+
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(strings.Replace(part, "==", "=", 1), "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid field selector %q, expected key=value", part)
+		}
+
+		s[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return s, nil
+}
+
+// RequiresExactMatch 返回 field 需要匹配的值，selector 中没有该字段时返回 false。
+func (s Selector) RequiresExactMatch(field string) (string, bool) {
+	value, ok := s[field]
+
+	return value, ok
+}
@@ -0,0 +1,30 @@
+package fields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	s, err := ParseSelector("name=admin, status==1")
+	require.NoError(t, err)
+
+	name, ok := s.RequiresExactMatch("name")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", name)
+
+	status, _ := s.RequiresExactMatch("status")
+	assert.Equal(t, "1", status)
+
+	_, ok = s.RequiresExactMatch("email")
+	assert.False(t, ok)
+
+	s, err = ParseSelector("")
+	require.NoError(t, err)
+	assert.Empty(t, s)
+
+	_, err = ParseSelector("name")
+	assert.Error(t, err)
+}
@@ -1,5 +1,12 @@
 package v1
 
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // TypeMeta describes an individual object in an API response or request
 // with strings representing the type of the object and its API schema version.
 // Structures that are versioned or persisted should inline TypeMeta.
@@ -17,6 +24,103 @@ type TypeMeta struct {
 	APIVersion string `json:"apiVersion,omitempty"`
 }
 
+// ObjectMeta is metadata that all persisted resources must have.
+// ObjectMeta is also used by gorm, Extend is saved in the extendShadow column as JSON.
+type ObjectMeta struct {
+	// ID is the unique in time and space value for this object. It is typically generated by
+	// the storage on successful creation of a resource and is not allowed to change on PUT
+	// operations.
+	//
+	// Populated by the system.
+	// Read-only.
+	ID uint64 `json:"id,omitempty" gorm:"primary_key;AUTO_INCREMENT;column:id"`
+
+	// InstanceID defines a string type resource identifier,
+	// use prefixed to distinguish resource types, easy to remember, Url-friendly.
+	InstanceID string `json:"instanceID,omitempty" gorm:"unique;column:instanceID;type:varchar(32)"`
+
+	// Required: true
+	// Name must be unique. Is required when creating resources.
+	// Name is primarily intended for creation idempotence and configuration
+	// definition.
+	// It will be generated automated only if Name is not specified.
+	// Cannot be updated.
+	Name string `json:"name,omitempty" gorm:"column:name;type:varchar(64);not null"`
+
+	// Extend store the fields that need to be added, but do not want to add a new table column.
+	Extend map[string]interface{} `json:"extend,omitempty" gorm:"-"`
+
+	// ExtendShadow is the shadow of Extend. DO NOT modify directly.
+	ExtendShadow string `json:"-" gorm:"column:extendShadow"`
+
+	// Version is incremented by the storage on every successful write of this object.
+	// It is used for ETags and optimistic concurrency control, timestamps are not
+	// precise enough to tell two writes apart.
+	//
+	// Populated by the system.
+	// Read-only.
+	Version uint64 `json:"version,omitempty" gorm:"column:version;not null;default:1"`
+
+	// CreatedAt is a timestamp representing the server time when this object was
+	// created. It is not guaranteed to be set in happens-before order across separate operations.
+	// Clients may not set this value. It is represented in RFC3339 form and is in UTC.
+	//
+	// Populated by the system.
+	// Read-only.
+	// Null for lists.
+	CreatedAt time.Time `json:"createdAt,omitempty" gorm:"column:createdAt"`
+
+	// UpdatedAt is a timestamp representing the server time when this object was updated.
+	// Clients may not set this value. It is represented in RFC3339 form and is in UTC.
+	//
+	// Populated by the system.
+	// Read-only.
+	// Null for lists.
+	UpdatedAt time.Time `json:"updatedAt,omitempty" gorm:"column:updatedAt"`
+}
+
+// BeforeCreate run before create database record.
+func (obj *ObjectMeta) BeforeCreate(tx *gorm.DB) error {
+	if obj.Version == 0 {
+		obj.Version = 1
+	}
+
+	return obj.shadowExtend()
+}
+
+// BeforeUpdate run before update database record.
+func (obj *ObjectMeta) BeforeUpdate(tx *gorm.DB) error {
+	return obj.shadowExtend()
+}
+
+// AfterFind run after find to unmarshal a extend shadow string into Extend.
+func (obj *ObjectMeta) AfterFind(tx *gorm.DB) error {
+	obj.Extend = nil
+	if obj.ExtendShadow == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(obj.ExtendShadow), &obj.Extend)
+}
+
+// shadowExtend 将 Extend 序列化到 ExtendShadow 中保存。
+func (obj *ObjectMeta) shadowExtend() error {
+	if len(obj.Extend) == 0 {
+		obj.ExtendShadow = ""
+
+		return nil
+	}
+
+	data, err := json.Marshal(obj.Extend)
+	if err != nil {
+		return err
+	}
+
+	obj.ExtendShadow = string(data)
+
+	return nil
+}
+
 // CreateOptions may be provided when creating an API object.
 type CreateOptions struct {
 	TypeMeta `json:",inline"`
@@ -28,4 +132,78 @@ type CreateOptions struct {
 	// - All: all dry run stages will be processed
 	// +optional
 	DryRun []string `json:"dryRun,omitempty"`
-}
\ No newline at end of file
+}
+
+// GetOptions is the standard query options to the standard REST get call.
+type GetOptions struct {
+	TypeMeta `json:",inline"`
+}
+
+// UpdateOptions may be provided when updating an API object.
+type UpdateOptions struct {
+	TypeMeta `json:",inline"`
+
+	// When present, indicates that modifications should not be
+	// persisted. An invalid or unrecognized dryRun directive will
+	// result in an error response and no further processing of the
+	// request. Valid values are:
+	// - All: all dry run stages will be processed
+	// +optional
+	DryRun []string `json:"dryRun,omitempty"`
+
+	// Must be fulfilled before an update is carried out.
+	// +optional
+	Preconditions *Preconditions `json:"preconditions,omitempty"`
+}
+
+// DeleteOptions may be provided when deleting an API object.
+type DeleteOptions struct {
+	TypeMeta `json:",inline"`
+
+	// +optional
+	Unscoped bool `json:"unscoped"`
+
+	// Must be fulfilled before a deletion is carried out.
+	// +optional
+	Preconditions *Preconditions `json:"preconditions,omitempty"`
+}
+
+// Preconditions must be fulfilled before an operation (update, delete, etc.) is carried out.
+// 存储层在同一条语句中检查前置条件，不满足时返回冲突错误，服务层将其转换为 code.ErrPreconditionFailed。
+type Preconditions struct {
+	// Specifies the target Version.
+	// +optional
+	Version *uint64 `json:"version,omitempty"`
+}
+
+// ListMeta describes metadata that synthetic resources must have, including lists and
+// various status objects. A resource may have only one of {ObjectMeta, ListMeta}.
+type ListMeta struct {
+	TotalCount int64 `json:"totalCount,omitempty"`
+}
+
+// ListOptions is the query options to a standard REST list call.
+type ListOptions struct {
+	TypeMeta `json:",inline"`
+
+	// FieldSelector restricts the list of returned objects by their fields. Defaults to everything.
+	// 只支持逗号分隔的 key=value，例如 name=admin。
+	FieldSelector string `json:"fieldSelector,omitempty" form:"fieldSelector"`
+
+	// Timeout for the list/watch call.
+	// 对于 watch 请求，指定多久之后结束事件流。
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty" form:"timeoutSeconds"`
+
+	// Offset specify the number of records to skip before starting to return the records.
+	Offset *int64 `json:"offset,omitempty" form:"offset"`
+
+	// Limit specify the number of records to be retrieved.
+	Limit *int64 `json:"limit,omitempty" form:"limit"`
+
+	// Watch for changes to the described resources and return them as a stream of
+	// add, update, and remove notifications.
+	Watch bool `json:"watch,omitempty" form:"watch"`
+
+	// ResourceVersion 是 watch 的恢复令牌，只返回该版本之后的事件；为空时从当前时刻开始。
+	ResourceVersion string `json:"resourceVersion,omitempty" form:"resourceVersion"`
+}
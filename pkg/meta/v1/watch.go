@@ -0,0 +1,26 @@
+package v1
+
+// EventType defines the possible types of watch events.
+type EventType string
+
+// Event types.
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Error    EventType = "ERROR"
+)
+
+// WatchEvent represents a single event to a watched resource.
+type WatchEvent struct {
+	Type EventType `json:"type"`
+
+	// ResourceVersion 是事件的版本，作为恢复令牌通过 resourceVersion 参数或 Last-Event-ID 请求头传回。
+	ResourceVersion string `json:"resourceVersion"`
+
+	// Object is:
+	//  * If Type is Added or Modified: the new state of the object.
+	//  * If Type is Deleted: the state of the object immediately before deletion.
+	//  * If Type is Error: an ErrResponse.
+	Object interface{} `json:"object"`
+}
@@ -0,0 +1,323 @@
+// Package migrate 实现了按版本顺序执行的数据库表结构迁移。
+//
+// 已执行的迁移记录在 schema_migrations 表中，每个版本一行。迁移开始前先写入 dirty 为 true 的记录，
+// 成功后再清除 dirty。MySQL 的 DDL 会隐式提交事务，迁移中途失败时记录保持 dirty，
+// 需要人工修复数据库后删除该记录，在此之前拒绝执行其他迁移。
+//
+// MySQL 使用 GET_LOCK 保证多个实例不会同时执行迁移；sqlite 的写事务本身互斥，
+// 每个迁移在事务中重新检查是否已经执行。
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TableName 是记录已执行迁移的表名。
+const TableName = "schema_migrations"
+
+// lockName 是 MySQL 中迁移使用的命名锁。
+const lockName = "schema_migrations"
+
+// Migration 是一个版本的表结构迁移。
+type Migration struct {
+	// Version 是迁移的版本号，按升序执行，必须唯一且大于 0
+	Version uint64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// Status 是一个迁移的执行状态。
+type Status struct {
+	Version   uint64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+}
+
+// record 是 schema_migrations 表中的一行。
+type record struct {
+	Version   uint64    `gorm:"primary_key;column:version"`
+	Name      string    `gorm:"column:name"`
+	Dirty     bool      `gorm:"column:dirty"`
+	AppliedAt time.Time `gorm:"column:appliedAt"`
+}
+
+// TableName maps to schema_migrations table.
+func (record) TableName() string {
+	return TableName
+}
+
+// Migrator 在数据库上执行迁移。
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	// LockTimeout 是等待其他实例完成迁移的最长时间
+	LockTimeout time.Duration
+}
+
+// New 创建 Migrator，migrations 按版本号排序，版本号重复或为 0 时返回错误。
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version == 0 {
+			return nil, fmt.Errorf("migration %q: version must be greater than 0", m.Name)
+		}
+
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d: %q and %q", m.Version, sorted[i-1].Name, m.Name)
+		}
+	}
+
+	return &Migrator{db: db, migrations: sorted, LockTimeout: time.Minute}, nil
+}
+
+// Up 执行所有未执行的迁移。
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.latest())
+}
+
+// Down 回滚最新的一个迁移，没有已执行的迁移时不做任何事情。
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.down(db, m.migrations[i])
+			}
+		}
+
+		return nil
+	})
+}
+
+// To 执行或回滚迁移，使数据库处于 version 版本：执行版本号不大于 version 的未执行迁移，
+// 回滚版本号大于 version 的已执行迁移。version 为 0 时回滚所有迁移。
+func (m *Migrator) To(ctx context.Context, version uint64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mi := m.migrations[i]
+			if _, ok := applied[mi.Version]; ok && mi.Version > version {
+				if err := m.down(db, mi); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, mi := range m.migrations {
+			if _, ok := applied[mi.Version]; !ok && mi.Version <= version {
+				if err := m.up(db, mi); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Status 返回所有已知迁移的执行状态。数据库中存在但不在 migrations 中的版本（例如由更新的程序执行）也会返回。
+// Status 只读取数据库，schema_migrations 表不存在时所有迁移都未执行，不会创建该表。
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+
+	var records []record
+	if db.Migrator().HasTable(TableName) {
+		if err := db.Order("version").Find(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	applied := map[uint64]record{}
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	var ret []Status
+	for _, mi := range m.migrations {
+		s := Status{Version: mi.Version, Name: mi.Name}
+		if r, ok := applied[mi.Version]; ok {
+			at := r.AppliedAt
+			s.Applied, s.Dirty, s.AppliedAt = true, r.Dirty, &at
+			delete(applied, mi.Version)
+		}
+
+		ret = append(ret, s)
+	}
+
+	for _, r := range records {
+		if _, ok := applied[r.Version]; ok {
+			at := r.AppliedAt
+			ret = append(ret, Status{Version: r.Version, Name: r.Name, Applied: true, Dirty: r.Dirty, AppliedAt: &at})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Version < ret[j].Version })
+
+	return ret, nil
+}
+
+func (m *Migrator) latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) find(version uint64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+// applied 返回已执行的迁移版本，存在 dirty 的迁移时返回错误。
+func (m *Migrator) applied(db *gorm.DB) (map[uint64]struct{}, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	var records []record
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	ret := map[uint64]struct{}{}
+	for _, r := range records {
+		if r.Dirty {
+			return nil, fmt.Errorf("migration %d (%s) is dirty, fix the database manually and "+
+				"delete version %d from %s before running migrations", r.Version, r.Name, r.Version, TableName)
+		}
+
+		ret[r.Version] = struct{}{}
+	}
+
+	return ret, nil
+}
+
+// up 执行一个迁移，其他实例已经执行过时跳过。
+func (m *Migrator) up(db *gorm.DB, mi Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&record{}).Where("version = ?", mi.Version).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count != 0 {
+			return nil
+		}
+
+		r := &record{Version: mi.Version, Name: mi.Name, Dirty: true, AppliedAt: time.Now()}
+		if err := tx.Create(r).Error; err != nil {
+			return err
+		}
+
+		if mi.Up != nil {
+			if err := mi.Up(tx); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(r).Update("dirty", false).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrate up %d (%s): %w", mi.Version, mi.Name, err)
+	}
+
+	return nil
+}
+
+// down 回滚一个迁移。
+func (m *Migrator) down(db *gorm.DB, mi Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&record{Version: mi.Version}).Update("dirty", true).Error; err != nil {
+			return err
+		}
+
+		if mi.Down != nil {
+			if err := mi.Down(tx); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&record{Version: mi.Version}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrate down %d (%s): %w", mi.Version, mi.Name, err)
+	}
+
+	return nil
+}
+
+// withLock 在持有迁移锁时执行 fn。
+// MySQL 的命名锁属于连接，因此 fn 中的所有语句都在获取锁的连接上执行。
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	if m.db.Dialector.Name() != "mysql" {
+		return fn(m.db.WithContext(ctx))
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.LockTimeout.Seconds())).
+		Scan(&locked); err != nil {
+		return err
+	}
+
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("timed out after %s waiting for another instance to finish migrations", m.LockTimeout)
+	}
+
+	//nolint: errcheck
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+
+	db := m.db.Session(&gorm.Session{Context: ctx})
+	db.Statement.ConnPool = conn
+
+	return fn(db)
+}
+
+// ensureTable 创建 schema_migrations 表。
+func ensureTable(db *gorm.DB) error {
+	return db.Exec("CREATE TABLE IF NOT EXISTS " + TableName + " (" +
+		"version bigint NOT NULL PRIMARY KEY, " +
+		"name varchar(255) NOT NULL, " +
+		"dirty boolean NOT NULL DEFAULT false, " +
+		"appliedAt timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP)").Error
+}
@@ -0,0 +1,160 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tiandh987/SharkAgent/pkg/db"
+	"gorm.io/gorm"
+)
+
+var testFS = fstest.MapFS{
+	"migrations/000001_create_a.up.sql":   {Data: []byte("-- table a\nCREATE TABLE a (\n  id int\n);\nINSERT INTO a VALUES (1);\n")},
+	"migrations/000001_create_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+	"migrations/000002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id int);\n")},
+	"migrations/000002_create_b.down.sql": {Data: []byte("DROP TABLE b;\n")},
+	"migrations/000003_create_c.up.sql":   {Data: []byte("CREATE TABLE c (id int);\n")},
+	"migrations/README.md":                {Data: []byte("ignored")},
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, *gorm.DB) {
+	t.Helper()
+
+	dbIns, err := db.NewSQLite(&db.SQLiteOptions{Path: ":memory:", JournalMode: "MEMORY", BusyTimeout: time.Second})
+	require.NoError(t, err)
+
+	migrations, err := FromFS(testFS, "migrations")
+	require.NoError(t, err)
+
+	m, err := New(dbIns, migrations)
+	require.NoError(t, err)
+
+	return m, dbIns
+}
+
+func hasTable(t *testing.T, db *gorm.DB, name string) bool {
+	t.Helper()
+
+	return db.Migrator().HasTable(name)
+}
+
+func versions(t *testing.T, m *Migrator) map[uint64]bool {
+	t.Helper()
+
+	status, err := m.Status(context.Background())
+	require.NoError(t, err)
+
+	ret := map[uint64]bool{}
+	for _, s := range status {
+		ret[s.Version] = s.Applied
+	}
+
+	return ret
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	// 查询状态不会创建 schema_migrations 表
+	assert.Equal(t, map[uint64]bool{1: false, 2: false, 3: false}, versions(t, m))
+	assert.False(t, hasTable(t, db, TableName))
+
+	require.NoError(t, m.Up(ctx))
+	assert.Equal(t, map[uint64]bool{1: true, 2: true, 3: true}, versions(t, m))
+	assert.True(t, hasTable(t, db, "c"))
+
+	// 再次执行不会重复迁移
+	require.NoError(t, m.Up(ctx))
+
+	// 3 没有 down 文件，回滚时只删除记录
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, map[uint64]bool{1: true, 2: true, 3: false}, versions(t, m))
+	assert.True(t, hasTable(t, db, "c"))
+
+	require.NoError(t, m.To(ctx, 1))
+	assert.Equal(t, map[uint64]bool{1: true, 2: false, 3: false}, versions(t, m))
+	assert.False(t, hasTable(t, db, "b"))
+
+	require.NoError(t, m.To(ctx, 2))
+	assert.True(t, hasTable(t, db, "b"))
+
+	require.NoError(t, m.To(ctx, 0))
+	assert.Equal(t, map[uint64]bool{1: false, 2: false, 3: false}, versions(t, m))
+	assert.False(t, hasTable(t, db, "a"))
+
+	assert.Error(t, m.To(ctx, 4))
+}
+
+func TestMigrator_Failed(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	m.migrations = append(m.migrations, Migration{Version: 4, Name: "broken", Up: SQL("CREATE TABLE d (id int)", "invalid")})
+
+	err := m.Up(ctx)
+	assert.Contains(t, err.Error(), "migrate up 4 (broken)")
+
+	// sqlite 的 DDL 在事务中执行，失败的迁移完整回滚
+	assert.False(t, hasTable(t, db, "d"))
+	assert.Equal(t, map[uint64]bool{1: true, 2: true, 3: true, 4: false}, versions(t, m))
+
+	// DDL 不支持事务的数据库中失败的迁移保持 dirty，需要人工处理
+	require.NoError(t, db.Model(&record{}).Where("version = ?", 3).Update("dirty", true).Error)
+	assert.Contains(t, m.Up(ctx).Error(), "is dirty")
+	assert.Contains(t, m.Down(ctx).Error(), "is dirty")
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[2].Dirty)
+}
+
+func TestMigrator_UnknownVersion(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+	require.NoError(t, m.Up(ctx))
+
+	// 数据库中有更新的程序执行的迁移
+	require.NoError(t, db.Create(&record{Version: 10, Name: "newer", AppliedAt: time.Now()}).Error)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 4)
+	assert.Equal(t, Status{Version: 10, Name: "newer", Applied: true, AppliedAt: status[3].AppliedAt}, status[3])
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, []Migration{{Version: 1, Name: "a"}, {Version: 1, Name: "b"}})
+	assert.Error(t, err)
+
+	_, err = New(nil, []Migration{{Version: 0, Name: "a"}})
+	assert.Error(t, err)
+}
+
+func TestFromFS(t *testing.T) {
+	migrations, err := FromFS(testFS, "migrations")
+	require.NoError(t, err)
+	assert.Len(t, migrations, 3)
+
+	for _, fsys := range []fstest.MapFS{
+		{"m/1_a.sql": {}},
+		{"m/a_b.up.sql": {}},
+		{"m/1_a.down.sql": {}},
+		{"m/1_a.up.sql": {}, "m/1_b.down.sql": {}},
+	} {
+		_, err := FromFS(fsys, "m")
+		assert.Error(t, err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\nid int\n);",
+		"INSERT INTO a VALUES (1);",
+		"SELECT 1",
+	}, splitStatements("-- comment\nCREATE TABLE a (\n  id int\n);\n\nINSERT INTO a VALUES (1);\nSELECT 1\n"))
+}
@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FromFS 读取 dir 目录中的 SQL 迁移文件。
+//
+// 文件名的格式为 <version>_<name>.up.sql 和 <version>_<name>.down.sql，例如 000001_create_user.up.sql。
+// down 文件可以不存在，此时回滚该版本不执行任何语句。
+// 文件中的语句以行尾的分号分隔，以 -- 开头的行是注释。
+func FromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint64]*Migration{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has different names: %q and %q", version, m.Name, name)
+		}
+
+		statements := SQL(splitStatements(string(data))...)
+		if direction == "up" {
+			m.Up = statements
+		} else {
+			m.Down = statements
+		}
+	}
+
+	ret := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", m.Version, m.Name)
+		}
+
+		ret = append(ret, *m)
+	}
+
+	return ret, nil
+}
+
+// SQL 返回依次执行 statements 的迁移函数。
+func SQL(statements ...string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, s := range statements {
+			if err := tx.Exec(s).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// parseFileName 解析迁移文件名，返回版本号、名称和方向（up 或 down）。
+func parseFileName(file string) (uint64, string, string, error) {
+	base := strings.TrimSuffix(file, ".sql")
+
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", fmt.Errorf("migration file %q must end with .up.sql or .down.sql", file)
+	}
+
+	base = strings.TrimSuffix(base, direction)
+
+	i := strings.Index(base, "_")
+	if i <= 0 {
+		return 0, "", "", fmt.Errorf("migration file %q must be named <version>_<name>%s.sql", file, direction)
+	}
+
+	version, err := strconv.ParseUint(base[:i], 10, 64)
+	if err != nil || version == 0 {
+		return 0, "", "", fmt.Errorf("migration file %q has invalid version %q", file, base[:i])
+	}
+
+	return version, base[i+1:], direction[1:], nil
+}
+
+// splitStatements 将 SQL 文件按行尾的分号拆分为单独的语句，MySQL 驱动默认不支持一次执行多条语句。
+func splitStatements(data string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(line, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+
+	if s := strings.TrimSpace(current.String()); s != "" {
+		statements = append(statements, s)
+	}
+
+	return statements
+}
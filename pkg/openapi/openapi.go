@@ -0,0 +1,193 @@
+// Package openapi 定义 OpenAPI 3 文档的数据结构，并通过反射 API 类型和 `validate` 标签生成 Schema。
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version 是生成的文档所遵循的 OpenAPI 规范版本。
+const Version = "3.0.3"
+
+// Document 是 OpenAPI 3 文档的根对象。
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// schemaTypes 记录 components.schemas 中每个名称对应的 Go 类型
+	schemaTypes map[string]reflect.Type
+}
+
+// Info 提供 API 的元数据。
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server 描述提供 API 服务的地址。
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 描述一个路径上的所有操作，key 为小写的 HTTP 方法。
+type PathItem map[string]*Operation
+
+// Operation 描述一个路径上的单个 API 操作。
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter 描述一个路径、查询或请求头参数。
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody 描述请求体。
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response 描述一个 HTTP 状态码对应的响应。
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 描述某种媒体类型的内容结构。
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components 保存可以被 $ref 引用的对象。
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema 是 OpenAPI 3.0 Schema Object 的子集。
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int64             `json:"minLength,omitempty"`
+	MaxLength            *int64             `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int64             `json:"minItems,omitempty"`
+	MaxItems             *int64             `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+}
+
+// ErrorCode 描述一个业务错误码，用于生成错误响应的文档。
+type ErrorCode struct {
+	Code       int
+	HTTPStatus int
+	Message    string
+}
+
+// NewDocument 创建一个空的 OpenAPI 文档。
+func NewDocument(info Info) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+}
+
+// ginParamRegexp 匹配 gin 路由中的 :name 和 *name 参数。
+var ginParamRegexp = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// AddOperation 将 gin 风格的路由（例如 /v1/users/:name）转换为 OpenAPI 路径并添加操作，
+// 路径参数会自动添加到 Parameters 中。
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	for _, match := range ginParamRegexp.FindAllStringSubmatch(path, -1) {
+		if !hasParameter(op.Parameters, match[1], "path") {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+
+	path = ginParamRegexp.ReplaceAllString(path, "{$1}")
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+
+	(*item)[strings.ToLower(method)] = op
+}
+
+func hasParameter(params []*Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorResponses 按 HTTP 状态码对错误码分组，生成引用 errSchema 的响应，
+// 响应的描述中列出该状态码下所有可能返回的错误码。
+func ErrorResponses(errSchema *Schema, codes ...ErrorCode) map[string]*Response {
+	byStatus := map[int][]ErrorCode{}
+	for _, c := range codes {
+		byStatus[c.HTTPStatus] = append(byStatus[c.HTTPStatus], c)
+	}
+
+	responses := make(map[string]*Response, len(byStatus))
+	for status, list := range byStatus {
+		sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+
+		lines := []string{http.StatusText(status)}
+		for _, c := range list {
+			lines = append(lines, "- "+strconv.Itoa(c.Code)+": "+c.Message)
+		}
+
+		responses[strconv.Itoa(status)] = &Response{
+			Description: strings.Join(lines, "\n"),
+			Content:     JSONContent(errSchema),
+		}
+	}
+
+	return responses
+}
+
+// JSONContent 返回 application/json 媒体类型的内容定义。
+func JSONContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor 返回 v 的类型对应的 Schema。结构体会注册到 components.schemas 中，并返回 $ref 引用。
+//
+// 字段名称取自 `json` 标签，`validate` 标签按以下规则转换：
+//   - required：加入 required 列表
+//   - min/max：字符串对应 minLength/maxLength，数字对应 minimum/maximum，切片对应 minItems/maxItems
+//   - email：format 设置为 email
+//   - url/uri：format 设置为 uri
+//   - oneof：转换为 enum
+func (d *Document) SchemaFor(v interface{}) *Schema {
+	return d.schemaForType(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return d.refFor(t)
+	}
+
+	return d.inlineSchema(t)
+}
+
+// inlineSchema 返回非结构体类型的 Schema。
+func (d *Document) inlineSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: d.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaForType(t.Elem())}
+	default:
+		// interface{} 等无法确定类型的字段允许任意值
+		return &Schema{}
+	}
+}
+
+// refFor 将结构体注册到 components.schemas 并返回引用。同名但不同包的结构体使用包名作为前缀区分。
+func (d *Document) refFor(t reflect.Type) *Schema {
+	name := d.schemaName(t)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if _, ok := d.Components.Schemas[name]; ok {
+		return ref
+	}
+
+	// 先占位，避免自引用的结构体无限递归
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	d.Components.Schemas[name] = schema
+	d.fillStruct(schema, t)
+
+	return ref
+}
+
+func (d *Document) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		name = "Anonymous"
+	}
+
+	if d.schemaTypes == nil {
+		d.schemaTypes = map[string]reflect.Type{}
+	}
+
+	if existing, ok := d.schemaTypes[name]; ok && existing != t {
+		pkg := t.PkgPath()
+		name = strings.ReplaceAll(pkg[strings.LastIndex(pkg, "/")+1:], ".", "") + name
+	}
+
+	d.schemaTypes[name] = t
+
+	return name
+}
+
+// fillStruct 将结构体字段转换为 properties。匿名嵌入且没有 json 名称的字段会被展开。
+func (d *Document) fillStruct(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		name, opts := parseTag(f.Tag.Get("json"))
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			d.fillStruct(schema, ft)
+
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := d.schemaForType(f.Type)
+		if prop.Ref == "" {
+			if required := applyValidateTag(prop, f.Tag.Get("validate")); required {
+				schema.Required = append(schema.Required, name)
+			}
+		} else if hasValidateRule(f.Tag.Get("validate"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+
+		if strings.Contains(strings.ToLower(name), "password") {
+			prop.WriteOnly = true
+		}
+
+		schema.Properties[name] = prop
+	}
+}
+
+// applyValidateTag 将 validate 标签中的规则转换为 Schema 约束，返回字段是否必填。
+func applyValidateTag(schema *Schema, tag string) bool {
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, value = rule[:i], rule[i+1:]
+		}
+
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				schema.Enum = append(schema.Enum, enumValue(schema, v))
+			}
+		case "min", "gte":
+			applyBound(schema, value, true)
+		case "max", "lte":
+			applyBound(schema, value, false)
+		case "len":
+			applyBound(schema, value, true)
+			applyBound(schema, value, false)
+		}
+	}
+
+	return required
+}
+
+func applyBound(schema *Schema, value string, lower bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		l := int64(n)
+		if lower {
+			schema.MinLength = &l
+		} else {
+			schema.MaxLength = &l
+		}
+	case "array":
+		l := int64(n)
+		if lower {
+			schema.MinItems = &l
+		} else {
+			schema.MaxItems = &l
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum = &n
+		} else {
+			schema.Maximum = &n
+		}
+	}
+}
+
+func enumValue(schema *Schema, v string) interface{} {
+	switch schema.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+
+	return v
+}
+
+func hasValidateRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseTag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+
+	return tag, ""
+}
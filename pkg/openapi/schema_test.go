@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMeta struct {
+	Name      string    `json:"name,omitempty" validate:"required,min=2,max=20"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+type testUser struct {
+	testMeta `json:",inline"`
+
+	Email    string            `json:"email"              validate:"required,email"`
+	Password string            `json:"password,omitempty" validate:"required"`
+	Age      int               `json:"age"                validate:"omitempty,min=0,max=150"`
+	Role     string            `json:"role"               validate:"oneof=admin user"`
+	Tags     []string          `json:"tags,omitempty"     validate:"max=10"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Friend   *testUser         `json:"friend,omitempty"`
+	internal string
+	Ignored  string `json:"-"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	d := NewDocument(Info{Title: "test", Version: "v1"})
+
+	ref := d.SchemaFor(&testUser{})
+	assert.Equal(t, "#/components/schemas/testUser", ref.Ref)
+
+	s := d.Components.Schemas["testUser"]
+	require.NotNil(t, s)
+	assert.ElementsMatch(t, []string{"name", "email", "password"}, s.Required)
+	assert.NotContains(t, s.Properties, "internal")
+	assert.NotContains(t, s.Properties, "Ignored")
+
+	assert.Equal(t, int64(2), *s.Properties["name"].MinLength)
+	assert.Equal(t, int64(20), *s.Properties["name"].MaxLength)
+	assert.Equal(t, "date-time", s.Properties["createdAt"].Format)
+	assert.Equal(t, "email", s.Properties["email"].Format)
+	assert.True(t, s.Properties["password"].WriteOnly)
+	assert.Equal(t, float64(150), *s.Properties["age"].Maximum)
+	assert.Equal(t, []interface{}{"admin", "user"}, s.Properties["role"].Enum)
+	assert.Equal(t, int64(10), *s.Properties["tags"].MaxItems)
+	assert.Equal(t, "string", s.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, ref.Ref, s.Properties["friend"].Ref)
+}
+
+func TestAddOperation(t *testing.T) {
+	d := NewDocument(Info{Title: "test", Version: "v1"})
+	d.AddOperation("GET", "/v1/users/:name", &Operation{})
+
+	item := d.Paths["/v1/users/{name}"]
+	require.NotNil(t, item)
+
+	op := (*item)["get"]
+	require.NotNil(t, op)
+	require.Len(t, op.Parameters, 1)
+	assert.Equal(t, "name", op.Parameters[0].Name)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.True(t, op.Parameters[0].Required)
+}
+
+func TestErrorResponses(t *testing.T) {
+	responses := ErrorResponses(&Schema{Ref: "#/components/schemas/ErrResponse"},
+		ErrorCode{Code: 100003, HTTPStatus: 400, Message: "Bind failed"},
+		ErrorCode{Code: 100004, HTTPStatus: 400, Message: "Validation failed"},
+		ErrorCode{Code: 100101, HTTPStatus: 500, Message: "Database error"},
+	)
+
+	require.Len(t, responses, 2)
+	assert.Equal(t, "Bad Request\n- 100003: Bind failed\n- 100004: Validation failed", responses["400"].Description)
+	assert.Equal(t, "Internal Server Error\n- 100101: Database error", responses["500"].Description)
+}
@@ -0,0 +1,242 @@
+// Package scheme 维护 API 版本与各版本类型的注册信息，并在各版本的类型和 hub 类型之间转换。
+//
+// 每个 kind 有一个 hub 类型（存储使用的类型），其他版本的类型实现 Convertible，
+// 只需要实现与 hub 之间的转换，不需要实现版本之间两两转换。
+package scheme
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/tiandh987/SharkAgent/pkg/validation/field"
+)
+
+// Hub 标记一个类型为 kind 的 hub 类型，其他版本的类型都与它相互转换。
+type Hub interface {
+	Hub()
+}
+
+// Convertible 由非 hub 版本的类型实现。
+type Convertible interface {
+	// ConvertTo 将对象转换为 hub 类型。
+	ConvertTo(hub Hub) error
+	// ConvertFrom 使用 hub 类型填充对象。
+	ConvertFrom(hub Hub) error
+}
+
+// Defaulter 由需要设置默认值的类型实现，在转换为 hub 类型之前调用。
+type Defaulter interface {
+	Default()
+}
+
+// Validator 由需要校验的类型实现，每个版本按照自己的字段校验。
+type Validator interface {
+	Validate() field.ErrorList
+	ValidateUpdate() field.ErrorList
+}
+
+// Version 描述一个 API 版本。
+type Version struct {
+	// Name 是版本名，同时是 URL 前缀，例如 v1。
+	Name string
+	// Deprecated 为 true 时，该版本的响应带有 Deprecation 响应头。
+	Deprecated bool
+	// DeprecatedAt 是版本被废弃的时间，为零值时使用 Deprecation: true。
+	DeprecatedAt time.Time
+	// Sunset 是版本计划下线的时间，为零值时不返回 Sunset 响应头。
+	Sunset time.Time
+	// Successor 是替代该版本的版本名，版本废弃且没有设置时为最后注册的未废弃版本。
+	Successor string
+}
+
+// Scheme 保存已注册的版本和类型。Scheme 在注册完成后只读，可以并发使用。
+type Scheme struct {
+	versions map[string]*Version
+	order    []string
+	types    map[string]map[string]reflect.Type
+	kinds    map[reflect.Type]string
+}
+
+// NewScheme creates an empty Scheme.
+func NewScheme() *Scheme {
+	return &Scheme{
+		versions: map[string]*Version{},
+		types:    map[string]map[string]reflect.Type{},
+		kinds:    map[reflect.Type]string{},
+	}
+}
+
+// AddVersion 注册一个版本，重复注册时覆盖之前的版本信息。
+func (s *Scheme) AddVersion(v Version) {
+	if _, ok := s.versions[v.Name]; !ok {
+		s.order = append(s.order, v.Name)
+		s.types[v.Name] = map[string]reflect.Type{}
+	}
+
+	s.versions[v.Name] = &v
+}
+
+// AddKnownTypes 注册版本的类型，kind 为类型名。types 必须是结构体指针，版本必须已经注册。
+func (s *Scheme) AddKnownTypes(version string, types ...interface{}) {
+	for _, obj := range types {
+		t := reflect.TypeOf(obj)
+		if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			panic(fmt.Sprintf("type %T must be a pointer to struct", obj))
+		}
+
+		s.AddKnownTypeWithName(version, t.Elem().Name(), obj)
+	}
+}
+
+// AddKnownTypeWithName 使用指定的 kind 注册版本的类型。同一个 kind 在各版本中表示同一种资源。
+func (s *Scheme) AddKnownTypeWithName(version, kind string, obj interface{}) {
+	known, ok := s.types[version]
+	if !ok {
+		panic(fmt.Sprintf("version %s is not registered", version))
+	}
+
+	t := reflect.TypeOf(obj)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("type %T must be a pointer to struct", obj))
+	}
+
+	known[kind] = t.Elem()
+	s.kinds[t.Elem()] = kind
+}
+
+// Deprecate 将已注册的版本标记为废弃。
+func (s *Scheme) Deprecate(version string, deprecatedAt, sunset time.Time) error {
+	v, ok := s.versions[version]
+	if !ok {
+		return fmt.Errorf("version %s is not registered", version)
+	}
+
+	v.Deprecated = true
+	v.DeprecatedAt = deprecatedAt
+	v.Sunset = sunset
+
+	return nil
+}
+
+// Version 返回已注册的版本。
+func (s *Scheme) Version(name string) (Version, bool) {
+	if _, ok := s.versions[name]; !ok {
+		return Version{}, false
+	}
+
+	return s.version(name), true
+}
+
+// Versions 按注册顺序返回所有版本。
+func (s *Scheme) Versions() []Version {
+	versions := make([]Version, 0, len(s.order))
+	for _, name := range s.order {
+		versions = append(versions, s.version(name))
+	}
+
+	return versions
+}
+
+func (s *Scheme) version(name string) Version {
+	v := *s.versions[name]
+	if !v.Deprecated || v.Successor != "" {
+		return v
+	}
+
+	for _, n := range s.order {
+		if !s.versions[n].Deprecated {
+			v.Successor = n
+		}
+	}
+
+	return v
+}
+
+// New 创建版本中 kind 对应类型的对象。
+func (s *Scheme) New(version, kind string) (interface{}, error) {
+	t, ok := s.types[version][kind]
+	if !ok {
+		return nil, fmt.Errorf("kind %s is not registered in version %s", kind, version)
+	}
+
+	return reflect.New(t).Interface(), nil
+}
+
+// Default 在对象实现 Defaulter 时设置默认值。
+func (s *Scheme) Default(obj interface{}) {
+	if d, ok := obj.(Defaulter); ok {
+		d.Default()
+	}
+}
+
+// Validate 在对象实现 Validator 时校验创建请求。
+func (s *Scheme) Validate(obj interface{}) field.ErrorList {
+	if v, ok := obj.(Validator); ok {
+		return v.Validate()
+	}
+
+	return nil
+}
+
+// ValidateUpdate 在对象实现 Validator 时校验更新请求。
+func (s *Scheme) ValidateUpdate(obj interface{}) field.ErrorList {
+	if v, ok := obj.(Validator); ok {
+		return v.ValidateUpdate()
+	}
+
+	return nil
+}
+
+// ToHub 将任意版本的对象转换为 hub 类型，in 本身是 hub 类型时直接复制。
+func (s *Scheme) ToHub(in interface{}, hub Hub) error {
+	if copyHub(in, hub) {
+		return nil
+	}
+
+	c, ok := in.(Convertible)
+	if !ok {
+		return fmt.Errorf("type %T can not be converted to %T", in, hub)
+	}
+
+	return c.ConvertTo(hub)
+}
+
+// FromHub 将 hub 类型的对象转换为指定版本中相同 kind 的对象。
+func (s *Scheme) FromHub(hub Hub, version string) (interface{}, error) {
+	kind, ok := s.kinds[reflect.TypeOf(hub).Elem()]
+	if !ok {
+		return nil, fmt.Errorf("type %T is not registered", hub)
+	}
+
+	out, err := s.New(version, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if copyHub(hub, out) {
+		return out, nil
+	}
+
+	c, ok := out.(Convertible)
+	if !ok {
+		return nil, fmt.Errorf("type %T can not be converted from %T", out, hub)
+	}
+
+	if err := c.ConvertFrom(hub); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// copyHub 在 in 和 out 是相同类型时将 in 复制到 out。
+func copyHub(in, out interface{}) bool {
+	if reflect.TypeOf(in) != reflect.TypeOf(out) {
+		return false
+	}
+
+	reflect.ValueOf(out).Elem().Set(reflect.ValueOf(in).Elem())
+
+	return true
+}
@@ -0,0 +1,82 @@
+package scheme
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hubObject struct {
+	Name string
+}
+
+func (*hubObject) Hub() {}
+
+type spokeObject struct {
+	FullName string
+}
+
+func (o *spokeObject) ConvertTo(hub Hub) error {
+	hub.(*hubObject).Name = o.FullName
+
+	return nil
+}
+
+func (o *spokeObject) ConvertFrom(hub Hub) error {
+	o.FullName = hub.(*hubObject).Name
+
+	return nil
+}
+
+func TestConvert(t *testing.T) {
+	s := NewScheme()
+	s.AddVersion(Version{Name: "v1"})
+	s.AddVersion(Version{Name: "v2"})
+	s.AddKnownTypeWithName("v1", "Object", &hubObject{})
+	s.AddKnownTypeWithName("v2", "Object", &spokeObject{})
+
+	hub := &hubObject{}
+	require.NoError(t, s.ToHub(&spokeObject{FullName: "colin"}, hub))
+	assert.Equal(t, "colin", hub.Name)
+
+	copied := &hubObject{}
+	require.NoError(t, s.ToHub(hub, copied))
+	assert.Equal(t, hub, copied)
+
+	out, err := s.FromHub(hub, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, hub, out)
+
+	out, err = s.FromHub(hub, "v2")
+	require.NoError(t, err)
+	assert.Equal(t, &spokeObject{FullName: "colin"}, out)
+
+	_, err = s.FromHub(hub, "v3")
+	assert.Error(t, err)
+
+	assert.Error(t, s.ToHub(struct{}{}, hub))
+}
+
+func TestDeprecate(t *testing.T) {
+	s := NewScheme()
+	s.AddVersion(Version{Name: "v1"})
+	s.AddVersion(Version{Name: "v2"})
+
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Deprecate("v1", time.Time{}, sunset))
+	assert.Error(t, s.Deprecate("v3", time.Time{}, time.Time{}))
+
+	v, ok := s.Version("v1")
+	require.True(t, ok)
+	assert.True(t, v.Deprecated)
+	assert.Equal(t, sunset, v.Sunset)
+	assert.Equal(t, "v2", v.Successor)
+
+	names := []string{}
+	for _, v := range s.Versions() {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"v1", "v2"}, names)
+}
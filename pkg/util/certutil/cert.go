@@ -0,0 +1,217 @@
+// Package certutil 提供生成、读取、持久化 TLS 证书的工具函数。
+package certutil
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// CertificateBlockType is a possible value for pem.Block.Type.
+	CertificateBlockType = "CERTIFICATE"
+	// ECPrivateKeyBlockType is a possible value for pem.Block.Type.
+	ECPrivateKeyBlockType = "EC PRIVATE KEY"
+
+	// duration365d 是自签名证书的有效期。
+	duration365d = time.Hour * 24 * 365
+)
+
+// GenerateSelfSignedCertKey 创建一个自签名的 CA，并用它签发一个服务端证书。
+// host 会作为服务端证书的 CommonName，若它是 IP 则同时加入 IP SAN，否则加入 DNS SAN。
+// 返回值依次为：PEM 编码的服务端证书（后接 CA 证书组成证书链）、PEM 编码的服务端私钥、PEM 编码的 CA 证书。
+func GenerateSelfSignedCertKey(host string, alternateIPs []net.IP, alternateDNS []string) ([]byte, []byte, []byte, error) {
+	validFrom := time.Now().Add(-time.Hour) // valid an hour earlier to avoid flakes due to clock skew
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	caSerial, err := randomSerialNumber()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	caTemplate := x509.Certificate{
+		SerialNumber: caSerial,
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("%s-ca@%d", host, time.Now().Unix()),
+		},
+		NotBefore:             validFrom,
+		NotAfter:              validFrom.Add(duration365d),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDERBytes, err := x509.CreateCertificate(rand.Reader, &caTemplate, &caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	caCertificate, err := x509.ParseCertificate(caDERBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("%s@%d", host, time.Now().Unix()),
+		},
+		NotBefore:             validFrom,
+		NotAfter:              validFrom.Add(duration365d),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = uniqueIPs(append([]net.IP{ip}, alternateIPs...))
+		template.DNSNames = uniqueNames(alternateDNS)
+	} else {
+		template.IPAddresses = uniqueIPs(alternateIPs)
+		template.DNSNames = uniqueNames(append([]string{host}, alternateDNS...))
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, caCertificate, &priv.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	privBytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Generate cert, followed by ca
+	certBuffer := bytes.Buffer{}
+	if err := pem.Encode(&certBuffer, &pem.Block{Type: CertificateBlockType, Bytes: derBytes}); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := pem.Encode(&certBuffer, &pem.Block{Type: CertificateBlockType, Bytes: caDERBytes}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Generate key
+	keyBuffer := bytes.Buffer{}
+	if err := pem.Encode(&keyBuffer, &pem.Block{Type: ECPrivateKeyBlockType, Bytes: privBytes}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Generate ca
+	caBuffer := bytes.Buffer{}
+	if err := pem.Encode(&caBuffer, &pem.Block{Type: CertificateBlockType, Bytes: caDERBytes}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return certBuffer.Bytes(), keyBuffer.Bytes(), caBuffer.Bytes(), nil
+}
+
+// uniqueIPs 返回去重后的 IP，保持原有顺序。
+func uniqueIPs(ips []net.IP) []net.IP {
+	var result []net.IP
+	seen := map[string]bool{}
+
+	for _, ip := range ips {
+		if key := ip.String(); !seen[key] {
+			seen[key] = true
+			result = append(result, ip)
+		}
+	}
+
+	return result
+}
+
+// uniqueNames 返回去重后的 DNS 名称，DNS 名称不区分大小写，保持原有顺序。
+func uniqueNames(names []string) []string {
+	var result []string
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+func randomSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+}
+
+// CanReadCertAndKey 判断证书和私钥文件是否存在且可读。
+// 只有一个文件存在时返回错误。
+func CanReadCertAndKey(certPath, keyPath string) (bool, error) {
+	certReadable := canReadFile(certPath)
+	keyReadable := canReadFile(keyPath)
+
+	if !certReadable && !keyReadable {
+		return false, nil
+	}
+
+	if !certReadable {
+		return false, fmt.Errorf("error reading %s, certificate and key must be supplied as a pair", certPath)
+	}
+
+	if !keyReadable {
+		return false, fmt.Errorf("error reading %s, certificate and key must be supplied as a pair", keyPath)
+	}
+
+	return true, nil
+}
+
+func canReadFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+
+	defer f.Close()
+
+	return true
+}
+
+// WriteCert 将 PEM 编码的证书写入指定路径，会自动创建父目录。
+func WriteCert(certPath string, data []byte) error {
+	return writeFile(certPath, data, os.FileMode(0o644))
+}
+
+// WriteKey 将 PEM 编码的私钥写入指定路径，会自动创建父目录，私钥文件只对属主可读写。
+func WriteKey(keyPath string, data []byte) error {
+	return writeFile(keyPath, data, os.FileMode(0o600))
+}
+
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0o755)); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, perm)
+}
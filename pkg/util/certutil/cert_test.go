@@ -0,0 +1,69 @@
+package certutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSelfSignedCertKey(t *testing.T) {
+	certPEM, keyPEM, caPEM, err := GenerateSelfSignedCertKey("localhost",
+		[]net.IP{net.ParseIP("127.0.0.1")}, []string{"iam.example.com"})
+	require.NoError(t, err)
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caPEM))
+
+	for _, name := range []string{"localhost", "iam.example.com", "127.0.0.1"} {
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: pool})
+		assert.NoError(t, err, name)
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "other.example.com", Roots: pool})
+	assert.Error(t, err)
+}
+
+func TestGenerateSelfSignedCertKey_UniqueSANs(t *testing.T) {
+	certPEM, keyPEM, _, err := GenerateSelfSignedCertKey("localhost",
+		[]net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.1")}, []string{"localhost", "LOCALHOST", "iam.example.com"})
+	require.NoError(t, err)
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"localhost", "iam.example.com"}, leaf.DNSNames)
+	require.Len(t, leaf.IPAddresses, 1)
+	assert.Equal(t, "127.0.0.1", leaf.IPAddresses[0].String())
+}
+
+func TestCanReadCertAndKey(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "iam.crt"), filepath.Join(dir, "iam.key")
+
+	ok, err := CanReadCertAndKey(certPath, keyPath)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	require.NoError(t, WriteCert(certPath, []byte("cert")))
+	_, err = CanReadCertAndKey(certPath, keyPath)
+	assert.Error(t, err)
+
+	require.NoError(t, WriteKey(keyPath, []byte("key")))
+	ok, err = CanReadCertAndKey(certPath, keyPath)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
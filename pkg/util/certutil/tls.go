@@ -0,0 +1,109 @@
+package certutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"VersionTLS10": tls.VersionTLS10,
+	"VersionTLS11": tls.VersionTLS11,
+	"VersionTLS12": tls.VersionTLS12,
+	"VersionTLS13": tls.VersionTLS13,
+}
+
+var curves = map[string]tls.CurveID{
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+	"X25519": tls.X25519,
+}
+
+// TLSVersion 将 VersionTLS10、VersionTLS11、VersionTLS12、VersionTLS13 转换为 tls 包中对应的常量。
+// 空字符串返回 0，表示使用 Go 的默认值。
+func TLSVersion(name string) (uint16, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	if version, ok := tlsVersions[name]; ok {
+		return version, nil
+	}
+
+	return 0, fmt.Errorf("unknown tls version %q, allowed values: %s", name, strings.Join(TLSVersionNames(), ", "))
+}
+
+// TLSVersionNames 返回支持的 TLS 版本名称。
+func TLSVersionNames() []string {
+	names := make([]string, 0, len(tlsVersions))
+	for name := range tlsVersions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// CipherSuites 将 IANA 定义的密码套件名称（例如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256）
+// 转换为 tls 包中对应的 ID。只接受 Go 认为安全的密码套件。
+func CipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	supported := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		supported[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := supported[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// CurvePreferences 将 P256、P384、P521、X25519 转换为 tls.CurveID。
+func CurvePreferences(names []string) ([]tls.CurveID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]tls.CurveID, 0, len(names))
+	for _, name := range names {
+		id, ok := curves[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve %q, allowed values: P256, P384, P521, X25519", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// NewPoolFromFile 从 PEM 编码的 CA 证书文件中创建 x509.CertPool。
+func NewPoolFromFile(filename string) (*x509.CertPool, error) {
+	pemBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("no valid certificate found in %s", filename)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,79 @@
+package certutil
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSVersion(t *testing.T) {
+	version, err := TLSVersion("VersionTLS12")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), version)
+
+	version, err = TLSVersion("")
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	_, err = TLSVersion("TLS1.2")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"VersionTLS10", "VersionTLS11", "VersionTLS12", "VersionTLS13"}, TLSVersionNames())
+}
+
+func TestCipherSuites(t *testing.T) {
+	ids, err := CipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_AES_128_GCM_SHA256"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_AES_128_GCM_SHA256}, ids)
+
+	ids, err = CipherSuites(nil)
+	require.NoError(t, err)
+	assert.Nil(t, ids)
+
+	// 不安全的密码套件不被接受
+	_, err = CipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	assert.Error(t, err)
+
+	_, err = CipherSuites([]string{"unknown"})
+	assert.Error(t, err)
+}
+
+func TestCurvePreferences(t *testing.T) {
+	ids, err := CurvePreferences([]string{"X25519", "P256"})
+	require.NoError(t, err)
+	assert.Equal(t, []tls.CurveID{tls.X25519, tls.CurveP256}, ids)
+
+	ids, err = CurvePreferences(nil)
+	require.NoError(t, err)
+	assert.Nil(t, ids)
+
+	_, err = CurvePreferences([]string{"P224"})
+	assert.Error(t, err)
+}
+
+func TestNewPoolFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	_, _, caPEM, err := GenerateSelfSignedCertKey("localhost", nil, nil)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, WriteCert(caFile, caPEM))
+
+	pool, err := NewPoolFromFile(caFile)
+	require.NoError(t, err)
+	assert.NotNil(t, pool)
+
+	invalid := filepath.Join(dir, "invalid.crt")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte("not a certificate"), 0o600))
+
+	_, err = NewPoolFromFile(invalid)
+	assert.Error(t, err)
+
+	_, err = NewPoolFromFile(filepath.Join(dir, "missing.crt"))
+	assert.Error(t, err)
+}
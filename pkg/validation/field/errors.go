@@ -2,9 +2,8 @@ package field
 
 import (
 	"fmt"
-	utilerrors "github.com/tiandh987/SharkAgent/pkg/errors"
 
-	"github.com/tiandh987/SharkAgent/pkg/util/sets"
+	"github.com/marmotedu/errors"
 )
 
 // TODO: These values are duplicated in api/types.go, but there's a circular dep.  Fix it.
@@ -12,6 +11,10 @@ const (
 	// ErrorTypeInvalid is used to report malformed values (e.g. failed regex
 	// match, too long, out of bounds).  See Invalid().
 	ErrorTypeInvalid ErrorType = "FieldValueInvalid"
+	// ErrorTypeRequired is used to report required values that are not
+	// provided (e.g. empty strings, null values, or empty arrays).  See
+	// Required().
+	ErrorTypeRequired ErrorType = "FieldValueRequired"
 )
 
 // Error is an implementation of the 'error' interface, which represents a
@@ -23,6 +26,11 @@ type Error struct {
 	Detail   string
 }
 
+// Error implements the error interface.
+func (v *Error) Error() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Detail)
+}
+
 // ErrorType is a machine readable value providing more detail about why
 // a field is invalid.  These values are expected to match 1-1 with
 // CauseType in api/types.go.
@@ -34,18 +42,18 @@ type ErrorType string
 type ErrorList []*Error
 
 // ToAggregate converts the ErrorList into an errors.Aggregate.
-func (list ErrorList) ToAggregate() utilerrors.Aggregate {
+func (list ErrorList) ToAggregate() errors.Aggregate {
 	errs := make([]error, 0, len(list))
-	errorMsgs := sets.NewString()
+	errorMsgs := make(map[string]struct{}, len(list))
 	for _, err := range list {
 		msg := fmt.Sprintf("%v", err)
-		if errorMsgs.Has(msg) {
+		if _, ok := errorMsgs[msg]; ok {
 			continue
 		}
-		errorMsgs.Insert(msg)
+		errorMsgs[msg] = struct{}{}
 		errs = append(errs, err)
 	}
-	return utilerrors.NewAggregate(errs)
+	return errors.NewAggregate(errs)
 }
 
 // Invalid returns a *Error indicating "invalid value".  This is used
@@ -54,4 +62,9 @@ func Invalid(field *Path, value interface{}, detail string) *Error {
 	return &Error{ErrorTypeInvalid, field.String(), value, detail}
 }
 
-
+// Required returns a *Error indicating "value required".  This is used
+// to report required values that are not provided (e.g. empty strings, null
+// values, or empty arrays).
+func Required(field *Path, detail string) *Error {
+	return &Error{ErrorTypeRequired, field.String(), "", detail}
+}
@@ -3,6 +3,7 @@ package field
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 )
 
 // Path represents the path from some root to a particular field.
@@ -21,6 +22,23 @@ func NewPath(name string, moreNames ...string) *Path {
 	return r
 }
 
+// Child creates a new Path that is a child of the method receiver.
+func (p *Path) Child(name string, moreNames ...string) *Path {
+	r := NewPath(name, moreNames...)
+	root := r
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.parent = p
+
+	return r
+}
+
+// Index indicates that the previous Path is to be subscripted by an int.
+func (p *Path) Index(index int) *Path {
+	return &Path{index: strconv.Itoa(index), parent: p}
+}
+
 // String produces a string representation of the Path.
 func (p *Path) String() string {
 	// make a slice to iterate
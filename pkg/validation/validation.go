@@ -9,6 +9,7 @@ import (
 	"github.com/tiandh987/SharkAgent/pkg/validation/field"
 	"os"
 	"reflect"
+	"strings"
 )
 
 const (
@@ -44,7 +45,14 @@ func (v *Validator) Validate() field.ErrorList {
 	// collect human-readable errors
 	vErrors, _ := err.(validator.ValidationErrors)
 	for _, vErr := range vErrors {
-		allErrs = append(allErrs, field.Invalid(field.NewPath(vErr.Namespace()), vErr.Translate(v.trans), ""))
+		path := field.NewPath(fieldPath(vErr.Namespace()))
+		if vErr.Tag() == "required" {
+			allErrs = append(allErrs, field.Required(path, vErr.Translate(v.trans)))
+
+			continue
+		}
+
+		allErrs = append(allErrs, field.Invalid(path, vErr.Value(), vErr.Translate(v.trans)))
 	}
 
 	return allErrs
@@ -54,6 +62,9 @@ func (v *Validator) Validate() field.ErrorList {
 func NewValidator(data interface{}) *Validator {
 	result := validator.New()
 
+	// 使用 json 标签作为字段名，使错误中的字段路径与 API 中的字段一致
+	result.RegisterTagNameFunc(jsonFieldName)
+
 	// independent validators
 	result.RegisterValidation("dir", validateDir)                 // nolint: errcheck // no need
 	result.RegisterValidation("file", validateFile)               // nolint: errcheck // no need
@@ -125,6 +136,28 @@ func translateFunc(ut ut.Translator, fe validator.FieldError) string {
 	return t
 }
 
+// jsonFieldName returns the json name of the struct field, or the field name if there is no json tag.
+func jsonFieldName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return fld.Name
+	}
+
+	return name
+}
+
+// fieldPath trims the top-level struct name from the validator namespace, e.g. User.metadata.name -> metadata.name.
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
 // validateDir checks if a given string is an existing directory.
 func validateDir(fl validator.FieldLevel) bool {
 	path := fl.Field().String()
@@ -33,4 +33,19 @@ gen.ca.%:
 #	把前缀<prefix>加到<names>中的每个单词前面，并返回加过前缀的文件名序列
 # 相当于是 gen.ca: gen.ca.iam-apiserver gen.ca.iam-auth-server gen.ca.admin
 .PHONY: gen.ca
-gen.ca: $(addprefix gen.ca., ${CERTIFICATES})
\ No newline at end of file
+gen.ca: $(addprefix gen.ca., ${CERTIFICATES})
+
+# 根据 apiserver 的路由、api/apiserver/v1 中的类型和错误码生成 OpenAPI 3 文档，
+# 可用于生成 TypeScript 等语言的客户端和发布 API 文档
+.PHONY: gen.openapi
+gen.openapi:
+	@echo "==============> Generating OpenAPI specification"
+	@$(GO) run ${ROOT_DIR}/tools/openapi-gen -output ${ROOT_DIR}/api/openapi/apiserver.json
+
+# 根据 api/proto 下的 .proto 文件生成 Go 代码，需要安装 protoc、protoc-gen-go 和 protoc-gen-go-grpc
+.PHONY: gen.protobuf
+gen.protobuf:
+	@echo "==============> Generating protobuf files"
+	@protoc --proto_path=${ROOT_DIR} --go_out=${ROOT_DIR} --go_opt=paths=source_relative \
+		--go-grpc_out=${ROOT_DIR} --go-grpc_opt=paths=source_relative \
+		$(wildcard ${ROOT_DIR}/api/proto/apiserver/v1/*.proto)
@@ -3,6 +3,9 @@ GO := go
 
 GO_SUPPORTED_VERSIONS ?= 1.13|1.14|1.15|1.16|1.17
 
+# sqlite 存储（--store.driver=sqlite）依赖 cgo，需要时使用 make build CGO_ENABLED=1 编译
+CGO_ENABLED ?= 0
+
 # 应用版本信息
 GO_LDFLAGS += -X ${VERSION_PACKAGE}.GitVersion=${VERSION} \
 	-X $(VERSION_PACKAGE).GitCommit=$(GIT_COMMIT) \
@@ -53,7 +56,7 @@ go.build.%:
 	$(eval ARCH := $(word 2,$(subst _, ,${PLATFORM})))
 	@echo "===========> Building binary ${COMMAND} ${VERSION} for ${OS} ${ARCH}"
 	@mkdir -p ${OUTPUT_DIR}/platforms/${OS}/${ARCH}
-	@CGO_ENABLED=0 GOOS=${OS} GOARCH=${ARCH} ${GO} build ${GO_BUILD_FLAGS} -o ${OUTPUT_DIR}/platforms/${OS}/${ARCH}/${COMMAND}${GO_OUT_EXT} ${ROOT_PACKAGE}/cmd/${COMMAND}
+	@CGO_ENABLED=${CGO_ENABLED} GOOS=${OS} GOARCH=${ARCH} ${GO} build ${GO_BUILD_FLAGS} -o ${OUTPUT_DIR}/platforms/${OS}/${ARCH}/${COMMAND}${GO_OUT_EXT} ${ROOT_PACKAGE}/cmd/${COMMAND}
 
 
 
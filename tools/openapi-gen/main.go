@@ -0,0 +1,61 @@
+// openapi-gen 根据 apiserver 注册的路由、api/apiserver/v1 中的类型和错误码生成 OpenAPI 3 文档。
+//
+//	$ go run ./tools/openapi-gen -output api/openapi/apiserver.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tiandh987/SharkAgent/internal/apiserver"
+)
+
+// 输出文件名; 为空时输出到标准输出
+var output = flag.String("output", "", "output file name; default stdout")
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("openapi-gen: ")
+
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage of openapi-gen:\n")
+		fmt.Fprintf(os.Stderr, "\topenapi-gen [flags]\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// 避免 gin 的调试日志混入输出的文档
+	gin.SetMode(gin.ReleaseMode)
+
+	doc, err := apiserver.OpenAPIDocument()
+	if err != nil {
+		log.Fatalf("generating document: %s", err)
+	}
+
+	src, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("encoding document: %s", err)
+	}
+	src = append(src, '\n')
+
+	if *output == "" {
+		_, _ = os.Stdout.Write(src)
+
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*output), 0o755); err != nil {
+		log.Fatalf("creating output directory: %s", err)
+	}
+
+	if err := ioutil.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatalf("writing output: %s", err)
+	}
+}